@@ -0,0 +1,60 @@
+package main
+
+import (
+	"flag"
+	"math"
+
+	"github.com/certusone/solana_exporter/pkg/rpc"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var emitAbsentZero = flag.Bool("emit-absent-zero", false,
+	"Keep exporting the vote account series of -votepubkey validators missing from getVoteAccounts")
+
+// updateWatchedNodes remembers the node identity of every watched vote account in the response and returns
+// the watched vote accounts missing from it, along with their last known identity. Validators that were
+// never seen have an empty identity.
+func (c *solanaCollector) updateWatchedNodes(cfg runtimeConfig, response *rpc.GetVoteAccountsResponse) []rpc.VoteAccount {
+	c.watchedNodesMu.Lock()
+	defer c.watchedNodesMu.Unlock()
+
+	seen := make(map[string]bool)
+	for _, account := range append(response.Result.Current, response.Result.Delinquent...) {
+		if cfg.isWatched(account.VotePubkey) {
+			c.watchedNodes[account.VotePubkey] = account.NodePubkey
+			seen[account.VotePubkey] = true
+		}
+	}
+
+	var missing []rpc.VoteAccount
+	for _, pubkey := range cfg.watched {
+		if !seen[pubkey] {
+			missing = append(missing, rpc.VoteAccount{VotePubkey: pubkey, NodePubkey: c.watchedNodes[pubkey]})
+		}
+	}
+
+	return missing
+}
+
+// emitAbsentValidators exports placeholder vote account series for watched validators missing from the
+// response, so that alerts see a value instead of a gap. Counts are exported as zero, while slots and the
+// delinquency flag, for which zero would be a misleading value, are exported as NaN.
+func (c *solanaCollector) emitAbsentValidators(ch chan<- prometheus.Metric, cfg runtimeConfig,
+	response *rpc.GetVoteAccountsResponse, versions map[string]string) {
+	missing := c.updateWatchedNodes(cfg, response)
+	if !cfg.emitAbsentZero {
+		return
+	}
+
+	for _, account := range missing {
+		labels := c.validatorLabelValues(account, versions)
+		ch <- prometheus.MustNewConstMetric(c.validatorOwned, prometheus.GaugeValue,
+			1, account.VotePubkey, account.NodePubkey)
+		ch <- prometheus.MustNewConstMetric(c.validatorActivatedStake, prometheus.GaugeValue, 0, labels...)
+		ch <- prometheus.MustNewConstMetric(c.validatorEpochCredits, prometheus.GaugeValue, 0, labels...)
+		ch <- prometheus.MustNewConstMetric(c.validatorLastVote, prometheus.GaugeValue, math.NaN(), labels...)
+		ch <- prometheus.MustNewConstMetric(c.validatorVoteDistance, prometheus.GaugeValue, math.NaN(), labels...)
+		ch <- prometheus.MustNewConstMetric(c.validatorRootSlot, prometheus.GaugeValue, math.NaN(), labels...)
+		ch <- prometheus.MustNewConstMetric(c.validatorDelinquent, prometheus.GaugeValue, math.NaN(), labels...)
+	}
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"math"
+	"testing"
+
+	"github.com/certusone/solana_exporter/pkg/rpc"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestEmitAbsentValidators(t *testing.T) {
+	defer func(v string) { *votePubkey = v }(*votePubkey)
+	defer func(v bool) { *emitAbsentZero = v }(*emitAbsentZero)
+	*votePubkey = "vote1,vote9"
+
+	present := &rpc.GetVoteAccountsResponse{}
+	present.Result.Current = []rpc.VoteAccount{{VotePubkey: "vote1", NodePubkey: "node1"}}
+	gone := &rpc.GetVoteAccountsResponse{}
+
+	for _, enabled := range []bool{false, true} {
+		*emitAbsentZero = enabled
+		c := NewSolanaCollector("http://localhost:8899", rpc.CommitmentProcessed)
+
+		got := emitted(t, func(ch chan<- prometheus.Metric) { c.emitAbsentValidators(ch, loadRuntimeConfig(), present, nil) })
+		// vote9 was never seen, so its identity is unknown.
+		stake, ok := got[`solana_validator_activated_stake{nodekey="",pubkey="vote9"}`]
+		if ok != enabled || stake != 0 {
+			t.Errorf("-emit-absent-zero=%v: vote9 stake = %v (present %v)", enabled, stake, ok)
+		}
+		if _, ok := got[`solana_validator_activated_stake{nodekey="node1",pubkey="vote1"}`]; ok {
+			t.Errorf("-emit-absent-zero=%v: placeholder emitted for vote1, which is in the response", enabled)
+		}
+
+		// Once vote1 disappears, it is exported with its last known identity.
+		got = emitted(t, func(ch chan<- prometheus.Metric) { c.emitAbsentValidators(ch, loadRuntimeConfig(), gone, nil) })
+		if !enabled {
+			if len(got) != 0 {
+				t.Errorf("-emit-absent-zero=false: emitted %v", got)
+			}
+			continue
+		}
+		want := map[string]float64{
+			`solana_validator_owned{nodekey="node1",pubkey="vote1"}`:           1,
+			`solana_validator_activated_stake{nodekey="node1",pubkey="vote1"}`: 0,
+			`solana_validator_epoch_credits{nodekey="node1",pubkey="vote1"}`:   0,
+			`solana_validator_last_vote{nodekey="node1",pubkey="vote1"}`:       math.NaN(),
+			`solana_validator_vote_distance{nodekey="node1",pubkey="vote1"}`:   math.NaN(),
+			`solana_validator_root_slot{nodekey="node1",pubkey="vote1"}`:       math.NaN(),
+			`solana_validator_delinquent{nodekey="node1",pubkey="vote1"}`:      math.NaN(),
+			`solana_validator_activated_stake{nodekey="",pubkey="vote9"}`:      0,
+		}
+		for key, value := range want {
+			v, ok := got[key]
+			if !ok || (math.IsNaN(value) != math.IsNaN(v)) || (!math.IsNaN(value) && v != value) {
+				t.Errorf("%s = %v (present %v), want %v", key, v, ok, value)
+			}
+		}
+	}
+}
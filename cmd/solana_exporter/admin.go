@@ -0,0 +1,97 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/certusone/solana_exporter/pkg/rpc"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"k8s.io/klog/v2"
+)
+
+// selfCollector exports the solana_exporter_* metrics about the exporter itself. They are kept apart from
+// solanaCollector so they can be served on -admin-addr, and are computed from state without any RPC call.
+type selfCollector struct {
+	c *solanaCollector
+}
+
+func (s selfCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- s.c.watchedValidators
+	ch <- s.c.nodeFirstSeen
+	ch <- s.c.seriesCapped
+	ch <- s.c.rpcCallsPerScrape
+	ch <- s.c.collectDuration
+	ch <- s.c.lastPollTimestamp
+	ch <- s.c.configInfo
+}
+
+func (s selfCollector) Collect(ch chan<- prometheus.Metric) {
+	configMu.RLock()
+	watched := len(watchedVotePubkeys())
+	config := configInfoValues(s.c.commitment)
+	configMu.RUnlock()
+
+	ch <- prometheus.MustNewConstMetric(s.c.watchedValidators, prometheus.GaugeValue, float64(watched))
+	ch <- prometheus.MustNewConstMetric(s.c.configInfo, prometheus.GaugeValue, 1, config...)
+	ch <- prometheus.MustNewConstMetric(s.c.seriesCapped, prometheus.CounterValue,
+		float64(atomic.LoadUint64(&s.c.cappedScrapes)))
+	ch <- prometheus.MustNewConstMetric(s.c.rpcCallsPerScrape, prometheus.GaugeValue,
+		float64(atomic.LoadUint64(&s.c.lastScrapeCalls)))
+	if d := atomic.LoadUint64(&s.c.lastCollectNanos); d > 0 {
+		ch <- prometheus.MustNewConstMetric(s.c.collectDuration, prometheus.GaugeValue,
+			time.Duration(d).Seconds())
+	}
+
+	if lastPoll := s.c.lastPollTime(); !lastPoll.IsZero() {
+		ch <- prometheus.MustNewConstMetric(s.c.lastPollTimestamp, prometheus.GaugeValue, float64(lastPoll.Unix()))
+	}
+
+	if firstSeen, identity := s.c.nodeFirstSeenAt(); !firstSeen.IsZero() {
+		ch <- prometheus.MustNewConstMetric(s.c.nodeFirstSeen, prometheus.GaugeValue,
+			float64(firstSeen.Unix()), identity)
+	}
+}
+
+// configInfoLabels are the labels of solana_exporter_config_info. Settings that may hold secrets, like the
+// RPC URI which some providers put an API key in, are left out.
+var configInfoLabels = []string{
+	"commitment", "voting", "watched", "credits_scope", "commission_unit", "balance_all", "validator_versions",
+	"push", "stdout", "poll_mode",
+}
+
+// configInfoValues returns the values of configInfoLabels. Callers must hold configMu.
+func configInfoValues(commitment rpc.Commitment) []string {
+	return []string{
+		string(commitment),
+		strconv.FormatBool(!*noVoting),
+		strconv.FormatBool(len(watchedVotePubkeys()) > 0),
+		*creditsScope,
+		*commissionUnit,
+		strconv.FormatBool(*balanceAll),
+		strconv.FormatBool(*validatorVersions),
+		strconv.FormatBool(*pushgateway != ""),
+		strconv.FormatBool(*stdoutInterval > 0),
+		pollMode(),
+	}
+}
+
+// pollMode returns whether node metrics are collected in the background or on every scrape.
+func pollMode() string {
+	if *backgroundPolling {
+		return "background"
+	}
+
+	return "scrape"
+}
+
+// adminServer returns a server for the metrics of g on addr.
+func adminServer(addr string, g prometheus.Gatherer, web *webConfig) *http.Server {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.HandlerFor(g, promhttp.HandlerOpts{EnableOpenMetrics: true}))
+
+	klog.Infof("serving exporter metrics on %s", addr)
+	return newHTTPServer(addr, mux, web)
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/certusone/solana_exporter/pkg/rpc"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// scrape returns the body of url's /metrics, retrying until the listener is up.
+func scrape(t *testing.T, url string) string {
+	t.Helper()
+
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		resp, err := http.Get(url + "/metrics")
+		if err == nil {
+			body, err := ioutil.ReadAll(resp.Body)
+			resp.Body.Close()
+			if err != nil {
+				t.Fatal(err)
+			}
+			return string(body)
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("scraping %s: %v", url, err)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+func TestAdminAddr(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	adminAddr := l.Addr().String()
+	l.Close()
+
+	node := newFakeNode(t)
+	url := startExporter(t, "-rpcURI", node.URL, "-admin-addr", adminAddr)
+
+	metrics := scrape(t, url)
+	if !strings.Contains(metrics, "solana_active_validators") {
+		t.Error("node metrics missing from the metrics endpoint")
+	}
+	for _, name := range []string{"solana_exporter_watched_validators", "go_goroutines"} {
+		if strings.Contains(metrics, name) {
+			t.Errorf("%s served on the metrics endpoint with -admin-addr", name)
+		}
+	}
+
+	admin := scrape(t, "http://"+adminAddr)
+	for _, name := range []string{"solana_exporter_watched_validators", "go_goroutines"} {
+		if !strings.Contains(admin, name) {
+			t.Errorf("%s missing from the admin endpoint", name)
+		}
+	}
+	if strings.Contains(admin, "solana_active_validators") {
+		t.Error("node metrics served on the admin endpoint")
+	}
+}
+
+func TestRPCCallsPerScrape(t *testing.T) {
+	node := newFakeNode(t)
+	c := NewSolanaCollector(node.URL, rpc.CommitmentProcessed)
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(c)
+	_, _ = registry.Gather()
+
+	var want int
+	for method := range node.calls {
+		want += node.callCount(method)
+	}
+
+	self := prometheus.NewRegistry()
+	self.MustRegister(selfCollector{c})
+	families, _ := self.Gather()
+	if got := metricValue(families, "solana_exporter_rpc_calls_per_scrape", nil); got != float64(want) {
+		t.Errorf("solana_exporter_rpc_calls_per_scrape = %v, want %d", got, want)
+	}
+}
+
+func TestCollectDuration(t *testing.T) {
+	node := newFakeNode(t)
+	node.setDelay("getVersion", 200*time.Millisecond)
+	c := NewSolanaCollector(node.URL, rpc.CommitmentProcessed)
+
+	self := prometheus.NewRegistry()
+	self.MustRegister(selfCollector{c})
+
+	// Nothing to report before the first scrape finished.
+	families, _ := self.Gather()
+	if got := metricValue(families, "solana_exporter_collect_duration_seconds", nil); got != -1 {
+		t.Errorf("solana_exporter_collect_duration_seconds = %v before any scrape", got)
+	}
+
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(c)
+	_, _ = registry.Gather()
+
+	families, _ = self.Gather()
+	if got := metricValue(families, "solana_exporter_collect_duration_seconds", nil); got < 0.2 || got > 2 {
+		t.Errorf("solana_exporter_collect_duration_seconds = %v, want about 0.2", got)
+	}
+}
+
+func TestConfigInfo(t *testing.T) {
+	defer func(v string) { *votePubkey = v }(*votePubkey)
+	defer func(v string) { *pushgateway = v }(*pushgateway)
+	defer func(v bool) { *noVoting = v }(*noVoting)
+	defer func(v bool) { *backgroundPolling = v }(*backgroundPolling)
+	*votePubkey = "vote1"
+	*pushgateway = "http://pushgateway:9091"
+	*noVoting = false
+	*backgroundPolling = false
+
+	node := newFakeNode(t)
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(selfCollector{NewSolanaCollector(node.URL, rpc.CommitmentFinalized)})
+	configInfo := func() map[string]string {
+		families, _ := registry.Gather()
+		for _, family := range families {
+			if family.GetName() != "solana_exporter_config_info" {
+				continue
+			}
+			if len(family.GetMetric()) != 1 {
+				t.Fatalf("got %d config info series, want 1", len(family.GetMetric()))
+			}
+			labels := make(map[string]string)
+			for _, pair := range family.GetMetric()[0].GetLabel() {
+				labels[pair.GetName()] = pair.GetValue()
+			}
+			return labels
+		}
+		t.Fatal("solana_exporter_config_info missing")
+		return nil
+	}
+	labels := configInfo()
+
+	for name, want := range map[string]string{
+		"commitment": "finalized",
+		"voting":     "true",
+		"watched":    "true",
+		"push":       "true",
+		"stdout":     "false",
+		"poll_mode":  "scrape",
+	} {
+		if got := labels[name]; got != want {
+			t.Errorf("label %s = %q, want %q", name, got, want)
+		}
+	}
+	if len(labels) != len(configInfoLabels) {
+		t.Errorf("got labels %v, want exactly %v", labels, configInfoLabels)
+	}
+	for _, value := range labels {
+		if strings.Contains(value, node.URL) {
+			t.Errorf("config info leaks the RPC URI in %q", value)
+		}
+	}
+
+	*backgroundPolling = true
+	if got := configInfo()["poll_mode"]; got != "background" {
+		t.Errorf("label poll_mode = %q with -background-polling, want background", got)
+	}
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/klog/v2"
+)
+
+type voteAuthorities struct {
+	voters     string
+	withdrawer string
+}
+
+// collectAuthorityChanges compares the authorized voters and withdrawer of a vote account against the
+// values seen on the previous scrape. A change is reported as 1 for a single scrape only, so alert on
+// max_over_time rather than on the instant value.
+func (c *solanaCollector) collectAuthorityChanges(ctx context.Context, ch chan<- prometheus.Metric, votePubkey string) {
+	info, err := c.rpcClient.GetAccountInfo(ctx, votePubkey, c.commitment)
+	if err != nil {
+		klog.Errorf("failed to get vote account info of %s: %v", votePubkey, err)
+		ch <- prometheus.NewInvalidMetric(c.validatorAuthorityChanged, err)
+		return
+	}
+
+	state, err := info.VoteState()
+	if err != nil {
+		klog.Errorf("failed to decode vote account info of %s: %v", votePubkey, err)
+		ch <- prometheus.NewInvalidMetric(c.validatorAuthorityChanged, err)
+		return
+	}
+
+	voters := make([]string, 0, len(state.AuthorizedVoters))
+	for _, v := range state.AuthorizedVoters {
+		voters = append(voters, v.AuthorizedVoter)
+	}
+
+	current := voteAuthorities{
+		voters:     strings.Join(voters, ","),
+		withdrawer: state.AuthorizedWithdrawer,
+	}
+
+	c.authoritiesMu.Lock()
+	previous, seen := c.authorities[votePubkey]
+	c.authorities[votePubkey] = current
+	c.authoritiesMu.Unlock()
+
+	var voterChanged, withdrawerChanged float64
+	if seen && previous.voters != current.voters {
+		klog.Warningf("authorized voter of %s changed from %q to %q", votePubkey, previous.voters, current.voters)
+		voterChanged = 1
+	}
+	if seen && previous.withdrawer != current.withdrawer {
+		klog.Warningf("authorized withdrawer of %s changed from %q to %q",
+			votePubkey, previous.withdrawer, current.withdrawer)
+		withdrawerChanged = 1
+	}
+
+	ch <- prometheus.MustNewConstMetric(c.validatorAuthorityChanged, prometheus.GaugeValue,
+		voterChanged, votePubkey, "voter")
+	ch <- prometheus.MustNewConstMetric(c.validatorAuthorityChanged, prometheus.GaugeValue,
+		withdrawerChanged, votePubkey, "withdrawer")
+}
+
+// pruneAuthorities drops the authorities of vote accounts no longer watched after a reload, so one watched
+// again later isn't compared against what it had back then.
+func (c *solanaCollector) pruneAuthorities(cfg runtimeConfig) {
+	c.authoritiesMu.Lock()
+	defer c.authoritiesMu.Unlock()
+	for pubkey := range c.authorities {
+		if !cfg.isWatched(pubkey) {
+			delete(c.authorities, pubkey)
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/certusone/solana_exporter/pkg/rpc"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func voteAccountInfo(voter, withdrawer string) map[string]interface{} {
+	return map[string]interface{}{
+		"context": map[string]interface{}{"slot": 990},
+		"value": map[string]interface{}{
+			"data": map[string]interface{}{
+				"program": "vote",
+				"parsed": map[string]interface{}{
+					"type": "vote",
+					"info": map[string]interface{}{
+						"authorizedVoters":     []map[string]interface{}{{"authorizedVoter": voter, "epoch": 5}},
+						"authorizedWithdrawer": withdrawer,
+						"commission":           5,
+						"nodePubkey":           "node1",
+					},
+				},
+				"space": 3731,
+			},
+			"executable": false, "lamports": 1000000, "owner": "Vote111111111111111111111111111111111111111",
+			"rentEpoch": 5,
+		},
+	}
+}
+
+func TestAuthorityChange(t *testing.T) {
+	steps := []struct {
+		name           string
+		voter          string
+		withdrawer     string
+		wantVoter      float64
+		wantWithdrawer float64
+	}{
+		{name: "first scrape", voter: "voterA", withdrawer: "withdrawerA"},
+		{name: "unchanged", voter: "voterA", withdrawer: "withdrawerA"},
+		{name: "voter changed", voter: "voterB", withdrawer: "withdrawerA", wantVoter: 1},
+		{name: "reported once", voter: "voterB", withdrawer: "withdrawerA"},
+		{name: "withdrawer changed", voter: "voterB", withdrawer: "attacker", wantWithdrawer: 1},
+	}
+
+	node := newFakeNode(t)
+	c := NewSolanaCollector(node.URL, rpc.CommitmentProcessed)
+
+	for _, step := range steps {
+		node.set("getAccountInfo", voteAccountInfo(step.voter, step.withdrawer))
+		got := emitted(t, func(ch chan<- prometheus.Metric) {
+			c.collectAuthorityChanges(context.Background(), ch, "vote1")
+		})
+
+		for authority, want := range map[string]float64{"voter": step.wantVoter, "withdrawer": step.wantWithdrawer} {
+			key := `solana_validator_authority_changed{authority="` + authority + `",pubkey="vote1"}`
+			if v, ok := got[key]; !ok || v != want {
+				t.Errorf("%s: %s = %v (present %v), want %v", step.name, key, v, ok, want)
+			}
+		}
+	}
+}
+
+// A validator dropped by a reload and watched again later isn't reported as changed against what it had before.
+func TestAuthoritiesPrunedOnReload(t *testing.T) {
+	defer func(v string) { *votePubkey = v }(*votePubkey)
+
+	node := newFakeNode(t)
+	c := NewSolanaCollector(node.URL, rpc.CommitmentProcessed)
+
+	node.set("getAccountInfo", voteAccountInfo("voterA", "withdrawerA"))
+	emitted(t, func(ch chan<- prometheus.Metric) {
+		c.collectAuthorityChanges(context.Background(), ch, "vote1")
+		c.collectAuthorityChanges(context.Background(), ch, "vote2")
+	})
+
+	*votePubkey = "vote1"
+	c.pruneAuthorities(loadRuntimeConfig())
+	if _, ok := c.authorities["vote2"]; ok || len(c.authorities) != 1 {
+		t.Errorf("authorities kept for %v, want only vote1's", c.authorities)
+	}
+
+	*votePubkey = "vote1,vote2"
+	node.set("getAccountInfo", voteAccountInfo("voterA", "withdrawerB"))
+	got := emitted(t, func(ch chan<- prometheus.Metric) {
+		c.collectAuthorityChanges(context.Background(), ch, "vote2")
+	})
+	key := `solana_validator_authority_changed{authority="withdrawer",pubkey="vote2"}`
+	if got[key] != 0 {
+		t.Errorf("%s = %v after vote2 was watched again, want 0", key, got[key])
+	}
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/certusone/solana_exporter/pkg/rpc"
+)
+
+// Weights of RPC calls, by which they share a scrape's deadline. Calls answered with something on every
+// validator, such as getVoteAccounts and getBlockProduction, take several times as long as the others.
+const (
+	lightCall = 1
+	heavyCall = 4
+)
+
+// minCallTimeout is the least time a call is given while the scrape's deadline allows it, even if that eats
+// into the shares of the calls after it.
+const minCallTimeout = time.Second
+
+// callBudget splits whatever is left of a scrape's deadline across the RPC calls still to come, in proportion
+// to their weights, so one slow call can't starve the ones after it. Time a call doesn't use is passed on to
+// later calls, but no call gets more than httpTimeout.
+type callBudget struct {
+	parent    context.Context
+	remaining int
+	cancels   []context.CancelFunc
+}
+
+// newCallBudget returns a budget for calls weighing weight in total, in units of lightCall.
+func newCallBudget(parent context.Context, weight int) *callBudget {
+	return &callBudget{parent: parent, remaining: weight}
+}
+
+// next returns the context for the next call, a light one.
+func (b *callBudget) next() context.Context {
+	return b.take(lightCall)
+}
+
+// nextHeavy returns the context for the next call, a heavy one.
+func (b *callBudget) nextHeavy() context.Context {
+	return b.take(heavyCall)
+}
+
+func (b *callBudget) take(weight int) context.Context {
+	share := httpTimeout
+	if deadline, ok := b.parent.Deadline(); ok && b.remaining > weight {
+		s := time.Until(deadline) * time.Duration(weight) / time.Duration(b.remaining)
+		if s < minCallTimeout {
+			s = minCallTimeout
+		}
+		if s < share {
+			share = s
+		}
+	}
+	b.remaining -= weight
+	if b.remaining < 0 {
+		b.remaining = 0
+	}
+
+	ctx, cancel := context.WithTimeout(b.parent, share)
+	b.cancels = append(b.cancels, cancel)
+
+	return ctx
+}
+
+// release cancels the contexts handed out by next.
+func (b *callBudget) release() {
+	for _, cancel := range b.cancels {
+		cancel()
+	}
+}
+
+// plannedCalls estimates the total weight of the RPC calls Collect makes with the given settings.
+func (c *solanaCollector) plannedCalls(cfg runtimeConfig) int {
+	// epoch info, version, confirmed and finalized slot, block time, performance samples, transaction count,
+	// supply, identity and health, plus the heavy cluster nodes
+	calls := 10 + heavyCall
+	calls += len(splitList(*tokenAccounts))
+	if *identityPubkey != "" {
+		calls++
+	}
+	if *extraEpochCommitment != "" {
+		calls++
+	}
+	if *referenceRPCAddr != "" {
+		calls++
+	}
+	if *recentForks {
+		// processed slot and finalized blocks
+		calls += 2
+	}
+
+	if cfg.noVoting {
+		// vote accounts to look up whether the node is a validator
+		calls += heavyCall
+	} else {
+		// vote accounts and block production
+		calls += 2 * heavyCall
+		if cfg.balanceAll {
+			calls += balanceChunks(int(atomic.LoadUint64(&c.lastVoteAccounts)))
+		}
+		if watched := len(cfg.watched); watched > 0 {
+			// leader rewards, inflation rewards, plus two balances and the vote account info per watched
+			// validator
+			calls += heavyCall + 1 + 3*watched
+			if watched == 1 {
+				// the unfiltered vote accounts for the rankings and the cluster block production, which
+				// several watched validators are picked out of anyway
+				calls += 2 * heavyCall
+			}
+			if *computeProjectedRewards {
+				// inflation rate
+				calls++
+			}
+		}
+	}
+
+	return calls
+}
+
+// balanceChunks returns the number of getMultipleAccounts calls -balance-all takes for the identity and vote
+// accounts of the given number of validators. Before the first collection, it is assumed to take one.
+func balanceChunks(validators int) int {
+	chunks := (2*validators + rpc.MaxMultipleAccounts - 1) / rpc.MaxMultipleAccounts
+	if chunks < 1 {
+		return 1
+	}
+
+	return chunks
+}
@@ -0,0 +1,210 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/certusone/solana_exporter/pkg/rpc"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// A first call that runs into its timeout must leave the calls after it their share of the scrape.
+func TestCallBudgetSlowFirstCall(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 4*time.Second)
+	defer cancel()
+
+	budget := newCallBudget(ctx, 4)
+	defer budget.release()
+
+	first := budget.next()
+	<-first.Done()
+	if ctx.Err() != nil {
+		t.Fatal("the first call used up the whole scrape")
+	}
+
+	second := budget.next()
+	deadline, _ := second.Deadline()
+	// About 3s are left for three calls.
+	if share := time.Until(deadline); share < 900*time.Millisecond || share > time.Second {
+		t.Errorf("second call got %v, want about 1s", share)
+	}
+
+	budget.next()
+	last, _ := budget.next().Deadline()
+	if scrapeDeadline, _ := ctx.Deadline(); !last.Equal(scrapeDeadline) {
+		t.Errorf("the last call ends at %v, want the end of the scrape at %v", last, scrapeDeadline)
+	}
+}
+
+// However long the scrape may take, no call gets more than httpTimeout.
+func TestCallBudgetCapsCalls(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 4*httpTimeout)
+	defer cancel()
+
+	budget := newCallBudget(ctx, 2)
+	defer budget.release()
+
+	for i := 0; i < 3; i++ {
+		deadline, ok := budget.next().Deadline()
+		if share := time.Until(deadline); !ok || share > httpTimeout {
+			t.Errorf("call %d got %v, want at most %v", i+1, share, httpTimeout)
+		}
+	}
+}
+
+func TestCallBudgetWithoutDeadline(t *testing.T) {
+	budget := newCallBudget(context.Background(), 3)
+	defer budget.release()
+
+	deadline, ok := budget.next().Deadline()
+	if share := time.Until(deadline); !ok || share > httpTimeout || share < httpTimeout-time.Second {
+		t.Errorf("call got %v without a scrape deadline, want %v", share, httpTimeout)
+	}
+}
+
+func TestCollectTimeout(t *testing.T) {
+	defer func(v time.Duration) { *collectTimeout = v }(*collectTimeout)
+	*collectTimeout = 300 * time.Millisecond
+
+	// Every call but the first one hangs, so the scrape takes as long as it is allowed to.
+	node := newFakeNode(t)
+	for method := range node.results {
+		if method != "getEpochInfo" {
+			node.setDelay(method, 10*time.Second)
+		}
+	}
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(NewSolanaCollector(node.URL, rpc.CommitmentProcessed))
+
+	start := time.Now()
+	families, _ := registry.Gather()
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("scrape took %v with -collect-timeout %v", elapsed, *collectTimeout)
+	}
+
+	// What was gathered before the timeout is still exported.
+	if got := metricValue(families, "solana_current_epoch", nil); got != 5 {
+		t.Errorf("solana_current_epoch = %v, want 5", got)
+	}
+}
+
+func TestCallBudgetShares(t *testing.T) {
+	tests := []struct {
+		name     string
+		deadline time.Duration // of the scrape, none if zero
+		planned  int
+		weights  []int
+		want     []time.Duration
+	}{
+		{
+			name:     "shares follow the weights",
+			deadline: 6 * time.Second,
+			planned:  heavyCall + 2*lightCall,
+			weights:  []int{heavyCall, lightCall, lightCall},
+			// The last call gets whatever is left, up to httpTimeout.
+			want: []time.Duration{4 * time.Second, 3 * time.Second, httpTimeout},
+		},
+		{
+			name:     "light calls share evenly",
+			deadline: 4 * time.Second,
+			planned:  2,
+			weights:  []int{lightCall, lightCall},
+			want:     []time.Duration{2 * time.Second, 4 * time.Second},
+		},
+		{
+			name:     "no call gets less than the minimum",
+			deadline: 3 * time.Second,
+			planned:  30,
+			weights:  []int{lightCall, heavyCall},
+			want:     []time.Duration{minCallTimeout, minCallTimeout},
+		},
+		{
+			name:     "no call gets more than the HTTP timeout",
+			deadline: 4 * httpTimeout,
+			planned:  heavyCall + lightCall,
+			weights:  []int{heavyCall, lightCall},
+			want:     []time.Duration{httpTimeout, httpTimeout},
+		},
+		{
+			name:    "without a deadline",
+			planned: 2,
+			weights: []int{heavyCall, lightCall},
+			want:    []time.Duration{httpTimeout, httpTimeout},
+		},
+		{
+			name:     "more calls than planned",
+			deadline: 2 * time.Second,
+			planned:  1,
+			weights:  []int{lightCall, lightCall},
+			want:     []time.Duration{2 * time.Second, 2 * time.Second},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parent := context.Background()
+			if tt.deadline > 0 {
+				var cancel context.CancelFunc
+				parent, cancel = context.WithTimeout(parent, tt.deadline)
+				defer cancel()
+			}
+
+			budget := newCallBudget(parent, tt.planned)
+			defer budget.release()
+
+			for i, weight := range tt.weights {
+				deadline, _ := budget.take(weight).Deadline()
+				if got := time.Until(deadline); got < tt.want[i]-100*time.Millisecond || got > tt.want[i] {
+					t.Errorf("call %d got %v, want %v", i, got, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestBalanceChunks(t *testing.T) {
+	tests := []struct {
+		validators, want int
+	}{
+		{0, 1},
+		{1, 1},
+		{rpc.MaxMultipleAccounts / 2, 1},
+		{rpc.MaxMultipleAccounts/2 + 1, 2},
+		{1800, 36},
+	}
+
+	for _, tt := range tests {
+		if got := balanceChunks(tt.validators); got != tt.want {
+			t.Errorf("balanceChunks(%d) = %d, want %d", tt.validators, got, tt.want)
+		}
+	}
+}
+
+func TestPlannedCallsCountsBalanceChunks(t *testing.T) {
+	c := &solanaCollector{lastVoteAccounts: 1800}
+
+	without := c.plannedCalls(runtimeConfig{})
+	with := c.plannedCalls(runtimeConfig{balanceAll: true})
+	if got := with - without; got != 36 {
+		t.Errorf("-balance-all adds %d to the planned calls, want 36 for 1800 validators", got)
+	}
+}
+
+// getVoteAccounts taking most of a scrape's deadline must not be cut short because many calls come after it.
+
+func TestSlowVoteAccountsFinishes(t *testing.T) {
+	defer func(v string, d time.Duration) { *votePubkey, *collectTimeout = v, d }(*votePubkey, *collectTimeout)
+	*votePubkey, *collectTimeout = "vote1", 2*time.Second
+
+	node := newFakeNode(t)
+	node.setDelay("getVoteAccounts", 600*time.Millisecond)
+
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(NewSolanaCollector(node.URL, rpc.CommitmentProcessed))
+	families, _ := registry.Gather()
+
+	if got := metricValue(families, "solana_active_validators", map[string]string{"state": "current"}); got != 1 {
+		t.Errorf("current validators = %v, want 1 from the slow getVoteAccounts", got)
+	}
+}
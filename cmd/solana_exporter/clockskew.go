@@ -0,0 +1,31 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/klog/v2"
+)
+
+// clockSkew returns how far the block time of a slot is behind now, in seconds.
+func clockSkew(blockTime int64, now time.Time) float64 {
+	return now.Sub(time.Unix(blockTime, 0)).Seconds()
+}
+
+// collectClockSkew compares the block time of slot against the local clock. Slots without a block time,
+// e.g. because the node has already purged them, are skipped.
+func (c *solanaCollector) collectClockSkew(ctx context.Context, ch chan<- prometheus.Metric, slot int64) {
+	blockTime, err := c.rpcClient.GetBlockTime(ctx, slot)
+	if err != nil {
+		klog.Errorf("failed to get block time of slot %d: %v", slot, err)
+		ch <- prometheus.NewInvalidMetric(c.clockSkew, err)
+		return
+	}
+
+	if blockTime == 0 {
+		return
+	}
+
+	ch <- prometheus.MustNewConstMetric(c.clockSkew, prometheus.GaugeValue, clockSkew(blockTime, time.Now()))
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/certusone/solana_exporter/pkg/rpc"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestClockSkew(t *testing.T) {
+	now := time.Unix(1600000010, 0)
+	if got := clockSkew(1600000000, now); got != 10 {
+		t.Errorf("clockSkew() = %v, want 10", got)
+	}
+	// A block time ahead of the local clock gives a negative skew.
+	if got := clockSkew(1600000013, now); got != -3 {
+		t.Errorf("clockSkew() = %v, want -3", got)
+	}
+}
+
+func TestCollectClockSkew(t *testing.T) {
+	tests := []struct {
+		name      string
+		blockTime interface{}
+		wantSkew  bool
+	}{
+		{name: "block time", blockTime: time.Now().Add(-5 * time.Second).Unix(), wantSkew: true},
+		{name: "purged slot", blockTime: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			node := newFakeNode(t)
+			node.set("getBlockTime", tt.blockTime)
+			c := NewSolanaCollector(node.URL, rpc.CommitmentProcessed)
+
+			got := emitted(t, func(ch chan<- prometheus.Metric) {
+				c.collectClockSkew(context.Background(), ch, 990)
+			})
+
+			skew, ok := got["solana_node_clock_skew_seconds"]
+			if ok != tt.wantSkew {
+				t.Fatalf("solana_node_clock_skew_seconds emitted %v, want %v", ok, tt.wantSkew)
+			}
+			if ok && (skew < 4 || skew > 10) {
+				t.Errorf("solana_node_clock_skew_seconds = %v, want about 5", skew)
+			}
+			if params := node.paramsOf("getBlockTime"); len(params) != 1 || string(params[0]) != "[990]" {
+				t.Errorf("getBlockTime called with %s, want [990]", params)
+			}
+		})
+	}
+}
+
+// The block time is looked up for the confirmed slot.
+func TestClockSkewUsesConfirmedSlot(t *testing.T) {
+	node := newFakeNode(t)
+	node.handle("getSlot", func(params json.RawMessage) interface{} {
+		if string(params) == `[{"commitment":"confirmed"}]` {
+			return 995
+		}
+		return 960
+	})
+
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(NewSolanaCollector(node.URL, rpc.CommitmentProcessed))
+	_, _ = registry.Gather()
+
+	if params := node.paramsOf("getBlockTime"); len(params) != 1 || string(params[0]) != "[995]" {
+		t.Errorf("getBlockTime called with %s, want [995]", params)
+	}
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/certusone/solana_exporter/pkg/rpc"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// collectClusterNode emits the gossip information the cluster advertises for our own node. version is the
+// node's own version from getVersion, or nil if unavailable.
+func (c *solanaCollector) collectClusterNode(ch chan<- prometheus.Metric, nodes []rpc.ClusterNode, err error,
+	identity string, version *string) {
+	if err != nil {
+		ch <- prometheus.NewInvalidMetric(c.nodeShredVersion, err)
+		ch <- prometheus.NewInvalidMetric(c.nodeVersionMatchesMode, err)
+		ch <- prometheus.NewInvalidMetric(c.nodeRPCEnabled, err)
+		return
+	}
+
+	if mode := clusterVersionMode(nodes); version != nil && mode != "" {
+		var matches float64
+		if *version == mode {
+			matches = 1
+		}
+		ch <- prometheus.MustNewConstMetric(c.nodeVersionMatchesMode, prometheus.GaugeValue, matches, identity)
+	}
+
+	for _, node := range nodes {
+		if node.Pubkey != identity {
+			continue
+		}
+
+		if node.ShredVersion != nil {
+			ch <- prometheus.MustNewConstMetric(c.nodeShredVersion, prometheus.GaugeValue,
+				float64(*node.ShredVersion), identity)
+		}
+
+		var rpcEnabled float64
+		if node.RPC != nil {
+			rpcEnabled = 1
+		}
+		ch <- prometheus.MustNewConstMetric(c.nodeRPCEnabled, prometheus.GaugeValue, rpcEnabled, identity)
+		return
+	}
+
+	err = fmt.Errorf("node %s not found in cluster nodes", identity)
+	ch <- prometheus.NewInvalidMetric(c.nodeShredVersion, err)
+	ch <- prometheus.NewInvalidMetric(c.nodeRPCEnabled, err)
+}
+
+// clusterVersionMode returns the most common software version among the cluster nodes, preferring the
+// higher version string on ties. It is empty if no node advertises a version.
+func clusterVersionMode(nodes []rpc.ClusterNode) string {
+	counts := make(map[string]int)
+	for _, node := range nodes {
+		if node.Version != nil {
+			counts[*node.Version]++
+		}
+	}
+
+	var mode string
+	for version, count := range counts {
+		if count > counts[mode] || (count == counts[mode] && version > mode) {
+			mode = version
+		}
+	}
+
+	return mode
+}
+
+// clusterNodeVersions maps node identities to the software version they advertise.
+func clusterNodeVersions(nodes []rpc.ClusterNode) map[string]string {
+	versions := make(map[string]string, len(nodes))
+	for _, node := range nodes {
+		if node.Version != nil {
+			versions[node.Pubkey] = *node.Version
+		}
+	}
+
+	return versions
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/certusone/solana_exporter/pkg/rpc"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+func shredVersion(v uint16) *uint16 { return &v }
+
+func softwareVersion(v string) *string { return &v }
+
+func rpcAddress(v string) *string { return &v }
+
+func TestCollectClusterNode(t *testing.T) {
+	tests := []struct {
+		name  string
+		nodes []rpc.ClusterNode
+		want  map[string]float64
+	}{
+		{
+			name: "advertised shred version",
+			nodes: []rpc.ClusterNode{
+				{Pubkey: "node2", ShredVersion: shredVersion(7)},
+				{Pubkey: "node1", ShredVersion: shredVersion(8)},
+			},
+			want: map[string]float64{
+				`solana_node_shred_version{nodekey="node1"}`: 8,
+				`solana_node_rpc_enabled{nodekey="node1"}`:   0,
+			},
+		},
+		{
+			name:  "no shred version",
+			nodes: []rpc.ClusterNode{{Pubkey: "node1"}},
+			want:  map[string]float64{`solana_node_rpc_enabled{nodekey="node1"}`: 0},
+		},
+		{
+			name:  "rpc address",
+			nodes: []rpc.ClusterNode{{Pubkey: "node1", RPC: rpcAddress("127.0.0.1:8899")}},
+			want:  map[string]float64{`solana_node_rpc_enabled{nodekey="node1"}`: 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewSolanaCollector("http://localhost:8899", rpc.CommitmentProcessed)
+
+			got := emitted(t, func(ch chan<- prometheus.Metric) {
+				c.collectClusterNode(ch, tt.nodes, nil, "node1", nil)
+			})
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("emitted %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCollectClusterNodeInvalid(t *testing.T) {
+	tests := []struct {
+		name  string
+		nodes []rpc.ClusterNode
+		err   error
+	}{
+		{name: "not in gossip", nodes: []rpc.ClusterNode{{Pubkey: "node2", ShredVersion: shredVersion(8)}}},
+		{name: "call failed", err: errors.New("RPC call failed")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewSolanaCollector("http://localhost:8899", rpc.CommitmentProcessed)
+
+			ch := make(chan prometheus.Metric, 16)
+			c.collectClusterNode(ch, tt.nodes, tt.err, "node1", nil)
+			close(ch)
+
+			var invalid []string
+			for m := range ch {
+				if err := m.Write(&dto.Metric{}); err == nil {
+					t.Errorf("emitted valid metric %s, want only invalid ones", m.Desc())
+				}
+				invalid = append(invalid, descName.FindStringSubmatch(m.Desc().String())[1])
+			}
+			for _, want := range []string{"solana_node_shred_version", "solana_node_rpc_enabled"} {
+				found := false
+				for _, name := range invalid {
+					found = found || name == want
+				}
+				if !found {
+					t.Errorf("no invalid %s among %v", want, invalid)
+				}
+			}
+		})
+	}
+}
+
+func TestClusterVersionMode(t *testing.T) {
+	tests := []struct {
+		name     string
+		versions []string
+		want     string
+	}{
+		{name: "no versions", want: ""},
+		{name: "majority", versions: []string{"1.9.0", "1.8.5", "1.9.0"}, want: "1.9.0"},
+		{name: "tie prefers the higher version", versions: []string{"1.8.5", "1.9.0"}, want: "1.9.0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// A node without a version doesn't count.
+			nodes := []rpc.ClusterNode{{Pubkey: "nodeX"}}
+			for _, version := range tt.versions {
+				nodes = append(nodes, rpc.ClusterNode{Version: softwareVersion(version)})
+			}
+			if got := clusterVersionMode(nodes); got != tt.want {
+				t.Errorf("clusterVersionMode() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNodeVersionMatchesMode(t *testing.T) {
+	nodes := []rpc.ClusterNode{
+		{Pubkey: "node1", Version: softwareVersion("1.8.5"), ShredVersion: shredVersion(8)},
+		{Pubkey: "node2", Version: softwareVersion("1.9.0")},
+		{Pubkey: "node3", Version: softwareVersion("1.9.0")},
+	}
+
+	for version, want := range map[string]float64{"1.9.0": 1, "1.8.5": 0} {
+		c := NewSolanaCollector("http://localhost:8899", rpc.CommitmentProcessed)
+		got := emitted(t, func(ch chan<- prometheus.Metric) {
+			c.collectClusterNode(ch, nodes, nil, "node1", softwareVersion(version))
+		})
+		if v := got[`solana_node_version_matches_cluster_mode{nodekey="node1"}`]; v != want {
+			t.Errorf("node on %s: solana_node_version_matches_cluster_mode = %v, want %v", version, v, want)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/certusone/solana_exporter/pkg/rpc"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// voteAccount returns a vote account as getVoteAccounts returns it.
+func voteAccount(votePubkey, nodePubkey string, commission int) map[string]interface{} {
+	return map[string]interface{}{
+		"votePubkey": votePubkey, "nodePubkey": nodePubkey, "activatedStake": 5000, "commission": commission,
+		"epochVoteAccount": true, "lastVote": 995, "rootSlot": 960, "epochCredits": [][]int{{5, 150, 100}},
+	}
+}
+
+func TestCommissionOverThreshold(t *testing.T) {
+	defer func(v int) { *maxCommission = v }(*maxCommission)
+	defer func(v string) { *votePubkey = v }(*votePubkey)
+	*maxCommission = 10
+	*votePubkey = "below,at,above"
+
+	node := newFakeNode(t)
+	node.set("getVoteAccounts", map[string]interface{}{
+		"current": []interface{}{
+			voteAccount("below", "node1", 9),
+			voteAccount("at", "node2", 10),
+			voteAccount("above", "node3", 11),
+			voteAccount("unwatched", "node4", 50),
+		},
+		"delinquent": []interface{}{},
+	})
+
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(NewSolanaCollector(node.URL, rpc.CommitmentProcessed))
+	families, _ := registry.Gather()
+
+	// Only the watched validators are compared against the threshold.
+	for pubkey, want := range map[string]float64{"below": 0, "at": 0, "above": 1, "unwatched": -1} {
+		got := metricValue(families, "solana_validator_commission_over_threshold", map[string]string{"pubkey": pubkey})
+		if got != want {
+			t.Errorf("solana_validator_commission_over_threshold{pubkey=%q} = %v, want %v", pubkey, got, want)
+		}
+	}
+}
+
+func TestCommissionUnit(t *testing.T) {
+	for unit, want := range map[string]float64{commissionUnitPercent: 5, commissionUnitBasisPoints: 500} {
+		t.Run(unit, func(t *testing.T) {
+			defer func(v string) { *commissionUnit = v }(*commissionUnit)
+			*commissionUnit = unit
+
+			node := newFakeNode(t)
+			registry := prometheus.NewRegistry()
+			registry.MustRegister(NewSolanaCollector(node.URL, rpc.CommitmentProcessed))
+			families, _ := registry.Gather()
+
+			if got := metricValue(families, "solana_validator_commission", map[string]string{"pubkey": "vote1"}); got != want {
+				t.Errorf("solana_validator_commission{pubkey=vote1} = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestInvalidCommissionUnit(t *testing.T) {
+	if os.Getenv("SOLANA_EXPORTER_RUN_MAIN") == "1" {
+		os.Args = []string{"solana_exporter", "-rpcURI", "http://localhost:8899", "-commission-unit", "permille"}
+		main()
+		return
+	}
+
+	cmd := exec.Command(os.Args[0], "-test.run=^TestInvalidCommissionUnit$")
+	cmd.Env = append(os.Environ(), "SOLANA_EXPORTER_RUN_MAIN=1")
+	out, err := cmd.CombinedOutput()
+	if _, ok := err.(*exec.ExitError); !ok {
+		t.Fatalf("exporter with -commission-unit permille didn't exit with an error: %v", err)
+	}
+	if !strings.Contains(string(out), `Invalid -commission-unit "permille"`) {
+		t.Errorf("output %q doesn't report the invalid unit", out)
+	}
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/certusone/solana_exporter/pkg/rpc"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestCommissionTier(t *testing.T) {
+	for commission, want := range map[int]string{
+		0: "0", 1: "1-5", 5: "1-5", 6: "6-10", 10: "6-10", 11: ">10", 100: ">10",
+	} {
+		if got := commissionTier(commission); got != want {
+			t.Errorf("commissionTier(%d) = %q, want %q", commission, got, want)
+		}
+	}
+}
+
+func TestStakeByCommissionTier(t *testing.T) {
+	c := NewSolanaCollector("http://localhost:8899", rpc.CommitmentProcessed)
+	accounts := []rpc.VoteAccount{
+		{VotePubkey: "a", Commission: 0, ActivatedStake: 100},
+		{VotePubkey: "b", Commission: 5, ActivatedStake: 200},
+		{VotePubkey: "c", Commission: 3, ActivatedStake: 300},
+		{VotePubkey: "d", Commission: 100, ActivatedStake: 400},
+	}
+
+	got := emitted(t, func(ch chan<- prometheus.Metric) {
+		c.emitStakeByCommissionTier(ch, accounts)
+	})
+	// Tiers without stake are still reported.
+	want := map[string]float64{
+		`solana_stake_by_commission_tier{tier="0"}`:    100,
+		`solana_stake_by_commission_tier{tier="1-5"}`:  500,
+		`solana_stake_by_commission_tier{tier="6-10"}`: 0,
+		`solana_stake_by_commission_tier{tier=">10"}`:  400,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("emitted %v, want %v", got, want)
+	}
+}
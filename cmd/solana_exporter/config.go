@@ -0,0 +1,170 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+
+	"k8s.io/klog/v2"
+)
+
+var (
+	configFile = flag.String("config", "", "JSON file with flag values, keyed by flag name (reloaded on SIGHUP)")
+
+	// Flags that can be changed by reloading the config file. Everything else requires a restart.
+	reloadableFlags = map[string]bool{
+		"votepubkey":     true,
+		"no-voting":      true,
+		"max-commission": true,
+		"credits-scope":  true,
+		"balance-all":    true,
+	}
+
+	// Held for reading while a collection takes its runtimeConfig, and for writing while a config reload
+	// updates the flags.
+	configMu sync.RWMutex
+
+	// Flags given on the command line, which take precedence over the config file.
+	explicitFlags = make(map[string]bool)
+)
+
+// runtimeConfig is a snapshot of the settings a config reload can change, taken once per collection so that
+// a reload can't change them halfway through one.
+type runtimeConfig struct {
+	watched        []string
+	watchedSet     map[string]bool
+	noVoting       bool
+	maxCommission  int
+	creditsScope   string
+	balanceAll     bool
+	emitAbsentZero bool
+}
+
+// loadRuntimeConfig takes a snapshot of the current settings.
+func loadRuntimeConfig() runtimeConfig {
+	configMu.RLock()
+	defer configMu.RUnlock()
+
+	cfg := runtimeConfig{
+		watched:        watchedVotePubkeys(),
+		noVoting:       *noVoting,
+		maxCommission:  *maxCommission,
+		creditsScope:   *creditsScope,
+		balanceAll:     *balanceAll,
+		emitAbsentZero: *emitAbsentZero,
+	}
+	cfg.watchedSet = make(map[string]bool, len(cfg.watched))
+	for _, pubkey := range cfg.watched {
+		cfg.watchedSet[pubkey] = true
+	}
+
+	return cfg
+}
+
+// isWatched reports whether the vote pubkey belongs to a validator configured with -votepubkey or
+// -identities-file.
+func (cfg runtimeConfig) isWatched(pubkey string) bool {
+	return cfg.watchedSet[pubkey]
+}
+
+// loadConfigFile reads a JSON object mapping flag names to values.
+func loadConfigFile(path string) (map[string]string, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var raw map[string]interface{}
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return nil, fmt.Errorf("failed to decode %s: %w", path, err)
+	}
+
+	values := make(map[string]string, len(raw))
+	for name, value := range raw {
+		if flag.Lookup(name) == nil {
+			return nil, fmt.Errorf("unknown setting %q in %s", name, path)
+		}
+		values[name] = fmt.Sprint(value)
+	}
+
+	return values, nil
+}
+
+// applyConfig sets the flags from the config file, skipping those given on the command line. On reload,
+// only reloadable flags are changed and the previous values are restored if validation fails.
+func applyConfig(values map[string]string, reload bool) error {
+	previous := make(map[string]string)
+
+	for name, value := range values {
+		f := flag.Lookup(name)
+		if explicitFlags[name] || f.Value.String() == value {
+			continue
+		}
+
+		if reload && !reloadableFlags[name] {
+			klog.Warningf("changing -%s requires a restart, ignoring new value", name)
+			continue
+		}
+
+		previous[name] = f.Value.String()
+		if err := flag.Set(name, value); err != nil {
+			restoreFlags(previous)
+			return fmt.Errorf("invalid value for -%s: %w", name, err)
+		}
+	}
+
+	if err := validateReloadableFlags(); err != nil {
+		restoreFlags(previous)
+		return err
+	}
+
+	for name := range previous {
+		klog.Infof("set -%s from config file", name)
+	}
+
+	return nil
+}
+
+func restoreFlags(values map[string]string) {
+	for name, value := range values {
+		_ = flag.Set(name, value)
+	}
+}
+
+// validateReloadableFlags checks the flags that can change at runtime.
+func validateReloadableFlags() error {
+	if *creditsScope != creditsScopeAllTime && *creditsScope != creditsScopeEpoch {
+		return fmt.Errorf("invalid -credits-scope %q, must be %s or %s", *creditsScope, creditsScopeAllTime, creditsScopeEpoch)
+	}
+
+	return nil
+}
+
+// reloadConfigOnSIGHUP re-reads the config file whenever the process receives SIGHUP.
+func reloadConfigOnSIGHUP(path string) {
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, syscall.SIGHUP)
+
+	for range sig {
+		klog.Infof("received SIGHUP, reloading %s", path)
+
+		values, err := loadConfigFile(path)
+		if err != nil {
+			klog.Errorf("failed to reload config: %v", err)
+			continue
+		}
+
+		configMu.Lock()
+		err = applyConfig(values, true)
+		configMu.Unlock()
+
+		if err != nil {
+			klog.Errorf("failed to reload config: %v", err)
+		}
+	}
+}
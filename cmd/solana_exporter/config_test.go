@@ -0,0 +1,166 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/certusone/solana_exporter/pkg/rpc"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestApplyConfig(t *testing.T) {
+	defer func(scope string, commission int, a string) {
+		*creditsScope, *maxCommission, *addr = scope, commission, a
+		delete(explicitFlags, "max-commission")
+	}(*creditsScope, *maxCommission, *addr)
+
+	*creditsScope, *maxCommission, *addr = creditsScopeAllTime, -1, ":8080"
+	explicitFlags["max-commission"] = true
+
+	// Flags given on the command line win over the file.
+	if err := applyConfig(map[string]string{"credits-scope": "epoch", "max-commission": "10"}, false); err != nil {
+		t.Fatal(err)
+	}
+	if *creditsScope != creditsScopeEpoch || *maxCommission != -1 {
+		t.Errorf("after load: -credits-scope %q, -max-commission %d, want epoch and -1", *creditsScope, *maxCommission)
+	}
+
+	// Settings that need a restart are left alone on reload.
+	if err := applyConfig(map[string]string{"addr": ":9090"}, true); err != nil {
+		t.Fatal(err)
+	}
+	if *addr != ":8080" {
+		t.Errorf("reload changed -addr to %q", *addr)
+	}
+
+	// An invalid file changes nothing.
+	err := applyConfig(map[string]string{"credits-scope": "forever", "balance-all": "true"}, true)
+	if err == nil {
+		t.Error("applied an invalid -credits-scope")
+	}
+	if *creditsScope != creditsScopeEpoch || *balanceAll {
+		t.Errorf("failed reload left -credits-scope %q, -balance-all %v", *creditsScope, *balanceAll)
+	}
+}
+
+func TestReloadConfigOnSIGHUP(t *testing.T) {
+	defer func(v string) { *creditsScope = v }(*creditsScope)
+	*creditsScope = creditsScopeAllTime
+
+	// Keep SIGHUP from terminating the test binary if it arrives before the reloader listens for it.
+	ignored := make(chan os.Signal, 1)
+	signal.Notify(ignored, syscall.SIGHUP)
+	defer signal.Stop(ignored)
+
+	dir, err := ioutil.TempDir("", "solana_exporter")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "config.json")
+	if err := ioutil.WriteFile(path, []byte(`{"credits-scope": "epoch"}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	go reloadConfigOnSIGHUP(path)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if err := syscall.Kill(os.Getpid(), syscall.SIGHUP); err != nil {
+			t.Fatal(err)
+		}
+		time.Sleep(10 * time.Millisecond)
+
+		configMu.RLock()
+		scope := *creditsScope
+		configMu.RUnlock()
+		if scope == creditsScopeEpoch {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("-credits-scope is still %q after SIGHUP, want epoch", scope)
+		}
+	}
+}
+
+func TestLoadRuntimeConfig(t *testing.T) {
+	tests := []struct {
+		name        string
+		votePubkey  string
+		filePubkeys []string
+		wantWatched []string
+		notWatched  string
+	}{
+		{name: "nothing watched", notWatched: "vote1"},
+		{name: "flag only", votePubkey: "vote1,vote2", wantWatched: []string{"vote1", "vote2"}, notWatched: "vote3"},
+		{
+			name:        "flag and identities file without duplicates",
+			votePubkey:  "vote1,vote2,vote1",
+			filePubkeys: []string{"vote2", "vote3"},
+			wantWatched: []string{"vote1", "vote2", "vote3"},
+			notWatched:  "vote4",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func(v string, f []string) { *votePubkey, filePubkeys = v, f }(*votePubkey, filePubkeys)
+			*votePubkey, filePubkeys = tt.votePubkey, tt.filePubkeys
+
+			cfg := loadRuntimeConfig()
+			if !reflect.DeepEqual(cfg.watched, tt.wantWatched) {
+				t.Errorf("watched = %v, want %v", cfg.watched, tt.wantWatched)
+			}
+			for _, pubkey := range tt.wantWatched {
+				if !cfg.isWatched(pubkey) {
+					t.Errorf("isWatched(%q) = false, want true", pubkey)
+				}
+			}
+			if cfg.isWatched(tt.notWatched) {
+				t.Errorf("isWatched(%q) = true, want false", tt.notWatched)
+			}
+		})
+	}
+}
+
+// Collections read their settings from a snapshot, so reloads running alongside don't race with them. Run
+
+// with -race to catch a setting read outside of it.
+
+func TestCollectDuringReload(t *testing.T) {
+	defer func(v string, m int) { *votePubkey, *maxCommission = v, m }(*votePubkey, *maxCommission)
+
+	node := newFakeNode(t)
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(NewSolanaCollector(node.URL, rpc.CommitmentProcessed))
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 20; i++ {
+			values := map[string]string{"votepubkey": "vote1", "max-commission": "5"}
+			if i%2 == 0 {
+				values = map[string]string{"votepubkey": "", "max-commission": "-1"}
+			}
+			configMu.Lock()
+			err := applyConfig(values, true)
+			configMu.Unlock()
+			if err != nil {
+				t.Error(err)
+			}
+		}
+	}()
+
+	for i := 0; i < 5; i++ {
+		_, _ = registry.Gather()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"time"
+
+	"github.com/certusone/solana_exporter/pkg/rpc"
+)
+
+const (
+	// With timely vote credits, a vote landing within the grace period earns the maximum credits, and one
+	// credit less for every further slot it takes to land.
+	maxCreditsPerVote = 16
+	voteGraceSlots    = 2
+)
+
+// creditSample is the epoch credits of a validator as seen on a scrape.
+type creditSample struct {
+	epoch   int
+	credits int
+	at      time.Time
+}
+
+// creditRate records the epoch credits of a validator and returns how many credits per second it earned
+// since the previous scrape. There is no rate on the first scrape or the first one in a new epoch, when
+// epoch credits start over.
+func (c *solanaCollector) creditRate(votePubkey string, epoch, credits int, now time.Time) (float64, bool) {
+	c.creditSamplesMu.Lock()
+	defer c.creditSamplesMu.Unlock()
+
+	previous, seen := c.creditSamples[votePubkey]
+	c.creditSamples[votePubkey] = creditSample{epoch: epoch, credits: credits, at: now}
+
+	elapsed := now.Sub(previous.at).Seconds()
+	if !seen || previous.epoch != epoch || elapsed <= 0 {
+		return 0, false
+	}
+
+	return float64(credits-previous.credits) / elapsed, true
+}
+
+// estimateVoteLatency approximates the mean number of slots a validator's votes take to land from its average
+// credits per slot, inverting the timely vote credits formula. It assumes every slot is voted on, so missed
+// votes and skipped slots show up as additional latency, and can't tell latencies within the grace period apart.
+// Without timely vote credits every vote earns a single credit whatever its latency, so there is no estimate.
+func estimateVoteLatency(creditsPerSlot float64, timely bool) (float64, bool) {
+	if !timely {
+		return 0, false
+	}
+
+	latency := voteGraceSlots + maxCreditsPerVote - creditsPerSlot
+	if latency < 1 {
+		return 1, true
+	}
+
+	return latency, true
+}
+
+// timelyVoteCredits reports whether the cluster awards timely vote credits in the current epoch. epochCredits
+// doesn't say, but without them a vote earns a single credit, so no validator can have more credits than slots
+// have passed in the epoch.
+func (c *solanaCollector) timelyVoteCredits(accounts []rpc.VoteAccount, slotIndex int64) bool {
+	for _, account := range accounts {
+		if int64(c.calcEpochCredits(account.EpochCredits)) > slotIndex {
+			return true
+		}
+	}
+
+	return false
+}
+
+// votingPercentage returns credits as a percentage of the most credits that could have been earned so far in
+// the epoch: one per slot, or maxCreditsPerVote per slot with timely vote credits. There is none in the very
+// first slot of an epoch, when no credits can have been earned yet.
+func votingPercentage(credits int, slotIndex int64, timely bool) (float64, bool) {
+	if slotIndex <= 0 {
+		return 0, false
+	}
+
+	maxCredits := float64(slotIndex)
+	if timely {
+		maxCredits *= maxCreditsPerVote
+	}
+
+	return float64(credits) / maxCredits * 100.0, true
+}
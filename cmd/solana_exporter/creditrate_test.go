@@ -0,0 +1,189 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/certusone/solana_exporter/pkg/rpc"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestCreditRate(t *testing.T) {
+	c := NewSolanaCollector("http://localhost:8899", rpc.CommitmentProcessed)
+	start := time.Unix(1600000000, 0)
+
+	steps := []struct {
+		epoch   int
+		credits int
+		after   time.Duration
+		want    float64
+		wantOK  bool
+	}{
+		// No rate without a previous scrape.
+		{epoch: 5, credits: 100, after: 0},
+		{epoch: 5, credits: 150, after: 10 * time.Second, want: 5, wantOK: true},
+		{epoch: 5, credits: 150, after: 20 * time.Second, want: 0, wantOK: true},
+		// Credits start over in a new epoch.
+		{epoch: 6, credits: 10, after: 30 * time.Second},
+		{epoch: 6, credits: 40, after: 40 * time.Second, want: 3, wantOK: true},
+		// Two scrapes at the same time give no rate.
+		{epoch: 6, credits: 50, after: 40 * time.Second},
+	}
+
+	for i, step := range steps {
+		got, ok := c.creditRate("vote1", step.epoch, step.credits, start.Add(step.after))
+		if ok != step.wantOK || got != step.want {
+			t.Errorf("scrape %d: creditRate() = %v, %v, want %v, %v", i, got, ok, step.want, step.wantOK)
+		}
+	}
+
+	// Validators are tracked separately.
+	if _, ok := c.creditRate("vote2", 6, 50, start.Add(time.Minute)); ok {
+		t.Error("creditRate() returned a rate on the first scrape of another validator")
+	}
+}
+
+func TestEstimateVoteLatency(t *testing.T) {
+	tests := []struct {
+		creditsPerSlot float64
+		want           float64
+	}{
+		// Every vote within the grace period.
+		{creditsPerSlot: 16, want: 2},
+		{creditsPerSlot: 14, want: 4},
+		{creditsPerSlot: 8.5, want: 9.5},
+		// Rounding can give more than the maximum credits, which still is the lowest latency.
+		{creditsPerSlot: 17.5, want: 1},
+	}
+
+	for _, tt := range tests {
+		if got, ok := estimateVoteLatency(tt.creditsPerSlot, true); !ok || got != tt.want {
+			t.Errorf("estimateVoteLatency(%v) = %v, %v, want %v", tt.creditsPerSlot, got, ok, tt.want)
+		}
+	}
+
+	// Every vote earns a single credit without timely vote credits, however long it takes to land.
+	if got, ok := estimateVoteLatency(1, false); ok {
+		t.Errorf("estimateVoteLatency() = %v without timely vote credits, want no estimate", got)
+	}
+}
+
+// voteAccountWithCredits returns a current vote account as getVoteAccounts returns it, which earned the given
+// credits in the current epoch.
+func voteAccountWithCredits(votePubkey string, credits int) map[string]interface{} {
+	return map[string]interface{}{
+		"votePubkey": votePubkey, "nodePubkey": "node1", "activatedStake": 5000, "epochVoteAccount": true,
+		"lastVote": 995, "rootSlot": 960, "epochCredits": [][]int{{5, 100 + credits, 100}},
+	}
+}
+
+func TestVoteLatency(t *testing.T) {
+	defer func(v string) { *votePubkey = v }(*votePubkey)
+	*votePubkey = "vote1"
+
+	tests := []struct {
+		name    string
+		credits int
+		want    float64
+	}{
+		// 1450 credits in the first 100 slots of the epoch take timely vote credits.
+		{name: "timely vote credits", credits: 1450, want: 3.5},
+		// At most one credit per slot, so the credits say nothing about the latency.
+		{name: "one credit per vote", credits: 50, want: -1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			node := newFakeNode(t)
+			node.set("getVoteAccounts", map[string]interface{}{
+				"current":    []interface{}{voteAccountWithCredits("vote1", tt.credits)},
+				"delinquent": []interface{}{},
+			})
+			registry := prometheus.NewRegistry()
+			registry.MustRegister(NewSolanaCollector(node.URL, rpc.CommitmentProcessed))
+			families, _ := registry.Gather()
+
+			got := metricValue(families, "solana_validator_vote_latency", map[string]string{"pubkey": "vote1"})
+			if got != tt.want {
+				t.Errorf("solana_validator_vote_latency{pubkey=vote1} = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestVoteLatencyWatchedOnly(t *testing.T) {
+	defer func(v string) { *votePubkey = v }(*votePubkey)
+	*votePubkey = "vote1"
+
+	node := newFakeNode(t)
+	node.set("getVoteAccounts", map[string]interface{}{
+		"current": []interface{}{
+			voteAccountWithCredits("vote1", 1450),
+			voteAccountWithCredits("vote3", 1600),
+		},
+		"delinquent": []interface{}{},
+	})
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(NewSolanaCollector(node.URL, rpc.CommitmentProcessed))
+	families, _ := registry.Gather()
+
+	if got := metricValue(families, "solana_validator_vote_latency", map[string]string{"pubkey": "vote1"}); got != 3.5 {
+		t.Errorf("solana_validator_vote_latency{pubkey=vote1} = %v, want 3.5", got)
+	}
+	if got := metricValue(families, "solana_validator_vote_latency", map[string]string{"pubkey": "vote3"}); got != -1 {
+		t.Errorf("solana_validator_vote_latency{pubkey=vote3} = %v, want it left out", got)
+	}
+}
+
+func TestTimelyVoteCredits(t *testing.T) {
+	c := NewSolanaCollector("http://localhost:8899", rpc.CommitmentProcessed)
+
+	tests := []struct {
+		name     string
+		accounts []rpc.VoteAccount
+		want     bool
+	}{
+		{name: "no accounts"},
+		{name: "one credit per slot at most", accounts: []rpc.VoteAccount{
+			{EpochCredits: [][3]int{{5, 1100, 1000}}},
+			{EpochCredits: [][3]int{{5, 1050, 1000}}},
+		}},
+		{name: "more credits than slots", want: true, accounts: []rpc.VoteAccount{
+			{EpochCredits: [][3]int{{5, 1050, 1000}}},
+			{EpochCredits: [][3]int{{5, 2500, 1000}}},
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := c.timelyVoteCredits(tt.accounts, 100); got != tt.want {
+				t.Errorf("timelyVoteCredits = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestVotingPercentage(t *testing.T) {
+	tests := []struct {
+		name      string
+		credits   int
+		slotIndex int64
+		timely    bool
+		want      float64
+		wantOK    bool
+	}{
+		{name: "without timely credits", credits: 50, slotIndex: 100, want: 50, wantOK: true},
+		{name: "with timely credits", credits: 800, slotIndex: 100, timely: true, want: 50, wantOK: true},
+		// Nothing can have been earned in the first slot of an epoch.
+		{name: "first slot", credits: 0, slotIndex: 0},
+		{name: "first slot with timely credits", credits: 0, slotIndex: 0, timely: true},
+		{name: "second slot", credits: 1, slotIndex: 1, want: 100, wantOK: true},
+	}
+
+	for _, tt := range tests {
+		got, ok := votingPercentage(tt.credits, tt.slotIndex, tt.timely)
+		if ok != tt.wantOK || got != tt.want {
+			t.Errorf("%s: votingPercentage() = %v, %v, want %v, %v", tt.name, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
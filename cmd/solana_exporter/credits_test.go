@@ -0,0 +1,36 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/certusone/solana_exporter/pkg/rpc"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestCreditsScope(t *testing.T) {
+	tests := []struct {
+		scope string
+		want  float64
+	}{
+		// vote1 had 100 credits at the start of the epoch and 150 now.
+		{scope: creditsScopeAllTime, want: 150},
+		{scope: creditsScopeEpoch, want: 50},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.scope, func(t *testing.T) {
+			defer func(v string) { *creditsScope = v }(*creditsScope)
+			*creditsScope = tt.scope
+
+			node := newFakeNode(t)
+			registry := prometheus.NewRegistry()
+			registry.MustRegister(NewSolanaCollector(node.URL, rpc.CommitmentProcessed))
+			families, _ := registry.Gather()
+
+			got := metricValue(families, "solana_validator_total_credits", map[string]string{"pubkey": "vote1"})
+			if got != tt.want {
+				t.Errorf("solana_validator_total_credits = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
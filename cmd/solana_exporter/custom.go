@@ -0,0 +1,156 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/certusone/solana_exporter/pkg/rpc"
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/klog/v2"
+)
+
+var (
+	customMetricsFile = flag.String("custom-metrics", "",
+		"JSON file defining additional gauges read from arbitrary RPC methods")
+
+	metricNameRE = regexp.MustCompile(`^[a-zA-Z_:][a-zA-Z0-9_:]*$`)
+)
+
+// customMetric maps a number in the result of an RPC method to a gauge.
+type customMetric struct {
+	// Name and help text of the gauge.
+	Name string `json:"name"`
+	Help string `json:"help"`
+	// RPC method and its parameters.
+	Method string        `json:"method"`
+	Params []interface{} `json:"params"`
+	// Dot separated path to the value in the result, with array elements selected by index, e.g.
+	// "value.0.lamports". Empty if the result itself is the value.
+	Path string `json:"path"`
+
+	desc *prometheus.Desc
+}
+
+// loadCustomMetrics reads and validates a JSON array of custom metric definitions.
+func loadCustomMetrics(path string) ([]*customMetric, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var metrics []*customMetric
+	if err := json.Unmarshal(b, &metrics); err != nil {
+		return nil, fmt.Errorf("failed to decode %s: %w", path, err)
+	}
+
+	names := make(map[string]bool, len(metrics))
+	for i, m := range metrics {
+		switch {
+		case !metricNameRE.MatchString(m.Name):
+			return nil, fmt.Errorf("custom metric %d: invalid name %q", i, m.Name)
+		case names[m.Name]:
+			return nil, fmt.Errorf("custom metric %d: duplicate name %q", i, m.Name)
+		case m.Method == "":
+			return nil, fmt.Errorf("custom metric %s: method is required", m.Name)
+		}
+		names[m.Name] = true
+
+		help := m.Help
+		if help == "" {
+			help = fmt.Sprintf("Value at %q in the result of %s", m.Path, m.Method)
+		}
+		m.desc = prometheus.NewDesc(m.Name, help, nil, nil)
+	}
+
+	return metrics, nil
+}
+
+// extractValue looks up path in a JSON document and converts the value found there to a float. Numbers,
+// booleans and numeric strings are supported.
+func extractValue(doc json.RawMessage, path string) (float64, error) {
+	var v interface{}
+	if err := json.Unmarshal(doc, &v); err != nil {
+		return 0, err
+	}
+
+	if path != "" {
+		for _, key := range strings.Split(path, ".") {
+			switch node := v.(type) {
+			case map[string]interface{}:
+				var ok bool
+				if v, ok = node[key]; !ok {
+					return 0, fmt.Errorf("key %q not found", key)
+				}
+			case []interface{}:
+				i, err := strconv.Atoi(key)
+				if err != nil || i < 0 || i >= len(node) {
+					return 0, fmt.Errorf("invalid index %q into array of length %d", key, len(node))
+				}
+				v = node[i]
+			default:
+				return 0, fmt.Errorf("can't look up %q in %T", key, node)
+			}
+		}
+	}
+
+	switch value := v.(type) {
+	case float64:
+		return value, nil
+	case bool:
+		if value {
+			return 1, nil
+		}
+		return 0, nil
+	case string:
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return 0, fmt.Errorf("value at %q is %q, not a number", path, value)
+		}
+		return f, nil
+	default:
+		return 0, fmt.Errorf("value at %q is %T, not a number", path, v)
+	}
+}
+
+// customCollector emits the metrics given with -custom-metrics.
+type customCollector struct {
+	rpcClient *rpc.RPCClient
+	metrics   []*customMetric
+}
+
+func (c *customCollector) Describe(ch chan<- *prometheus.Desc) {
+	for _, m := range c.metrics {
+		ch <- m.desc
+	}
+}
+
+func (c *customCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), httpTimeout)
+	defer cancel()
+	budget := newCallBudget(ctx, len(c.metrics))
+	defer budget.release()
+
+	for _, m := range c.metrics {
+		result, err := c.rpcClient.Call(budget.next(), m.Method, m.Params)
+		if err != nil {
+			klog.Errorf("failed to call %s for %s: %v", m.Method, m.Name, err)
+			ch <- prometheus.NewInvalidMetric(m.desc, err)
+			continue
+		}
+
+		value, err := extractValue(result, m.Path)
+		if err != nil {
+			klog.Errorf("failed to read %s from %s result: %v", m.Name, m.Method, err)
+			ch <- prometheus.NewInvalidMetric(m.desc, err)
+			continue
+		}
+
+		ch <- prometheus.MustNewConstMetric(m.desc, prometheus.GaugeValue, value)
+	}
+}
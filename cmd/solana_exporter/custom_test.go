@@ -0,0 +1,112 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/certusone/solana_exporter/pkg/rpc"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func writeCustomMetrics(t *testing.T, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "custom.json")
+	if err := ioutil.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
+
+func TestLoadCustomMetrics(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr bool
+	}{
+		{name: "valid", content: `[{"name": "solana_slot", "method": "getSlot"}, {"name": "b", "method": "getHealth"}]`},
+		{name: "invalid json", content: `{`, wantErr: true},
+		{name: "invalid name", content: `[{"name": "1slot", "method": "getSlot"}]`, wantErr: true},
+		{name: "duplicate name", content: `[{"name": "a", "method": "getSlot"}, {"name": "a", "method": "getSlot"}]`, wantErr: true},
+		{name: "no method", content: `[{"name": "a"}]`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := loadCustomMetrics(writeCustomMetrics(t, tt.content))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("loadCustomMetrics() error = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestExtractValue(t *testing.T) {
+	doc := json.RawMessage(`{"value": [{"lamports": 42, "ok": true, "s": "1.5", "name": "x"}], "n": 7}`)
+
+	tests := []struct {
+		path    string
+		want    float64
+		wantErr bool
+	}{
+		{path: "n", want: 7},
+		{path: "value.0.lamports", want: 42},
+		{path: "value.0.ok", want: 1},
+		{path: "value.0.s", want: 1.5},
+		{path: "value.0.name", wantErr: true},
+		{path: "value.1.lamports", wantErr: true},
+		{path: "value.x", wantErr: true},
+		{path: "missing", wantErr: true},
+		{path: "n.x", wantErr: true},
+		{path: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := extractValue(doc, tt.path)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("extractValue(%q) error = %v, want error %v", tt.path, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("extractValue(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+
+	if got, err := extractValue(json.RawMessage(`990`), ""); err != nil || got != 990 {
+		t.Errorf("extractValue of a bare number = %v, %v, want 990", got, err)
+	}
+}
+
+func TestCustomCollector(t *testing.T) {
+	node := newFakeNode(t)
+	metrics, err := loadCustomMetrics(writeCustomMetrics(t, `[
+		{"name": "custom_supply_total", "method": "getSupply", "params": [{"commitment": "finalized"}], "path": "value.total"},
+		{"name": "custom_unknown", "method": "getUnknown"}
+	]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := &customCollector{rpcClient: rpc.NewRPCClient(node.URL), metrics: metrics}
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(c)
+
+	// The failing method is reported as an error without dropping the other gauge.
+	families, err := registry.Gather()
+	if err == nil {
+		t.Error("Gather() succeeded for an unknown method")
+	}
+	if got := metricValue(families, "custom_supply_total", nil); got != 100 {
+		t.Errorf("custom_supply_total = %v, want 100", got)
+	}
+	if params := node.paramsOf("getSupply"); len(params) != 1 || string(params[0]) != `[{"commitment":"finalized"}]` {
+		t.Errorf("getSupply called with %s, want the configured params", params)
+	}
+	if got := testutil.CollectAndCount(c); got != 2 {
+		t.Errorf("collected %d metrics, want 2", got)
+	}
+}
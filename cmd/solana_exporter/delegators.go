@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"sync"
+	"time"
+
+	"github.com/certusone/solana_exporter/pkg/rpc"
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/klog/v2"
+)
+
+var (
+	delegatorCount = flag.Bool("delegator-count", false,
+		"Export the number of stake accounts delegated to each -votepubkey validator (expensive)")
+	delegatorCountTTL = flag.Duration("delegator-count-ttl", time.Hour,
+		"How long the number of delegated stake accounts is cached")
+)
+
+type delegatorSample struct {
+	count     int
+	fetchedAt time.Time
+}
+
+// delegatorsCollector emits the number of stake accounts delegated to each watched validator. This takes a
+// getProgramAccounts call over the whole stake program per validator, so counts are cached for
+// -delegator-count-ttl and the collector has a timeout of its own.
+type delegatorsCollector struct {
+	rpcClient  *rpc.RPCClient
+	commitment rpc.Commitment
+
+	mu      sync.Mutex
+	samples map[string]delegatorSample
+
+	delegators *prometheus.Desc
+}
+
+func newDelegatorsCollector(client *rpc.RPCClient, commitment rpc.Commitment) *delegatorsCollector {
+	return &delegatorsCollector{
+		rpcClient:  client,
+		commitment: commitment,
+		samples:    make(map[string]delegatorSample),
+		delegators: prometheus.NewDesc(
+			"solana_validator_delegator_count",
+			"Number of stake accounts delegated to the vote account which haven't been deactivated",
+			[]string{"pubkey"}, nil),
+	}
+}
+
+func (c *delegatorsCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.delegators
+}
+
+// Collect fetches the counts of the watched validators one after another. A validator whose count can't be
+// fetched is logged and left out.
+func (c *delegatorsCollector) Collect(ch chan<- prometheus.Metric) {
+	configMu.RLock()
+	watched := watchedVotePubkeys()
+	configMu.RUnlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), httpTimeout)
+	defer cancel()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, pubkey := range watched {
+		sample, ok := c.samples[pubkey]
+		fetchedAt := sample.fetchedAt
+		if !ok || time.Since(sample.fetchedAt) > *delegatorCountTTL {
+			count, err := c.rpcClient.GetDelegatorCount(ctx, pubkey, c.commitment)
+			if err != nil {
+				klog.Errorf("failed to get delegators of %s: %v", pubkey, err)
+				continue
+			}
+
+			sample = delegatorSample{count: count, fetchedAt: time.Now()}
+			c.samples[pubkey] = sample
+			fetchedAt = time.Time{}
+		}
+
+		ch <- cachedMetric(prometheus.MustNewConstMetric(c.delegators, prometheus.GaugeValue,
+			float64(sample.count), pubkey), fetchedAt)
+	}
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/certusone/solana_exporter/pkg/rpc"
+)
+
+func TestDelegatorsCollector(t *testing.T) {
+	defer func(v string) { *votePubkey = v }(*votePubkey)
+	defer func(v time.Duration) { *delegatorCountTTL = v }(*delegatorCountTTL)
+	*votePubkey, *delegatorCountTTL = "vote1,vote2", time.Hour
+
+	node := newFakeNode(t)
+	node.handle("getProgramAccounts", func(params json.RawMessage) interface{} {
+		if strings.Contains(string(params), `"vote1"`) {
+			return []map[string]interface{}{{"pubkey": "stake1"}, {"pubkey": "stake2"}}
+		}
+		// Not a list of accounts, so vote2 fails.
+		return "unexpected"
+	})
+	c := newDelegatorsCollector(rpc.NewRPCClient(node.URL), rpc.CommitmentFinalized)
+
+	for scrape := 1; scrape <= 2; scrape++ {
+		got := emitted(t, c.Collect)
+		want := map[string]float64{`solana_validator_delegator_count{pubkey="vote1"}`: 2}
+		if len(got) != len(want) || got[`solana_validator_delegator_count{pubkey="vote1"}`] != 2 {
+			t.Errorf("scrape %d: emitted %v, want %v", scrape, got, want)
+		}
+	}
+
+	// vote1 is cached, the failing vote2 is tried again on every scrape.
+	if calls := node.callCount("getProgramAccounts"); calls != 3 {
+		t.Errorf("getProgramAccounts called %d times, want 3", calls)
+	}
+
+	// Once the TTL is over, counts are fetched again.
+	*delegatorCountTTL = 0
+	emitted(t, c.Collect)
+	if calls := node.callCount("getProgramAccounts"); calls != 5 {
+		t.Errorf("getProgramAccounts called %d times after the TTL, want 5", calls)
+	}
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/certusone/solana_exporter/pkg/rpc"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestDelinquentDuration(t *testing.T) {
+	node := newFakeNode(t)
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(NewSolanaCollector(node.URL, rpc.CommitmentProcessed))
+
+	steps := []struct {
+		delinquent bool
+		want       float64
+	}{
+		{delinquent: true, want: 1},
+		{delinquent: true, want: 2},
+		{delinquent: true, want: 3},
+		{delinquent: false, want: 0},
+		// A validator that recovered starts over.
+		{delinquent: true, want: 1},
+	}
+
+	for i, step := range steps {
+		accounts := map[string]interface{}{
+			"current":    []map[string]interface{}{voteAccount("vote1", "node1", 5)},
+			"delinquent": []map[string]interface{}{},
+		}
+		if step.delinquent {
+			accounts["delinquent"] = []map[string]interface{}{voteAccount("vote2", "node2", 5)}
+		} else {
+			accounts["current"] = append(accounts["current"].([]map[string]interface{}), voteAccount("vote2", "node2", 5))
+		}
+		node.set("getVoteAccounts", accounts)
+
+		families, _ := registry.Gather()
+		got := metricValue(families, "solana_validator_delinquent_duration", map[string]string{"pubkey": "vote2"})
+		if got != step.want {
+			t.Errorf("scrape %d: solana_validator_delinquent_duration = %v, want %v", i+1, got, step.want)
+		}
+		if got := metricValue(families, "solana_validator_delinquent_duration", map[string]string{"pubkey": "vote1"}); got != 0 {
+			t.Errorf("scrape %d: current validator has delinquent duration %v, want 0", i+1, got)
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"github.com/certusone/solana_exporter/pkg/rpc"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// secondsPerSlot returns the mean slot time over all samples, or false if they cover no slots at all.
+func secondsPerSlot(samples []rpc.PerformanceSample) (float64, bool) {
+	var slots, secs int64
+	for _, sample := range samples {
+		slots += sample.NumSlots
+		secs += sample.SamplePeriodSecs
+	}
+
+	if slots == 0 {
+		return 0, false
+	}
+
+	return float64(secs) / float64(slots), true
+}
+
+// collectEpochProgress emits how far the epoch in info has progressed.
+func (c *solanaCollector) collectEpochProgress(ch chan<- prometheus.Metric, info *rpc.EpochInfo,
+	commitment rpc.Commitment) {
+	labels := c.epochLabelValues(commitment)
+	ch <- prometheus.MustNewConstMetric(c.epochSlotIndex, prometheus.GaugeValue, float64(info.SlotIndex), labels...)
+	ch <- prometheus.MustNewConstMetric(c.epochSlotsInEpoch, prometheus.GaugeValue, float64(info.SlotsInEpoch), labels...)
+	if info.SlotsInEpoch > 0 {
+		ch <- prometheus.MustNewConstMetric(c.epochProgress, prometheus.GaugeValue,
+			100*float64(info.SlotIndex)/float64(info.SlotsInEpoch), labels...)
+	}
+}
+
+// collectEpochTimeRemaining emits the estimated time until the epoch in info ends, assuming the remaining slots
+// take as long as those in the recent performance samples.
+func (c *solanaCollector) collectEpochTimeRemaining(ch chan<- prometheus.Metric, info *rpc.EpochInfo,
+	samples []rpc.PerformanceSample) {
+	secs, ok := secondsPerSlot(samples)
+	if !ok {
+		return
+	}
+
+	remaining := info.SlotsInEpoch - info.SlotIndex
+	if remaining < 0 {
+		remaining = 0
+	}
+
+	ch <- prometheus.MustNewConstMetric(c.epochTimeRemaining, prometheus.GaugeValue, float64(remaining)*secs,
+		c.epochLabelValues(c.commitment)...)
+}
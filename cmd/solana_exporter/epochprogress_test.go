@@ -0,0 +1,52 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/certusone/solana_exporter/pkg/rpc"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestSecondsPerSlot(t *testing.T) {
+	samples := []rpc.PerformanceSample{
+		{NumSlots: 150, SamplePeriodSecs: 60},
+		{NumSlots: 100, SamplePeriodSecs: 60},
+	}
+	if got, ok := secondsPerSlot(samples); !ok || got != 0.48 {
+		t.Errorf("secondsPerSlot = %v, %v, want 0.48, true", got, ok)
+	}
+
+	if _, ok := secondsPerSlot([]rpc.PerformanceSample{{SamplePeriodSecs: 60}}); ok {
+		t.Error("secondsPerSlot of samples covering no slots is ok, want false")
+	}
+}
+
+func TestCollectEpochProgress(t *testing.T) {
+	node := newFakeNode(t)
+	node.set("getEpochInfo", map[string]interface{}{
+		"absoluteSlot": 1000, "blockHeight": 900, "epoch": 5, "slotIndex": 108000, "slotsInEpoch": 432000,
+		"transactionCount": 7,
+	})
+
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(NewSolanaCollector(node.URL, rpc.CommitmentProcessed))
+	families, _ := registry.Gather()
+
+	for name, want := range map[string]float64{
+		"solana_epoch_slot_index":     108000,
+		"solana_epoch_slots_in_epoch": 432000,
+		"solana_epoch_progress_pct":   25,
+		// 324000 slots left at the 0.4s per slot of the performance sample.
+		"solana_epoch_time_remaining_seconds": 129600,
+	} {
+		if got := metricValue(families, name, nil); got != want {
+			t.Errorf("%s = %v, want %v", name, got, want)
+		}
+	}
+
+	node.set("getRecentPerformanceSamples", []map[string]interface{}{})
+	families, _ = registry.Gather()
+	if got := metricValue(families, "solana_epoch_time_remaining_seconds", nil); got != -1 {
+		t.Errorf("solana_epoch_time_remaining_seconds = %v without performance samples, want no metric", got)
+	}
+}
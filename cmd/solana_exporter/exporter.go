@@ -7,6 +7,11 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"k8s.io/klog/v2"
@@ -14,41 +19,295 @@ import (
 
 const (
 	httpTimeout = 5 * time.Second
+
+	// Interval at which -rpc-token-file is re-read.
+	tokenRefresh = time.Minute
+
+	creditsScopeAllTime = "all-time"
+	creditsScopeEpoch   = "epoch"
+
+	commissionUnitPercent     = "percent"
+	commissionUnitBasisPoints = "bps"
 )
 
 var (
-	rpcAddr    = flag.String("rpcURI", "", "Solana RPC URI (including protocol and path)")
+	// Public RPC endpoints selectable with -network.
+	networkRPCAddrs = map[string]string{
+		"mainnet": "https://api.mainnet-beta.solana.com",
+		"testnet": "https://api.testnet.solana.com",
+		"devnet":  "https://api.devnet.solana.com",
+	}
+)
+
+var (
+	rpcAddr    = flag.String("rpcURI", "", "Solana RPC URI (including protocol and path), comma-separated for failover")
+	network    = flag.String("network", "", "Public cluster to use when -rpcURI is not set (mainnet, testnet or devnet)")
 	addr       = flag.String("addr", ":8080", "Listen address")
-	votePubkey = flag.String("votepubkey", "", "Validator vote address (will only return results of this address)")
+	votePubkey = flag.String("votepubkey", "", "Validator vote address, or a comma-separated list of them (will only return results of these addresses)")
 	noVoting   = flag.Bool("no-voting", false, "Specify for RPC node without voting")
+	commitment = flag.String("commitment", string(rpc.CommitmentProcessed),
+		"Commitment level for RPC queries (processed, confirmed or finalized)")
+	maxCommission = flag.Int("max-commission", -1,
+		"Commission (in percent) above which a watched validator is reported as over threshold, disabled if negative")
+	creditsScope = flag.String("credits-scope", creditsScopeAllTime,
+		"Credits reported by solana_validator_total_credits: all-time (cumulative since genesis) or epoch (current epoch only)")
+	balanceAll        = flag.Bool("balance-all", false, "Fetch balances of all validators' identity and vote accounts")
+	validatorVersions = flag.Bool("validator-versions", false,
+		"Add the software version from getClusterNodes as a label to per-validator vote account metrics")
+	rpcMaxBodyBytes = flag.Int64("rpc-max-body-bytes", rpc.DefaultMaxBodyBytes, "Maximum size of an RPC response body")
+	rpcRetries      = flag.Int("rpc-retries", 1, "How often an RPC request failing with a timeout, connection, rate limit or server error is retried")
+	tokenAccounts   = flag.String("token-accounts", "", "Comma separated SPL token accounts to export the balance of")
+	pushgateway     = flag.String("pushgateway", "", "Pushgateway URL to push metrics to (disabled if empty)")
+	pushJob         = flag.String("pushgateway-job", "solana_exporter", "Job name used when pushing to the Pushgateway")
+	pushGrouping    = flag.String("pushgateway-grouping", "", "Comma separated name=value grouping labels for the Pushgateway")
+	pollInterval    = flag.Duration("poll-interval", 30*time.Second, "Interval between pushes to the Pushgateway, between polls with -background-polling, and for which -shard-metrics endpoints share a collection")
+	maxSeries       = flag.Int("max-series", 0,
+		"Number of series per scrape after which per-validator series are dropped, unlimited if 0")
+	summaryVerbosity = flag.Int("summary-v", 1, "Log verbosity at which a summary of each scrape is logged")
+	adminAddr        = flag.String("admin-addr", "",
+		"Listen address for metrics about the exporter itself, served along with the node metrics if empty")
+	failOnStartupError = flag.Bool("fail-on-startup-error", false,
+		"Exit if the RPC endpoint is not reachable on startup instead of logging a warning")
+	rpcMaxRetryAfter = flag.Duration("rpc-max-retry-after", rpc.DefaultMaxRetryAfter,
+		"Longest Retry-After of a rate limited RPC request that is waited for before retrying, ignored if 0")
+	commissionUnit = flag.String("commission-unit", commissionUnitPercent,
+		"Unit commissions are exported in: percent or bps (basis points)")
+	collectTimeout = flag.Duration("collect-timeout", httpTimeout,
+		"Time after which a scrape is cut short and exports what it gathered so far")
+	rpcTokenFile = flag.String("rpc-token-file", "",
+		"File containing a bearer token sent with every RPC request, re-read every minute")
+	identityPubkey = flag.String("identity", "",
+		"Node identity pubkey to export the balance of, also with -no-voting")
+	extraEpochCommitment = flag.String("extra-epoch-commitment", "",
+		"Additional commitment level to fetch epoch info at, adding a commitment label to the epoch metrics")
+	splitVoteAccounts = flag.Bool("split-vote-accounts", false,
+		"Fetch current and delinquent vote accounts with separate getVoteAccounts calls, for providers supporting the status filter")
 )
 
 func init() {
 	klog.InitFlags(nil)
 }
 
+// splitList splits a comma separated flag value, ignoring empty entries.
+func splitList(s string) []string {
+	var list []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+
+	return list
+}
+
 type solanaCollector struct {
-	rpcClient *rpc.RPCClient
-
-	totalValidatorsDesc     *prometheus.Desc
-	validatorActivatedStake *prometheus.Desc
-	validatorLastVote       *prometheus.Desc
-	validatorRootSlot       *prometheus.Desc
-	validatorDelinquent     *prometheus.Desc
-	solanaVersion           *prometheus.Desc
-	totalLeaderSlots        *prometheus.Desc
-	totalProducedSlots      *prometheus.Desc
-	validatorBalance        *prometheus.Desc
-	validatorEpochCredits   *prometheus.Desc
-	validatorPctVote        *prometheus.Desc
-	validatorTotalCredits   *prometheus.Desc
-	nodeHealth              *prometheus.Desc
-	currentEpoch            *prometheus.Desc
+	rpcClient  *rpc.RPCClient
+	commitment rpc.Commitment
+
+	// Additional commitment level epoch info is fetched at, empty if none.
+	extraCommitment rpc.Commitment
+
+	// Node the confirmed slot is compared against, nil without -reference-rpcURI.
+	referenceClient *rpc.RPCClient
+
+	// Set to 1 once the initial fetch on startup succeeded.
+	ready int32
+
+	// Vote account authorities seen on the previous scrape, keyed by vote pubkey.
+	authoritiesMu sync.Mutex
+	authorities   map[string]voteAuthorities
+
+	// Number of consecutive scrapes each validator has been seen delinquent, keyed by vote pubkey.
+	// Validators that are current or gone from the vote accounts are dropped.
+	delinquentMu      sync.Mutex
+	delinquentStreaks map[string]int
+
+	// Number of flips between current and delinquent of each validator and whether it was delinquent on the
+	// previous scrape, keyed by vote pubkey. Validators gone from the vote accounts are dropped.
+	transitionsMu sync.Mutex
+	transitions   map[string]uint64
+	wasDelinquent map[string]bool
+
+	// Epoch credits ranks of the watched validators on the previous scrape.
+	creditsRanksMu sync.Mutex
+	creditsRanks   creditsRanks
+
+	// Number of duplicate vote accounts dropped from getVoteAccounts responses.
+	droppedDuplicates uint64
+
+	// Number of scrapes in which per-validator series were dropped because of -max-series.
+	cappedScrapes uint64
+
+	// Number of RPC requests made by the most recent scrape.
+	lastScrapeCalls uint64
+
+	// Number of vote accounts in the most recent getVoteAccounts response, to plan the calls of -balance-all.
+	lastVoteAccounts uint64
+
+	// Duration of the most recent Collect in nanoseconds, zero before the first one finished.
+	lastCollectNanos uint64
+
+	// Epoch credits of the watched validators on the previous scrape, keyed by vote pubkey.
+	creditSamplesMu sync.Mutex
+	creditSamples   map[string]creditSample
+
+	// Inflation rewards of the watched validators for rewardsEpoch, keyed by vote pubkey.
+	rewardsMu        sync.Mutex
+	rewardsEpoch     int64
+	rewardsFetchedAt time.Time
+	rewards          map[string]*rpc.InflationReward
+
+	// Fee rewards of the watched validators as leaders in the current epoch.
+	leaderRewardsMu sync.Mutex
+	leaderRewards   *leaderRewards
+
+	// Last known node identity of the watched validators, keyed by vote pubkey.
+	watchedNodesMu sync.Mutex
+	watchedNodes   map[string]string
+
+	// Confirmed slot of the previous scrape and the number of consecutive scrapes it hasn't advanced for.
+	slotMu         sync.Mutex
+	lastSlot       int64
+	stalledScrapes int
+
+	// Metrics of the latest -background-polling poll and when it finished.
+	polledMu sync.Mutex
+	polled   []prometheus.Metric
+	lastPoll time.Time
+
+	// Processed slots sampled for -recent-forks that aren't finalized yet, and whether each of the last
+	// resolved ones was abandoned.
+	forksMu      sync.Mutex
+	forkPending  []int64
+	forkOutcomes []bool
+
+	// When the node was first seen healthy, reset after it was unhealthy or unreachable.
+	firstSeenMu   sync.Mutex
+	firstSeen     time.Time
+	firstSeenNode string
+	nodeDown      bool
+
+	totalValidatorsDesc       *prometheus.Desc
+	validatorActivatedStake   *prometheus.Desc
+	validatorLastVote         *prometheus.Desc
+	validatorVoteDistance     *prometheus.Desc
+	validatorRootSlot         *prometheus.Desc
+	validatorDelinquent       *prometheus.Desc
+	solanaVersion             *prometheus.Desc
+	totalLeaderSlots          *prometheus.Desc
+	totalProducedSlots        *prometheus.Desc
+	validatorBalance          *prometheus.Desc
+	validatorEpochCredits     *prometheus.Desc
+	validatorPctVote          *prometheus.Desc
+	validatorTotalCredits     *prometheus.Desc
+	nodeHealth                *prometheus.Desc
+	currentEpoch              *prometheus.Desc
+	validatorAuthorityChanged *prometheus.Desc
+	validatorAccountBalance   *prometheus.Desc
+	validatorCommissionOver   *prometheus.Desc
+	validatorCommission       *prometheus.Desc
+	clusterLeaderSlots        *prometheus.Desc
+	clusterProducedSlots      *prometheus.Desc
+	voteAccountDuplicates     *prometheus.Desc
+	nodeShredVersion          *prometheus.Desc
+	nonCirculatingAccounts    *prometheus.Desc
+	expectedCredits           *prometheus.Desc
+	validatorCreditEfficiency *prometheus.Desc
+	validatorOwned            *prometheus.Desc
+	validatorStakeRank        *prometheus.Desc
+	validatorStakePercentile  *prometheus.Desc
+	validatorDelinquentFor    *prometheus.Desc
+	stakeByCommissionTier     *prometheus.Desc
+	nodeVersionMatchesMode    *prometheus.Desc
+	tokenAccountBalance       *prometheus.Desc
+	watchedValidators         *prometheus.Desc
+	nodeFirstSeen             *prometheus.Desc
+	blockProductionRange      *prometheus.Desc
+	validatorIdentityInfo     *prometheus.Desc
+	seriesCapped              *prometheus.Desc
+	inflationReward           *prometheus.Desc
+	rewardCommission          *prometheus.Desc
+	rewardPostBalance         *prometheus.Desc
+	epochReward               *prometheus.Desc
+	epochRewardPostBalance    *prometheus.Desc
+	finalizationGap           *prometheus.Desc
+	validatorStakeShare       *prometheus.Desc
+	nodeIsValidator           *prometheus.Desc
+	validatorCreditRate       *prometheus.Desc
+	skippedSlotsEstimate      *prometheus.Desc
+	leaderSlotsCounter        *prometheus.Desc
+	producedSlotsCounter      *prometheus.Desc
+	clockSkew                 *prometheus.Desc
+	rpcCallsPerScrape         *prometheus.Desc
+	leaderRewardsLamports     *prometheus.Desc
+	validatorVoteLatency      *prometheus.Desc
+	clusterDelinquentStake    *prometheus.Desc
+	nodeRPCEnabled            *prometheus.Desc
+	validatorCreditsRankDelta *prometheus.Desc
+	collectDuration           *prometheus.Desc
+	lastPollTimestamp         *prometheus.Desc
+	delinquencyTransitions    *prometheus.Desc
+	transactionsPerSecond     *prometheus.Desc
+	nodeIdentityBalance       *prometheus.Desc
+	rpcPing                   *prometheus.Desc
+	configInfo                *prometheus.Desc
+	slotStuck                 *prometheus.Desc
+	leaderSlotsRemaining      *prometheus.Desc
+	recentForks               *prometheus.Desc
+	nodeSlotsBehind           *prometheus.Desc
+	skipRateVsCluster         *prometheus.Desc
+	projectedEpochRewards     *prometheus.Desc
+	validatorSkipRate         *prometheus.Desc
+	epochSlotIndex            *prometheus.Desc
+	epochSlotsInEpoch         *prometheus.Desc
+	epochProgress             *prometheus.Desc
+	epochTimeRemaining        *prometheus.Desc
+	slotsPerSample            *prometheus.Desc
+	transactionCount          *prometheus.Desc
+	nodeReferenceSlotLag      *prometheus.Desc
 }
 
-func NewSolanaCollector(rpcAddr string) *solanaCollector {
+func NewSolanaCollector(rpcAddr string, commitment rpc.Commitment) *solanaCollector {
+	validatorLabels := []string{"pubkey", "nodekey"}
+	if *validatorVersions {
+		validatorLabels = append(validatorLabels, "version")
+	}
+
+	epochLabels, skippedLabels := []string{"epoch"}, []string(nil)
+	if *extraEpochCommitment != "" {
+		epochLabels, skippedLabels = append(epochLabels, "commitment"), []string{"commitment"}
+	}
+
+	rpcOptions := []rpc.Option{
+		rpc.WithMaxBodyBytes(*rpcMaxBodyBytes),
+		rpc.WithRetries(*rpcRetries),
+		rpc.WithMaxRetryAfter(*rpcMaxRetryAfter),
+	}
+	if *rpcTokenFile != "" {
+		rpcOptions = append(rpcOptions, rpc.WithTokenFile(rpc.NewTokenFile(*rpcTokenFile, tokenRefresh)))
+	}
+	// Validated in main.
+	if headers, _ := rpcHeaders(); len(headers) > 0 {
+		rpcOptions = append(rpcOptions, rpc.WithHeaders(headers))
+	}
+	// The first endpoint is the primary one, the others are failed over to in order.
+	addrs := splitList(rpcAddr)
+	if len(addrs) > 1 {
+		rpcOptions = append(rpcOptions, rpc.WithFallbacks(addrs[1:]...))
+	}
+	if *splitVoteAccounts {
+		rpcOptions = append(rpcOptions, rpc.WithSplitVoteAccounts())
+	}
+
 	return &solanaCollector{
-		rpcClient: rpc.NewRPCClient(rpcAddr),
+		rpcClient:         rpc.NewRPCClient(addrs[0], rpcOptions...),
+		commitment:        commitment,
+		authorities:       make(map[string]voteAuthorities),
+		delinquentStreaks: make(map[string]int),
+		transitions:       make(map[string]uint64),
+		wasDelinquent:     make(map[string]bool),
+		creditSamples:     make(map[string]creditSample),
+		watchedNodes:      make(map[string]string),
 		totalValidatorsDesc: prometheus.NewDesc(
 			"solana_active_validators",
 			"Total number of active validators by state",
@@ -56,19 +315,23 @@ func NewSolanaCollector(rpcAddr string) *solanaCollector {
 		validatorActivatedStake: prometheus.NewDesc(
 			"solana_validator_activated_stake",
 			"Activated stake per validator",
-			[]string{"pubkey", "nodekey"}, nil),
+			validatorLabels, nil),
 		validatorLastVote: prometheus.NewDesc(
 			"solana_validator_last_vote",
 			"Last voted slot per validator",
-			[]string{"pubkey", "nodekey"}, nil),
+			validatorLabels, nil),
+		validatorVoteDistance: prometheus.NewDesc(
+			"solana_validator_vote_distance",
+			"Number of slots the last vote of each validator is behind the current slot",
+			validatorLabels, nil),
 		validatorRootSlot: prometheus.NewDesc(
 			"solana_validator_root_slot",
 			"Root slot per validator",
-			[]string{"pubkey", "nodekey"}, nil),
+			validatorLabels, nil),
 		validatorDelinquent: prometheus.NewDesc(
 			"solana_validator_delinquent",
 			"Whether a validator is delinquent",
-			[]string{"pubkey", "nodekey"}, nil),
+			validatorLabels, nil),
 		solanaVersion: prometheus.NewDesc(
 			"solana_node_version",
 			"Node version of solana",
@@ -84,32 +347,289 @@ func NewSolanaCollector(rpcAddr string) *solanaCollector {
 		validatorBalance: prometheus.NewDesc(
 			"solana_validator_balance",
 			"The balance of the account of validator identity and vote pubkey",
-			[]string{"account"}, nil),
+			[]string{"account", "pubkey"}, nil),
 		validatorEpochCredits: prometheus.NewDesc(
 			"solana_validator_epoch_credits",
 			"How many credits earned by current epoch",
-			[]string{"pubkey", "nodekey"}, nil),
+			validatorLabels, nil),
 		validatorPctVote: prometheus.NewDesc(
 			"solana_validator_voting_percentage",
-			"The percentage of participate voting in current epoch",
-			[]string{"pubkey", "nodekey"}, nil),
+			"Credits earned in current epoch as a percentage of the most that could have been earned",
+			validatorLabels, nil),
 		validatorTotalCredits: prometheus.NewDesc(
 			"solana_validator_total_credits",
-			"Total credits earned by validator",
-			[]string{"pubkey", "nodekey"}, nil),
+			"Credits earned by validator, either all-time or in current epoch depending on -credits-scope",
+			validatorLabels, nil),
 		nodeHealth: prometheus.NewDesc(
 			"solana_health_check",
 			"Health status of solana node",
 			[]string{"nodekey"}, nil),
+		validatorSkipRate: prometheus.NewDesc(
+			"solana_validator_skip_rate",
+			"Share of the validator's leader slots in the epoch without a produced block",
+			[]string{"pubkey", "nodekey", "epoch"}, nil),
+		projectedEpochRewards: prometheus.NewDesc(
+			"solana_validator_projected_epoch_rewards_lamports",
+			"Estimated inflation rewards of the validator for current epoch, by recipient (vote or stakers)",
+			[]string{"pubkey", "nodekey", "recipient"}, nil),
+		skipRateVsCluster: prometheus.NewDesc(
+			"solana_validator_skip_rate_vs_cluster",
+			"Skip rate of the validator in current epoch minus that of the whole cluster",
+			[]string{"pubkey", "nodekey"}, nil),
+		nodeSlotsBehind: prometheus.NewDesc(
+			"solana_node_slots_behind",
+			"Number of slots the node is behind the cluster according to getHealth",
+			[]string{"nodekey"}, nil),
 		currentEpoch: prometheus.NewDesc(
 			"solana_current_epoch",
 			"Current epoch number",
-			[]string{"epoch"}, nil),
+			epochLabels, nil),
+		validatorAuthorityChanged: prometheus.NewDesc(
+			"solana_validator_authority_changed",
+			"Whether the vote account authority changed since the previous scrape",
+			[]string{"pubkey", "authority"}, nil),
+		validatorAccountBalance: prometheus.NewDesc(
+			"solana_validator_account_balance",
+			"The balance of the identity and vote account of each validator (only with -balance-all)",
+			[]string{"pubkey", "nodekey", "account"}, nil),
+		validatorCommissionOver: prometheus.NewDesc(
+			"solana_validator_commission_over_threshold",
+			"Whether the commission of a validator given with -votepubkey exceeds -max-commission",
+			validatorLabels, nil),
+		validatorCommission: prometheus.NewDesc(
+			"solana_validator_commission",
+			"Commission of the vote account, in percent or basis points depending on -commission-unit",
+			validatorLabels, nil),
+		clusterLeaderSlots: prometheus.NewDesc(
+			"solana_cluster_leader_slots",
+			"The number of leader slots of all validators in current epoch",
+			nil, nil),
+		clusterProducedSlots: prometheus.NewDesc(
+			"solana_cluster_produced_slots",
+			"The number of produced slots of all validators in current epoch",
+			nil, nil),
+		voteAccountDuplicates: prometheus.NewDesc(
+			"solana_vote_account_duplicates_total",
+			"Number of duplicate vote accounts dropped from getVoteAccounts responses",
+			nil, nil),
+		nodeShredVersion: prometheus.NewDesc(
+			"solana_node_shred_version",
+			"Shred version advertised by the node in gossip",
+			[]string{"nodekey"}, nil),
+		nonCirculatingAccounts: prometheus.NewDesc(
+			"solana_non_circulating_account_count",
+			"Number of accounts holding non-circulating supply",
+			nil, nil),
+		expectedCredits: prometheus.NewDesc(
+			"solana_validator_expected_credits",
+			"Ideal number of credits earned by current epoch (one per slot)",
+			nil, nil),
+		validatorCreditEfficiency: prometheus.NewDesc(
+			"solana_validator_credit_efficiency",
+			"Ratio of credits earned to the ideal number of credits in current epoch",
+			validatorLabels, nil),
+		validatorOwned: prometheus.NewDesc(
+			"solana_validator_owned",
+			"Set to 1 for validators watched with -votepubkey",
+			[]string{"pubkey", "nodekey"}, nil),
+		validatorStakeRank: prometheus.NewDesc(
+			"solana_validator_stake_rank",
+			"Rank of the validator by activated stake among current validators (1 is the highest)",
+			[]string{"pubkey", "nodekey"}, nil),
+		validatorStakePercentile: prometheus.NewDesc(
+			"solana_validator_stake_percentile",
+			"Percentage of current validators with the same or a lower stake rank than the validator",
+			[]string{"pubkey", "nodekey"}, nil),
+		validatorDelinquentFor: prometheus.NewDesc(
+			"solana_validator_delinquent_duration",
+			"Number of consecutive scrapes the validator has been delinquent, 0 if current",
+			validatorLabels, nil),
+		stakeByCommissionTier: prometheus.NewDesc(
+			"solana_stake_by_commission_tier",
+			"Activated stake of all validators grouped by commission tier",
+			[]string{"tier"}, nil),
+		nodeVersionMatchesMode: prometheus.NewDesc(
+			"solana_node_version_matches_cluster_mode",
+			"Whether the node runs the most common software version in the cluster",
+			[]string{"nodekey"}, nil),
+		tokenAccountBalance: prometheus.NewDesc(
+			"solana_token_account_balance",
+			"Balance of SPL token accounts given with -token-accounts, in token units",
+			[]string{"pubkey", "mint", "owner"}, nil),
+		watchedValidators: prometheus.NewDesc(
+			"solana_exporter_watched_validators",
+			"Number of vote pubkeys configured with -votepubkey",
+			nil, nil),
+		configInfo: prometheus.NewDesc(
+			"solana_exporter_config_info",
+			"Always 1, labeled with the effective exporter configuration",
+			configInfoLabels, nil),
+		nodeFirstSeen: prometheus.NewDesc(
+			"solana_exporter_node_first_seen_timestamp_seconds",
+			"Unix timestamp at which the node was first seen healthy since its last outage",
+			[]string{"nodekey"}, nil),
+		blockProductionRange: prometheus.NewDesc(
+			"solana_block_production_range_slots",
+			"Number of slots covered by the block production counts",
+			nil, nil),
+		validatorIdentityInfo: prometheus.NewDesc(
+			"solana_validator_identity_info",
+			"Maps vote pubkeys to the identity pubkey of their node, always 1",
+			[]string{"pubkey", "nodekey"}, nil),
+		seriesCapped: prometheus.NewDesc(
+			"solana_exporter_series_capped_total",
+			"Number of scrapes in which per-validator series were dropped because of -max-series",
+			nil, nil),
+		inflationReward: prometheus.NewDesc(
+			"solana_validator_inflation_reward",
+			"Deprecated, use solana_validator_epoch_reward_lamports",
+			[]string{"pubkey", "epoch"}, nil),
+		rewardCommission: prometheus.NewDesc(
+			"solana_validator_inflation_reward_commission",
+			"Commission of the vote account when the inflation reward for the previous epoch was credited",
+			[]string{"pubkey", "epoch"}, nil),
+		rewardPostBalance: prometheus.NewDesc(
+			"solana_validator_inflation_reward_post_balance",
+			"Deprecated, use solana_validator_epoch_reward_post_balance",
+			[]string{"pubkey", "epoch"}, nil),
+		epochReward: prometheus.NewDesc(
+			"solana_validator_epoch_reward_lamports",
+			"Inflation reward credited to the vote account at the start of the current epoch, for the previous one",
+			[]string{"pubkey", "epoch"}, nil),
+		epochRewardPostBalance: prometheus.NewDesc(
+			"solana_validator_epoch_reward_post_balance",
+			"Balance of the vote account after the inflation reward of the previous epoch was credited, in lamports",
+			[]string{"pubkey", "epoch"}, nil),
+		finalizationGap: prometheus.NewDesc(
+			"solana_confirmation_finalization_gap_slots",
+			"Number of slots the confirmed slot is ahead of the finalized slot",
+			nil, nil),
+		validatorStakeShare: prometheus.NewDesc(
+			"solana_validator_stake_share",
+			"Share of the total activated stake held by the validator, between 0 and 1",
+			[]string{"pubkey", "nodekey"}, nil),
+		nodeIsValidator: prometheus.NewDesc(
+			"solana_node_is_validator",
+			"Whether the node's identity has a vote account",
+			[]string{"nodekey"}, nil),
+		validatorCreditRate: prometheus.NewDesc(
+			"solana_validator_credit_rate",
+			"Vote credits earned per second since the previous scrape",
+			validatorLabels, nil),
+		skippedSlotsEstimate: prometheus.NewDesc(
+			"solana_node_skipped_slots_estimate",
+			"Number of slots without a block since genesis, estimated as slot minus block height",
+			skippedLabels, nil),
+		epochSlotIndex: prometheus.NewDesc(
+			"solana_epoch_slot_index",
+			"Current slot relative to the start of the current epoch",
+			skippedLabels, nil),
+		epochSlotsInEpoch: prometheus.NewDesc(
+			"solana_epoch_slots_in_epoch",
+			"Number of slots in the current epoch",
+			skippedLabels, nil),
+		epochProgress: prometheus.NewDesc(
+			"solana_epoch_progress_pct",
+			"Share of the current epoch's slots that have passed, in percent",
+			skippedLabels, nil),
+		epochTimeRemaining: prometheus.NewDesc(
+			"solana_epoch_time_remaining_seconds",
+			"Estimated seconds until the current epoch ends, based on the slot time of the recent performance samples",
+			skippedLabels, nil),
+		// solana_leader_slots_total is the skip status counter of WatchSlots, which has other labels.
+		leaderSlotsCounter: prometheus.NewDesc(
+			"solana_assigned_leader_slots_total",
+			"Number of leader slots assigned in the current epoch, resets at epoch boundaries",
+			[]string{"pubkey", "nodekey"}, nil),
+		producedSlotsCounter: prometheus.NewDesc(
+			"solana_produced_slots_total",
+			"Number of blocks produced in the current epoch, resets at epoch boundaries",
+			[]string{"pubkey", "nodekey"}, nil),
+		clockSkew: prometheus.NewDesc(
+			"solana_node_clock_skew_seconds",
+			"Seconds the latest confirmed block time is behind the local clock, including a few seconds of confirmation delay",
+			nil, nil),
+		rpcCallsPerScrape: prometheus.NewDesc(
+			"solana_exporter_rpc_calls_per_scrape",
+			"Number of RPC requests made by the most recent scrape, including retries",
+			nil, nil),
+		lastPollTimestamp: prometheus.NewDesc(
+			"solana_exporter_last_poll_timestamp",
+			"Unix timestamp at which the latest background poll of the node metrics finished",
+			nil, nil),
+		collectDuration: prometheus.NewDesc(
+			"solana_exporter_collect_duration_seconds",
+			"Time the most recent scrape of the node metrics took",
+			nil, nil),
+		leaderRewardsLamports: prometheus.NewDesc(
+			"solana_validator_leader_rewards_lamports",
+			"Fee rewards received as leader in the current epoch, in lamports",
+			[]string{"pubkey", "nodekey"}, nil),
+		validatorVoteLatency: prometheus.NewDesc(
+			"solana_validator_vote_latency",
+			"Mean number of slots votes take to land in the current epoch, estimated from the credits earned",
+			validatorLabels, nil),
+		clusterDelinquentStake: prometheus.NewDesc(
+			"solana_cluster_delinquent_stake_percent",
+			"Share of the total activated stake held by delinquent validators, in percent",
+			nil, nil),
+		nodeRPCEnabled: prometheus.NewDesc(
+			"solana_node_rpc_enabled",
+			"Whether the node advertises a JSON RPC address in gossip",
+			[]string{"nodekey"}, nil),
+		delinquencyTransitions: prometheus.NewDesc(
+			"solana_validator_delinquency_transitions_total",
+			"Number of times the validator flipped between current and delinquent since the exporter started",
+			validatorLabels, nil),
+		leaderSlotsRemaining: prometheus.NewDesc(
+			"solana_validator_leader_slots_remaining",
+			"Number of leader slots the validator has left in the current epoch",
+			[]string{"pubkey", "nodekey"}, nil),
+		recentForks: prometheus.NewDesc(
+			"solana_recent_forks",
+			"Number of the node's recently sampled processed slots that were abandoned instead of finalized",
+			nil, nil),
+		slotStuck: prometheus.NewDesc(
+			"solana_node_slot_stuck",
+			"Whether the node's confirmed slot hasn't advanced for more than -slot-stuck-scrapes scrapes",
+			nil, nil),
+		rpcPing: prometheus.NewDesc(
+			"solana_rpc_ping_seconds",
+			"Round trip time of the getHealth call to the RPC endpoint",
+			nil, nil),
+		nodeIdentityBalance: prometheus.NewDesc(
+			"solana_node_identity_balance",
+			"Balance of the identity account given with -identity, in lamports",
+			[]string{"nodekey"}, nil),
+		transactionsPerSecond: prometheus.NewDesc(
+			"solana_cluster_tps",
+			"Transactions processed per second, averaged over the recent performance samples",
+			nil, nil),
+		slotsPerSample: prometheus.NewDesc(
+			"solana_cluster_slots_per_sample",
+			"Number of slots completed per one minute performance sample, averaged over the recent samples",
+			nil, nil),
+		transactionCount: prometheus.NewDesc(
+			"solana_network_transaction_count",
+			"Number of transactions processed since genesis, from getTransactionCount",
+			nil, nil),
+		nodeReferenceSlotLag: prometheus.NewDesc(
+			"solana_node_reference_slot_lag",
+			"Number of slots the confirmed slot of the node is behind that of -reference-rpcURI, falling back to getHealth",
+			nil, nil),
+		validatorCreditsRankDelta: prometheus.NewDesc(
+			"solana_validator_credits_rank_delta",
+			"Number of places the validator climbed in the epoch credits ranking of current validators since the previous scrape",
+			[]string{"pubkey", "nodekey"}, nil),
 	}
 }
 
 func (c *solanaCollector) Describe(ch chan<- *prometheus.Desc) {
 	ch <- c.totalValidatorsDesc
+	ch <- c.validatorActivatedStake
+	ch <- c.validatorLastVote
+	ch <- c.validatorRootSlot
+	ch <- c.validatorDelinquent
 	ch <- c.solanaVersion
 	ch <- c.totalLeaderSlots
 	ch <- c.totalProducedSlots
@@ -119,58 +639,479 @@ func (c *solanaCollector) Describe(ch chan<- *prometheus.Desc) {
 	ch <- c.validatorTotalCredits
 	ch <- c.nodeHealth
 	ch <- c.currentEpoch
+	ch <- c.validatorAuthorityChanged
+	ch <- c.validatorAccountBalance
+	ch <- c.validatorCommissionOver
+	ch <- c.validatorCommission
+	ch <- c.clusterLeaderSlots
+	ch <- c.clusterProducedSlots
+	ch <- c.voteAccountDuplicates
+	ch <- c.nodeShredVersion
+	ch <- c.nonCirculatingAccounts
+	ch <- c.expectedCredits
+	ch <- c.validatorCreditEfficiency
+	ch <- c.validatorOwned
+	ch <- c.validatorStakeRank
+	ch <- c.validatorStakePercentile
+	ch <- c.validatorDelinquentFor
+	ch <- c.stakeByCommissionTier
+	ch <- c.nodeVersionMatchesMode
+	ch <- c.tokenAccountBalance
+	ch <- c.blockProductionRange
+	ch <- c.validatorIdentityInfo
+	ch <- c.inflationReward
+	ch <- c.rewardCommission
+	ch <- c.rewardPostBalance
+	ch <- c.epochReward
+	ch <- c.epochRewardPostBalance
+	ch <- c.finalizationGap
+	ch <- c.validatorStakeShare
+	ch <- c.nodeIsValidator
+	ch <- c.validatorCreditRate
+	ch <- c.skippedSlotsEstimate
+	ch <- c.leaderSlotsCounter
+	ch <- c.producedSlotsCounter
+	ch <- c.clockSkew
+	ch <- c.leaderRewardsLamports
+	ch <- c.validatorVoteLatency
+	ch <- c.clusterDelinquentStake
+	ch <- c.nodeRPCEnabled
+	ch <- c.validatorCreditsRankDelta
+	ch <- c.delinquencyTransitions
+	ch <- c.transactionsPerSecond
+	ch <- c.nodeIdentityBalance
+	ch <- c.rpcPing
+	ch <- c.slotStuck
+	ch <- c.leaderSlotsRemaining
+	ch <- c.recentForks
+	ch <- c.nodeSlotsBehind
+	ch <- c.skipRateVsCluster
+	ch <- c.projectedEpochRewards
+	ch <- c.validatorSkipRate
+	ch <- c.epochSlotIndex
+	ch <- c.epochSlotsInEpoch
+	ch <- c.epochProgress
+	ch <- c.epochTimeRemaining
+	ch <- c.slotsPerSample
+	ch <- c.transactionCount
+	ch <- c.nodeReferenceSlotLag
+	ch <- c.validatorVoteDistance
+}
+
+// voteDistance returns how many slots lastVote is behind the current slot. Epoch info is fetched before the vote
+// accounts, so a vote can land on a slot past the one reported as current, which counts as no distance.
+func voteDistance(current, lastVote int64) int64 {
+	if lastVote > current {
+		return 0
+	}
+
+	return current - lastVote
 }
 
-func (c *solanaCollector) calcEpochCredits(credits [][]int) int {
+// calcEpochCredits returns the credits earned in the most recent epoch with an epochCredits entry, zero for a
+// vote account that hasn't earned any credits yet.
+func (c *solanaCollector) calcEpochCredits(credits [][3]int) int {
 	size := len(credits)
+	if size == 0 {
+		return 0
+	}
 
 	return credits[size-1][1] - credits[size-1][2]
 }
 
-func (c *solanaCollector) mustEmitMetrics(ch chan<- prometheus.Metric, response *rpc.GetVoteAccountsResponse, epoch *rpc.EpochInfo) {
+// calcTotalCredits returns the credits reported by solana_validator_total_credits. The last epochCredits
+// entry is [epoch, credits, previousCredits], where credits is the cumulative count since genesis.
+func (c *solanaCollector) calcTotalCredits(credits [][3]int, scope string) int {
+	if scope == creditsScopeEpoch || len(credits) == 0 {
+		return c.calcEpochCredits(credits)
+	}
+
+	return credits[len(credits)-1][1]
+}
+
+// dedupVoteAccounts removes repeated vote pubkeys from the response, keeping the first occurrence.
+// A duplicate would otherwise make the whole scrape fail on duplicate series.
+func (c *solanaCollector) dedupVoteAccounts(response *rpc.GetVoteAccountsResponse) {
+	seen := make(map[string]bool)
+	filter := func(accounts []rpc.VoteAccount) []rpc.VoteAccount {
+		unique := accounts[:0]
+		for _, account := range accounts {
+			if seen[account.VotePubkey] {
+				klog.Warningf("dropping duplicate vote account %s", account.VotePubkey)
+				atomic.AddUint64(&c.droppedDuplicates, 1)
+				continue
+			}
+			seen[account.VotePubkey] = true
+			unique = append(unique, account)
+		}
+		return unique
+	}
+
+	response.Result.Current = filter(response.Result.Current)
+	response.Result.Delinquent = filter(response.Result.Delinquent)
+}
+
+// watchedVotePubkeys returns the vote pubkeys configured with -votepubkey and -identities-file, without
+// duplicates. Callers must hold configMu.
+func watchedVotePubkeys() []string {
+	seen := make(map[string]bool)
+	var pubkeys []string
+	for _, pubkey := range append(splitList(*votePubkey), filePubkeys...) {
+		if !seen[pubkey] {
+			seen[pubkey] = true
+			pubkeys = append(pubkeys, pubkey)
+		}
+	}
+
+	return pubkeys
+}
+
+// missingVotePubkeys returns the watched vote pubkeys that aren't among the found vote accounts.
+func missingVotePubkeys(watched []string, found []rpc.VoteAccount) []string {
+	seen := make(map[string]bool, len(found))
+	for _, account := range found {
+		seen[account.VotePubkey] = true
+	}
+
+	var missing []string
+	for _, pubkey := range watched {
+		if !seen[pubkey] {
+			missing = append(missing, pubkey)
+		}
+	}
+
+	return missing
+}
+
+// validatorLabelValues returns the label values of per-validator vote account metrics. versions maps node
+// identities to software versions and is only used with -validator-versions.
+func (c *solanaCollector) validatorLabelValues(account rpc.VoteAccount, versions map[string]string) []string {
+	if !*validatorVersions {
+		return []string{account.VotePubkey, account.NodePubkey}
+	}
+
+	version, ok := versions[account.NodePubkey]
+	if !ok {
+		version = "unknown"
+	}
+
+	return []string{account.VotePubkey, account.NodePubkey, version}
+}
+
+func (c *solanaCollector) mustEmitMetrics(ch chan<- prometheus.Metric, cfg runtimeConfig,
+	response *rpc.GetVoteAccountsResponse, epoch *rpc.EpochInfo, versions map[string]string) {
+	c.dedupVoteAccounts(response)
+	ch <- prometheus.MustNewConstMetric(c.voteAccountDuplicates, prometheus.CounterValue,
+		float64(atomic.LoadUint64(&c.droppedDuplicates)))
+
 	ch <- prometheus.MustNewConstMetric(c.totalValidatorsDesc, prometheus.GaugeValue,
 		float64(len(response.Result.Delinquent)), "delinquent")
 	ch <- prometheus.MustNewConstMetric(c.totalValidatorsDesc, prometheus.GaugeValue,
 		float64(len(response.Result.Current)), "current")
+	// Without epoch info, metrics relative to the progress of the epoch are skipped.
+	accounts := append(response.Result.Current, response.Result.Delinquent...)
+	var timely bool
+	if epoch != nil {
+		ch <- prometheus.MustNewConstMetric(c.expectedCredits, prometheus.GaugeValue, float64(epoch.SlotIndex))
+		timely = c.timelyVoteCredits(accounts, epoch.SlotIndex)
+	}
 
-	for _, account := range append(response.Result.Current, response.Result.Delinquent...) {
+	for _, account := range accounts {
+		labels := c.validatorLabelValues(account, versions)
+		ch <- prometheus.MustNewConstMetric(c.validatorIdentityInfo, prometheus.GaugeValue,
+			1, account.VotePubkey, account.NodePubkey)
 		ch <- prometheus.MustNewConstMetric(c.validatorActivatedStake, prometheus.GaugeValue,
-			float64(account.ActivatedStake), account.VotePubkey, account.NodePubkey)
-		ch <- prometheus.MustNewConstMetric(c.validatorLastVote, prometheus.GaugeValue,
-			float64(account.LastVote), account.VotePubkey, account.NodePubkey)
-		ch <- prometheus.MustNewConstMetric(c.validatorRootSlot, prometheus.GaugeValue,
-			float64(account.RootSlot), account.VotePubkey, account.NodePubkey)
+			float64(account.ActivatedStake), labels...)
+		// A vote account that hasn't voted yet reports null, decoded as slot 0, which isn't a slot it
+		// voted on or rooted and would be far off every other validator's.
+		if account.LastVote > 0 {
+			ch <- prometheus.MustNewConstMetric(c.validatorLastVote, prometheus.GaugeValue,
+				float64(account.LastVote), labels...)
+			if epoch != nil {
+				ch <- prometheus.MustNewConstMetric(c.validatorVoteDistance, prometheus.GaugeValue,
+					float64(voteDistance(epoch.AbsoluteSlot, int64(account.LastVote))), labels...)
+			}
+		}
+		if account.RootSlot > 0 {
+			ch <- prometheus.MustNewConstMetric(c.validatorRootSlot, prometheus.GaugeValue,
+				float64(account.RootSlot), labels...)
+		}
 		credits := c.calcEpochCredits(account.EpochCredits)
 		ch <- prometheus.MustNewConstMetric(c.validatorEpochCredits, prometheus.GaugeValue,
-			float64(credits), account.VotePubkey, account.NodePubkey)
-		ch <- prometheus.MustNewConstMetric(c.validatorPctVote, prometheus.GaugeValue,
-			float64(credits)/float64(epoch.SlotIndex)*100.0, account.VotePubkey, account.NodePubkey)
+			float64(credits), labels...)
+		if epoch != nil {
+			if pct, ok := votingPercentage(credits, epoch.SlotIndex, timely); ok {
+				ch <- prometheus.MustNewConstMetric(c.validatorPctVote, prometheus.GaugeValue, pct, labels...)
+			}
+		}
 		ch <- prometheus.MustNewConstMetric(c.validatorTotalCredits, prometheus.GaugeValue,
-			float64(account.EpochCredits[len(account.EpochCredits)-1][1]), account.VotePubkey, account.NodePubkey)
+			float64(c.calcTotalCredits(account.EpochCredits, cfg.creditsScope)), labels...)
+
+		if cfg.isWatched(account.VotePubkey) {
+			ch <- prometheus.MustNewConstMetric(c.validatorOwned, prometheus.GaugeValue,
+				1, account.VotePubkey, account.NodePubkey)
+
+			if n := len(account.EpochCredits); n > 0 {
+				creditsEpoch := account.EpochCredits[n-1][0]
+				if rate, ok := c.creditRate(account.VotePubkey, creditsEpoch, credits, time.Now()); ok {
+					ch <- prometheus.MustNewConstMetric(c.validatorCreditRate, prometheus.GaugeValue, rate, labels...)
+				}
+			}
+		}
+
+		// No credits can be expected in the very first slot of an epoch.
+		if epoch != nil && epoch.SlotIndex > 0 {
+			efficiency := float64(credits) / float64(epoch.SlotIndex)
+			ch <- prometheus.MustNewConstMetric(c.validatorCreditEfficiency, prometheus.GaugeValue,
+				efficiency, labels...)
+
+			if latency, ok := estimateVoteLatency(efficiency, timely); ok && cfg.isWatched(account.VotePubkey) {
+				ch <- prometheus.MustNewConstMetric(c.validatorVoteLatency, prometheus.GaugeValue,
+					latency, labels...)
+			}
+		}
+
+		ch <- prometheus.MustNewConstMetric(c.validatorCommission, prometheus.GaugeValue,
+			commissionValue(account.Commission), labels...)
+
+		if cfg.maxCommission >= 0 && cfg.isWatched(account.VotePubkey) {
+			var over float64
+			if account.Commission > cfg.maxCommission {
+				over = 1
+			}
+			ch <- prometheus.MustNewConstMetric(c.validatorCommissionOver, prometheus.GaugeValue,
+				over, labels...)
+		}
 	}
 	for _, account := range response.Result.Current {
 		ch <- prometheus.MustNewConstMetric(c.validatorDelinquent, prometheus.GaugeValue,
-			0, account.VotePubkey, account.NodePubkey)
+			0, c.validatorLabelValues(account, versions)...)
 	}
 	for _, account := range response.Result.Delinquent {
 		ch <- prometheus.MustNewConstMetric(c.validatorDelinquent, prometheus.GaugeValue,
-			1, account.VotePubkey, account.NodePubkey)
+			1, c.validatorLabelValues(account, versions)...)
+	}
+
+	streaks := c.updateDelinquentStreaks(response.Result.Delinquent)
+	for _, account := range response.Result.Current {
+		ch <- prometheus.MustNewConstMetric(c.validatorDelinquentFor, prometheus.GaugeValue,
+			0, c.validatorLabelValues(account, versions)...)
+	}
+	for _, account := range response.Result.Delinquent {
+		ch <- prometheus.MustNewConstMetric(c.validatorDelinquentFor, prometheus.GaugeValue,
+			float64(streaks[account.VotePubkey]), c.validatorLabelValues(account, versions)...)
+	}
+
+	transitions := c.updateDelinquencyTransitions(response.Result.Current, response.Result.Delinquent)
+	for _, account := range append(response.Result.Current, response.Result.Delinquent...) {
+		ch <- prometheus.MustNewConstMetric(c.delinquencyTransitions, prometheus.CounterValue,
+			float64(transitions[account.VotePubkey]), c.validatorLabelValues(account, versions)...)
 	}
 }
 
-func (c *solanaCollector) Collect(ch chan<- prometheus.Metric) {
-	ctx, cancel := context.WithTimeout(context.Background(), httpTimeout)
-	defer cancel()
+// updateDelinquencyTransitions counts a transition for every validator whose delinquency differs from the
+// previous scrape. Validators seen for the first time start at zero.
+func (c *solanaCollector) updateDelinquencyTransitions(current, delinquent []rpc.VoteAccount) map[string]uint64 {
+	c.transitionsMu.Lock()
+	defer c.transitionsMu.Unlock()
+
+	transitions := make(map[string]uint64, len(current)+len(delinquent))
+	wasDelinquent := make(map[string]bool, len(current)+len(delinquent))
+	observe := func(accounts []rpc.VoteAccount, isDelinquent bool) {
+		for _, account := range accounts {
+			count := c.transitions[account.VotePubkey]
+			if was, seen := c.wasDelinquent[account.VotePubkey]; seen && was != isDelinquent {
+				count++
+			}
+			transitions[account.VotePubkey] = count
+			wasDelinquent[account.VotePubkey] = isDelinquent
+		}
+	}
+	observe(current, false)
+	observe(delinquent, true)
+
+	c.transitions, c.wasDelinquent = transitions, wasDelinquent
+
+	return transitions
+}
+
+// updateDelinquentStreaks extends the streak of every delinquent validator by one scrape. Validators
+// missing from delinquent start over the next time they become delinquent.
+func (c *solanaCollector) updateDelinquentStreaks(delinquent []rpc.VoteAccount) map[string]int {
+	c.delinquentMu.Lock()
+	defer c.delinquentMu.Unlock()
+
+	streaks := make(map[string]int, len(delinquent))
+	for _, account := range delinquent {
+		streaks[account.VotePubkey] = c.delinquentStreaks[account.VotePubkey] + 1
+	}
+	c.delinquentStreaks = streaks
+
+	return streaks
+}
+
+// commissionValue converts a commission in percent into the unit given with -commission-unit.
+func commissionValue(percent int) float64 {
+	if *commissionUnit == commissionUnitBasisPoints {
+		return float64(percent) * 100
+	}
+
+	return float64(percent)
+}
+
+// commissionTiers lists the tiers of solana_stake_by_commission_tier in ascending order.
+var commissionTiers = []string{"0", "1-5", "6-10", ">10"}
+
+func commissionTier(commission int) string {
+	switch {
+	case commission <= 0:
+		return "0"
+	case commission <= 5:
+		return "1-5"
+	case commission <= 10:
+		return "6-10"
+	default:
+		return ">10"
+	}
+}
+
+func (c *solanaCollector) emitStakeByCommissionTier(ch chan<- prometheus.Metric, accounts []rpc.VoteAccount) {
+	stake := make(map[string]int64, len(commissionTiers))
+	for _, account := range accounts {
+		stake[commissionTier(account.Commission)] += int64(account.ActivatedStake)
+	}
+
+	for _, tier := range commissionTiers {
+		ch <- prometheus.MustNewConstMetric(c.stakeByCommissionTier, prometheus.GaugeValue, float64(stake[tier]), tier)
+	}
+}
+
+// collectIdentityBalance emits the balance of the identity account given with -identity.
+func (c *solanaCollector) collectIdentityBalance(ctx context.Context, ch chan<- prometheus.Metric, identity string) {
+	balance, err := c.rpcClient.GetBalance(ctx, []interface{}{identity, c.commitment})
+	if err != nil {
+		klog.Errorf("failed to get identity balance: %v", err)
+		ch <- prometheus.NewInvalidMetric(c.nodeIdentityBalance, err)
+		return
+	}
+
+	ch <- prometheus.MustNewConstMetric(c.nodeIdentityBalance, prometheus.GaugeValue,
+		float64(balance.Result.Value), identity)
+}
+
+// collectAllBalances fetches the identity and vote account balances of all given validators using
+// getMultipleAccounts, with a share of the budget per call.
+func (c *solanaCollector) collectAllBalances(budget *callBudget, ch chan<- prometheus.Metric, accounts []rpc.VoteAccount) {
+	pubkeys := make([]string, 0, 2*len(accounts))
+	for _, account := range accounts {
+		pubkeys = append(pubkeys, account.NodePubkey, account.VotePubkey)
+	}
+
+	balances := make([]*rpc.Account, 0, len(pubkeys))
+	for start := 0; start < len(pubkeys); start += rpc.MaxMultipleAccounts {
+		end := start + rpc.MaxMultipleAccounts
+		if end > len(pubkeys) {
+			end = len(pubkeys)
+		}
+
+		chunk, err := c.rpcClient.GetMultipleAccounts(budget.next(), pubkeys[start:end], c.commitment)
+		if err != nil {
+			klog.Errorf("failed to get validator balances: %v", err)
+			ch <- prometheus.NewInvalidMetric(c.validatorAccountBalance, err)
+			return
+		}
+		balances = append(balances, chunk...)
+	}
+
+	for i, account := range accounts {
+		if node := balances[2*i]; node != nil {
+			ch <- prometheus.MustNewConstMetric(c.validatorAccountBalance, prometheus.GaugeValue,
+				float64(node.Lamports), account.VotePubkey, account.NodePubkey, "validator")
+		}
+		if vote := balances[2*i+1]; vote != nil {
+			ch <- prometheus.MustNewConstMetric(c.validatorAccountBalance, prometheus.GaugeValue,
+				float64(vote.Lamports), account.VotePubkey, account.NodePubkey, "vote")
+		}
+	}
+}
 
-	info, err := c.rpcClient.GetEpochInfo(ctx, rpc.CommitmentRecent)
+// epochLabelValues returns the label values of the epoch info metrics fetched at commitment. The commitment
+// label is only there with -extra-epoch-commitment.
+func (c *solanaCollector) epochLabelValues(commitment rpc.Commitment, labels ...string) []string {
+	if c.extraCommitment != "" {
+		labels = append(labels, string(commitment))
+	}
+
+	return labels
+}
+
+// collectEpochInfo fetches epoch info at commitment and emits the metrics derived from it.
+func (c *solanaCollector) collectEpochInfo(ctx context.Context, ch chan<- prometheus.Metric,
+	commitment rpc.Commitment) (*rpc.EpochInfo, error) {
+	info, err := c.rpcClient.GetEpochInfo(ctx, commitment)
 	if err != nil {
-		klog.Infof("failed to fetch epoch info, err: %v", err)
+		klog.Infof("failed to fetch epoch info at %s commitment, err: %v", commitment, err)
 		ch <- prometheus.NewInvalidMetric(c.currentEpoch, err)
+		return nil, err
+	}
+
+	ch <- prometheus.MustNewConstMetric(c.currentEpoch, prometheus.GaugeValue, float64(info.Epoch),
+		c.epochLabelValues(commitment, "epoch")...)
+
+	// Slots and block heights both count from genesis, so their difference is the number of slots that
+	// didn't produce a block.
+	if skipped := info.AbsoluteSlot - info.BlockHeight; skipped >= 0 {
+		ch <- prometheus.MustNewConstMetric(c.skippedSlotsEstimate, prometheus.GaugeValue, float64(skipped),
+			c.epochLabelValues(commitment)...)
+	}
+
+	c.collectEpochProgress(ch, info, commitment)
+
+	return info, nil
+}
+
+func (c *solanaCollector) Collect(ch chan<- prometheus.Metric) {
+	if *backgroundPolling {
+		c.collectPolled(ch)
+		return
+	}
+
+	c.collectNow(ch)
+}
+
+// collectNow collects the node metrics from the RPC node.
+func (c *solanaCollector) collectNow(ch chan<- prometheus.Metric) {
+	start := time.Now()
+	defer func() { atomic.StoreUint64(&c.lastCollectNanos, uint64(time.Since(start))) }()
+
+	if *maxSeries > 0 {
+		c.collectCapped(ch, *maxSeries)
 	} else {
-		ch <- prometheus.MustNewConstMetric(c.currentEpoch, prometheus.GaugeValue, float64(info.Epoch), "epoch")
+		c.collect(ch)
+	}
+}
+
+func (c *solanaCollector) collect(ch chan<- prometheus.Metric) {
+	cfg := loadRuntimeConfig()
+
+	var calls uint64
+	defer func() { atomic.StoreUint64(&c.lastScrapeCalls, atomic.LoadUint64(&calls)) }()
+
+	ctx, cancel := context.WithTimeout(rpc.WithCallCounter(context.Background(), &calls), *collectTimeout)
+	defer cancel()
+	budget := newCallBudget(ctx, c.plannedCalls(cfg))
+	defer budget.release()
+
+	var summary scrapeSummary
+	defer summary.log(time.Now())
+
+	info, err := c.collectEpochInfo(budget.next(), ch, c.commitment)
+	summary.epoch = info
+	if c.extraCommitment != "" {
+		c.collectEpochInfo(budget.next(), ch, c.extraCommitment)
 	}
 
-	version, err := c.rpcClient.GetVersion(ctx)
+	version, err := c.rpcClient.GetVersion(budget.next())
 
 	if err != nil {
 		ch <- prometheus.NewInvalidMetric(c.solanaVersion, err)
@@ -178,29 +1119,95 @@ func (c *solanaCollector) Collect(ch chan<- prometheus.Metric) {
 		ch <- prometheus.MustNewConstMetric(c.solanaVersion, prometheus.GaugeValue, 1, *version)
 	}
 
-	identity, err := c.rpcClient.GetIdentity(ctx)
-	health, err := c.rpcClient.GetHealth(ctx)
+	confirmed, finalized, confirmedErr := c.collectFinalizationGap(budget, ch)
+	if confirmedErr == nil {
+		c.collectClockSkew(budget.next(), ch, confirmed)
+		c.collectSlotStuck(ch, confirmed)
+		if *recentForks {
+			c.collectRecentForks(budget, ch, finalized)
+		}
+	}
+
+	samples := c.collectPerformance(budget.next(), ch)
+	c.collectTransactionCount(budget.next(), ch)
+	if info != nil {
+		c.collectEpochTimeRemaining(ch, info, samples)
+	}
+
+	supply, err := c.rpcClient.GetSupply(budget.next(), c.commitment)
+	if err != nil {
+		ch <- prometheus.NewInvalidMetric(c.nonCirculatingAccounts, err)
+	} else {
+		ch <- prometheus.MustNewConstMetric(c.nonCirculatingAccounts, prometheus.GaugeValue,
+			float64(len(supply.NonCirculatingAccounts)))
+	}
 
-	var healthVar float64
-	if health {
-		healthVar = 1
+	identity, err := c.rpcClient.GetIdentity(budget.next())
+	pingStart := time.Now()
+	health, err := c.rpcClient.GetHealthStatus(budget.next())
+	// getHealth does next to no work on the node, so its round trip is mostly network latency.
+	if err == nil {
+		ch <- prometheus.MustNewConstMetric(c.rpcPing, prometheus.GaugeValue, time.Since(pingStart).Seconds())
 	}
 
 	if err != nil {
 		ch <- prometheus.NewInvalidMetric(c.nodeHealth, err)
+		ch <- prometheus.NewInvalidMetric(c.nodeSlotsBehind, err)
 	} else {
+		var healthVar float64
+		if health.Healthy {
+			healthVar = 1
+		}
 		ch <- prometheus.MustNewConstMetric(c.nodeHealth, prometheus.GaugeValue, healthVar, identity)
+
+		// An unhealthy node doesn't always say how far behind it is, which is left out rather than reported as 0.
+		if health.Healthy {
+			ch <- prometheus.MustNewConstMetric(c.nodeSlotsBehind, prometheus.GaugeValue, 0, identity)
+		} else if health.NumSlotsBehind != nil {
+			ch <- prometheus.MustNewConstMetric(c.nodeSlotsBehind, prometheus.GaugeValue,
+				float64(*health.NumSlotsBehind), identity)
+		}
 	}
 
-	if *noVoting == true {
+	c.observeHealth(identity, err == nil && health.Healthy, time.Now())
+
+	if c.referenceClient != nil {
+		c.collectReferenceSlotLag(budget.next(), ch, confirmed, confirmedErr, health)
+	}
+
+	if *identityPubkey != "" {
+		c.collectIdentityBalance(budget.next(), ch, *identityPubkey)
+	}
+
+	// Cluster nodes are fetched once per scrape and shared by everything that needs them.
+	var nodes []rpc.ClusterNode
+	if identity != "" || *validatorVersions {
+		nodes, err = c.rpcClient.GetClusterNodes(budget.nextHeavy())
+		if err != nil {
+			klog.Errorf("failed to get cluster nodes: %v", err)
+		}
+	}
+
+	if identity != "" {
+		c.collectClusterNode(ch, nodes, err, identity, version)
+	}
+
+	if accounts := splitList(*tokenAccounts); len(accounts) > 0 {
+		c.collectTokenAccounts(budget, ch, accounts)
+	}
+
+	allVoteAccounts := &voteAccountSet{c: c, budget: budget}
+
+	if cfg.noVoting {
 		klog.Info("set -no-voting, skip vote account metrics!")
 	} else {
-		params := map[string]string{"commitment": string(rpc.CommitmentRecent)}
-		if *votePubkey != "" {
-			params = map[string]string{"commitment": string(rpc.CommitmentRecent), "votePubkey": *votePubkey}
+		var accs, all *rpc.GetVoteAccountsResponse
+		if len(cfg.watched) > 0 {
+			accs, all, err = c.rpcClient.GetVoteAccountsFor(budget.nextHeavy(), c.commitment, cfg.watched)
+		} else {
+			accs, err = c.rpcClient.GetAllVoteAccounts(budget.nextHeavy(), c.commitment)
+			all = accs
 		}
-
-		accs, err := c.rpcClient.GetVoteAccounts(ctx, []interface{}{params})
 		if err != nil {
 			ch <- prometheus.NewInvalidMetric(c.totalValidatorsDesc, err)
 			ch <- prometheus.NewInvalidMetric(c.validatorActivatedStake, err)
@@ -211,83 +1218,369 @@ func (c *solanaCollector) Collect(ch chan<- prometheus.Metric) {
 			ch <- prometheus.NewInvalidMetric(c.validatorPctVote, err)
 			ch <- prometheus.NewInvalidMetric(c.validatorTotalCredits, err)
 		} else {
-			c.mustEmitMetrics(ch, accs, info)
-		}
+			summary.accounts = accs
+			atomic.StoreUint64(&c.lastVoteAccounts, uint64(len(accs.Result.Current)+len(accs.Result.Delinquent)))
+			// The watched accounts may have been picked out of the whole set, which the rankings reuse.
+			if all != nil {
+				allVoteAccounts.resp, allVoteAccounts.fetched = all, true
+			}
+
+			var versions map[string]string
+			if *validatorVersions {
+				versions = clusterNodeVersions(nodes)
+			}
 
-		if *votePubkey != "" {
-			for _, account := range append(accs.Result.Current, accs.Result.Delinquent...) {
-				params = map[string]string{"identity": account.NodePubkey}
+			c.mustEmitMetrics(ch, cfg, accs, info, versions)
+			c.emitAbsentValidators(ch, cfg, accs, versions)
+
+			if cfg.balanceAll {
+				c.collectAllBalances(budget, ch, append(accs.Result.Current, accs.Result.Delinquent...))
+			}
+
+			if len(cfg.watched) > 0 {
+				c.collectStakeRank(ch, accs.Result.Current, allVoteAccounts)
+				c.collectCreditsRank(ch, accs.Result.Current, allVoteAccounts, info)
+				if *computeProjectedRewards {
+					c.collectProjectedRewards(budget.next(), ch, accs.Result.Current, allVoteAccounts, info, supply)
+				}
+			} else {
+				all := append(accs.Result.Current, accs.Result.Delinquent...)
+				c.emitStakeByCommissionTier(ch, all)
+				c.emitStakeShare(ch, all, totalActivatedStake(all))
 			}
+
+			c.collectDelinquentStake(ch, allVoteAccounts)
+		}
+
+		// Vote accounts are missing if getVoteAccounts failed.
+		var found []rpc.VoteAccount
+		if accs != nil {
+			found = append(accs.Result.Current, accs.Result.Delinquent...)
 		}
 
-		blockproduction, err := c.rpcClient.GetBlockProduction(ctx, []interface{}{params})
+		// getBlockProduction only filters by a single identity, so several watched validators take the
+		// whole cluster's block production.
+		blockProductionParams := map[string]string{"commitment": string(c.commitment)}
+		if len(found) == 1 {
+			blockProductionParams["identity"] = found[0].NodePubkey
+		}
+
+		blockproduction, err := c.rpcClient.GetBlockProduction(budget.nextHeavy(), []interface{}{blockProductionParams})
 
 		if err != nil {
 			ch <- prometheus.NewInvalidMetric(c.totalLeaderSlots, err)
 			ch <- prometheus.NewInvalidMetric(c.totalProducedSlots, err)
+			ch <- prometheus.NewInvalidMetric(c.leaderSlotsCounter, err)
+			ch <- prometheus.NewInvalidMetric(c.producedSlotsCounter, err)
+			ch <- prometheus.NewInvalidMetric(c.clusterLeaderSlots, err)
+			ch <- prometheus.NewInvalidMetric(c.clusterProducedSlots, err)
+			ch <- prometheus.NewInvalidMetric(c.blockProductionRange, err)
 		} else {
-			for _, account := range append(accs.Result.Current, accs.Result.Delinquent...) {
+			ch <- prometheus.MustNewConstMetric(c.blockProductionRange, prometheus.GaugeValue,
+				float64(blockproduction.Result.RangeSlots()))
+
+			// Block production is filtered by identity with -votepubkey, so totals only make sense without it.
+			if len(cfg.watched) == 0 {
+				leaderSlots, producedSlots := blockproduction.Result.Value.ByIdentity.Totals()
+				ch <- prometheus.MustNewConstMetric(c.clusterLeaderSlots, prometheus.GaugeValue, float64(leaderSlots))
+				ch <- prometheus.MustNewConstMetric(c.clusterProducedSlots, prometheus.GaugeValue, float64(producedSlots))
+			}
+
+			for _, account := range found {
 				val, exist := blockproduction.Result.Value.ByIdentity[account.NodePubkey]
 				if exist {
 					ch <- prometheus.MustNewConstMetric(c.totalLeaderSlots, prometheus.GaugeValue,
 						float64(val[0]), account.VotePubkey, account.NodePubkey)
 					ch <- prometheus.MustNewConstMetric(c.totalProducedSlots, prometheus.GaugeValue,
 						float64(val[1]), account.VotePubkey, account.NodePubkey)
+					ch <- prometheus.MustNewConstMetric(c.leaderSlotsCounter, prometheus.CounterValue,
+						float64(val[0]), account.VotePubkey, account.NodePubkey)
+					ch <- prometheus.MustNewConstMetric(c.producedSlotsCounter, prometheus.CounterValue,
+						float64(val[1]), account.VotePubkey, account.NodePubkey)
+					// The epoch label keeps the rates of consecutive epochs apart at the rollover.
+					if rate, ok := skipRate(val[0], val[1]); ok && info != nil {
+						ch <- prometheus.MustNewConstMetric(c.validatorSkipRate, prometheus.GaugeValue, rate,
+							account.VotePubkey, account.NodePubkey, strconv.FormatInt(info.Epoch, 10))
+					}
 				}
 			}
 		}
 
-		// execute getBalance when the vote account provided by -votepubkey option
-		// we don't need to get balance for all validators accounts
-		if *votePubkey != "" {
-			var account rpc.VoteAccount
-			if len(accs.Result.Current) == 1 {
-				account = accs.Result.Current[0]
-			} else if len(accs.Result.Delinquent) == 1 {
-				account = accs.Result.Delinquent[0]
-			} else {
-				klog.Errorf("Failed to get voteAccount: %s", *votePubkey)
+		if len(cfg.watched) > 0 {
+			// The skip rates are compared against the whole cluster, which takes another call only if block
+			// production was filtered by identity.
+			clusterProduction, clusterErr := blockproduction, err
+			if _, filtered := blockProductionParams["identity"]; filtered {
+				clusterProduction, clusterErr = c.rpcClient.GetBlockProduction(budget.nextHeavy(),
+					[]interface{}{map[string]string{"commitment": string(c.commitment)}})
 			}
+			c.collectSkipRateVsCluster(ch, found, clusterProduction, clusterErr)
+		}
 
-			nodebalance, err := c.rpcClient.GetBalance(ctx, []interface{}{account.NodePubkey})
-			if err != nil {
-				ch <- prometheus.NewInvalidMetric(c.validatorBalance, err)
-			} else {
-				ch <- prometheus.MustNewConstMetric(c.validatorBalance, prometheus.GaugeValue,
-					float64(nodebalance.Result.Value), "validator")
+		// execute getBalance for the vote accounts provided by -votepubkey option
+		// we don't need to get balance for all validators accounts
+		if len(cfg.watched) > 0 {
+			if len(found) < len(cfg.watched) {
+				klog.Errorf("Failed to get voteAccount: %s", strings.Join(missingVotePubkeys(cfg.watched, found), ","))
 			}
 
-			votebalance, err := c.rpcClient.GetBalance(ctx, []interface{}{account.VotePubkey})
-			if err != nil {
-				ch <- prometheus.NewInvalidMetric(c.validatorBalance, err)
-			} else {
-				ch <- prometheus.MustNewConstMetric(c.validatorBalance, prometheus.GaugeValue,
-					float64(votebalance.Result.Value), "vote")
+			for _, account := range found {
+				nodebalance, err := c.rpcClient.GetBalance(budget.next(), []interface{}{account.NodePubkey, c.commitment})
+				if err != nil {
+					ch <- prometheus.NewInvalidMetric(c.validatorBalance, err)
+				} else {
+					ch <- prometheus.MustNewConstMetric(c.validatorBalance, prometheus.GaugeValue,
+						float64(nodebalance.Result.Value), "validator", account.VotePubkey)
+				}
+
+				votebalance, err := c.rpcClient.GetBalance(budget.next(), []interface{}{account.VotePubkey, c.commitment})
+				if err != nil {
+					ch <- prometheus.NewInvalidMetric(c.validatorBalance, err)
+				} else {
+					ch <- prometheus.MustNewConstMetric(c.validatorBalance, prometheus.GaugeValue,
+						float64(votebalance.Result.Value), "vote", account.VotePubkey)
+				}
+
+				c.collectAuthorityChanges(budget.next(), ch, account.VotePubkey)
 			}
+			c.pruneAuthorities(cfg)
+
+			c.collectInflationRewards(budget.next(), ch, info, cfg.watched)
+			c.collectLeaderRewards(budget.nextHeavy(), ch, info, found)
 		}
 	}
+
+	if identity != "" {
+		c.collectIsValidator(ch, identity, allVoteAccounts)
+	}
 }
 
 func main() {
 	flag.Parse()
 
-	if *rpcAddr == "" {
-		klog.Fatal("Please specify -rpcURI")
+	flag.Visit(func(f *flag.Flag) {
+		explicitFlags[f.Name] = true
+	})
+
+	if *configFile != "" {
+		values, err := loadConfigFile(*configFile)
+		if err != nil {
+			klog.Fatalf("Failed to load -config: %v", err)
+		}
+		if err := applyConfig(values, false); err != nil {
+			klog.Fatalf("Failed to load -config: %v", err)
+		}
+	}
+
+	if *network != "" {
+		networkAddr, ok := networkRPCAddrs[*network]
+		if !ok {
+			klog.Fatalf("Invalid -network %q, must be mainnet, testnet or devnet", *network)
+		}
+		if *rpcAddr == "" {
+			*rpcAddr = networkAddr
+		}
+	}
+
+	if len(splitList(*rpcAddr)) == 0 {
+		klog.Fatal("Please specify -rpcURI or -network")
 	}
 
 	if *noVoting == true {
 		klog.Info("set -no-voting, This node is not a validator!")
 	}
 
-	collector := NewSolanaCollector(*rpcAddr)
+	if err := validateReloadableFlags(); err != nil {
+		klog.Fatal(err)
+	}
+
+	if *rpcMaxBodyBytes <= 0 {
+		klog.Fatal("-rpc-max-body-bytes must be positive")
+	}
+
+	if *perfSamplesLimit < 1 || *perfSamplesLimit > rpc.MaxPerformanceSamples {
+		klog.Fatalf("-perf-samples-limit must be between 1 and %d", rpc.MaxPerformanceSamples)
+	}
+
+	if *slotStuckScrapes < 0 {
+		klog.Fatal("-slot-stuck-scrapes must not be negative")
+	}
+
+	if *collectTimeout <= 0 {
+		klog.Fatal("-collect-timeout must be positive")
+	}
+	if *httpWriteTimeout != 0 && *httpWriteTimeout <= *collectTimeout {
+		klog.Fatal("-http-write-timeout must exceed -collect-timeout")
+	}
+	web, err := loadWebConfig()
+	if err != nil {
+		klog.Fatalf("Invalid TLS or basic auth settings: %v", err)
+	}
+
+	if *rpcTokenFile != "" {
+		if _, err := rpc.NewTokenFile(*rpcTokenFile, tokenRefresh).Token(); err != nil {
+			klog.Fatalf("Invalid -rpc-token-file: %v", err)
+		}
+	}
+	headers, err := rpcHeaders()
+	if err != nil {
+		klog.Fatalf("Invalid RPC headers: %v", err)
+	}
+
+	if *commissionUnit != commissionUnitPercent && *commissionUnit != commissionUnitBasisPoints {
+		klog.Fatalf("Invalid -commission-unit %q, must be %s or %s", *commissionUnit,
+			commissionUnitPercent, commissionUnitBasisPoints)
+	}
+
+	if *identitiesFile != "" {
+		pubkeys, err := loadIdentitiesFile(*identitiesFile)
+		if err != nil {
+			klog.Fatalf("Invalid -identities-file: %v", err)
+		}
+		filePubkeys = pubkeys
+		go reloadIdentitiesOnSIGHUP(*identitiesFile)
+	}
+
+	level, err := rpc.ParseCommitment(*commitment)
+	if err != nil {
+		klog.Fatalf("Invalid -commitment: %v", err)
+	}
+
+	collector := NewSolanaCollector(*rpcAddr, level)
+
+	if *extraEpochCommitment != "" {
+		collector.extraCommitment, err = rpc.ParseCommitment(*extraEpochCommitment)
+		if err != nil {
+			klog.Fatalf("Invalid -extra-epoch-commitment: %v", err)
+		}
+		if collector.extraCommitment == level {
+			klog.Fatal("-extra-epoch-commitment must differ from -commitment")
+		}
+	}
 
-	if *votePubkey == "" {
-		go collector.WatchSlots()
+	// The reference is usually a public or another provider's node, so -rpc-header and the tokens aren't sent.
+	if *referenceRPCAddr != "" {
+		collector.referenceClient = rpc.NewRPCClient(*referenceRPCAddr,
+			rpc.WithMaxBodyBytes(*rpcMaxBodyBytes),
+			rpc.WithRetries(*rpcRetries),
+			rpc.WithMaxRetryAfter(*rpcMaxRetryAfter))
 	}
 
-	prometheus.MustRegister(collector)
-	http.Handle("/metrics", promhttp.Handler())
+	if err := collector.selfTest(); err != nil {
+		if *failOnStartupError {
+			klog.Fatal(err)
+		}
+		klog.Warning(err)
+	}
+
+	// Canceled on shutdown to stop watching slots, polling and following the WebSocket.
+	ctx, stop := context.WithCancel(context.Background())
+
+	if len(watchedVotePubkeys()) == 0 {
+		go collector.WatchSlots(ctx)
+	}
+
+	go collector.warmup(ctx)
+
+	// With -admin-addr, node metrics get a registry of their own and the default registry, which also holds
+	// the Go runtime, process and RPC client metrics, is served on the admin address.
+	var (
+		nodeRegisterer = prometheus.DefaultRegisterer
+		nodeGatherer   = prometheus.DefaultGatherer
+	)
+	if *adminAddr != "" {
+		registry := prometheus.NewRegistry()
+		nodeRegisterer, nodeGatherer = registry, registry
+	}
+
+	// With -shard-metrics, the per-validator metrics are split from all other node metrics, which are
+	// registered with both the node and the cluster registry from here on. /metrics and the shards are served
+	// from the same collection.
+	var validatorsRegistry, clusterRegistry *prometheus.Registry
+	if *shardMetrics {
+		shared := newSharedCollection(collector, *pollInterval)
+		nodeRegisterer.MustRegister(shared)
+
+		validatorsRegistry, clusterRegistry = prometheus.NewRegistry(), prometheus.NewRegistry()
+		validatorsRegistry.MustRegister(shardCollector{s: shared, validators: true})
+		clusterRegistry.MustRegister(shardCollector{s: shared, validators: false})
+		nodeRegisterer = registerers{nodeRegisterer, clusterRegistry}
+	} else {
+		nodeRegisterer.MustRegister(collector)
+	}
+
+	registerSlotMetrics(nodeRegisterer)
+
+	if *wsAddr != "" {
+		registerWSMetrics(nodeRegisterer)
+		go collector.WatchWebSocket(ctx, rpc.NewWSClient(*wsAddr, headers))
+	}
+
+	if programs := splitList(*programIDs); len(programs) > 0 {
+		nodeRegisterer.MustRegister(newProgramAccountsCollector(collector.rpcClient, level, programs))
+	}
+
+	if *delegatorCount {
+		nodeRegisterer.MustRegister(newDelegatorsCollector(collector.rpcClient, level))
+	}
+
+	if *stakeAccounts {
+		nodeRegisterer.MustRegister(newStakeAccountsCollector(collector.rpcClient, level))
+	}
+
+	if *adminSocket != "" {
+		nodeRegisterer.MustRegister(newValidatorAdminCollector(*adminSocket))
+	}
+
+	if *customMetricsFile != "" {
+		metrics, err := loadCustomMetrics(*customMetricsFile)
+		if err != nil {
+			klog.Fatalf("Invalid -custom-metrics: %v", err)
+		}
+		if err := nodeRegisterer.Register(&customCollector{rpcClient: collector.rpcClient, metrics: metrics}); err != nil {
+			klog.Fatalf("Invalid -custom-metrics: %v", err)
+		}
+	}
+	prometheus.MustRegister(selfCollector{collector})
+
+	// Pushing and writing to stdout don't tell node metrics from metrics about the exporter itself.
+	var allGatherer prometheus.Gatherer = prometheus.DefaultGatherer
+	if *adminAddr != "" {
+		allGatherer = prometheus.Gatherers{nodeGatherer, prometheus.DefaultGatherer}
+	}
+
+	if *pushgateway != "" {
+		grouping, err := parseGroupingLabels(*pushGrouping)
+		if err != nil {
+			klog.Fatalf("Invalid -pushgateway-grouping: %v", err)
+		}
+
+		klog.Infof("pushing metrics to %s every %v", *pushgateway, *pollInterval)
+		go pushMetrics(allGatherer, *pushgateway, *pushJob, grouping, *pollInterval)
+	}
+
+	if *stdoutInterval > 0 {
+		klog.Infof("writing metrics to stdout every %v", *stdoutInterval)
+		go writeMetricsLoop(os.Stdout, allGatherer, *stdoutInterval)
+	}
+
+	if *configFile != "" {
+		go reloadConfigOnSIGHUP(*configFile)
+	}
+
+	http.Handle("/metrics", promhttp.InstrumentMetricHandler(prometheus.DefaultRegisterer,
+		promhttp.HandlerFor(nodeGatherer, promhttp.HandlerOpts{EnableOpenMetrics: true})))
+	if *shardMetrics {
+		http.Handle("/metrics/validators", promhttp.HandlerFor(validatorsRegistry, promhttp.HandlerOpts{EnableOpenMetrics: true}))
+		http.Handle("/metrics/cluster", promhttp.HandlerFor(clusterRegistry, promhttp.HandlerOpts{EnableOpenMetrics: true}))
+	}
+	http.HandleFunc("/readyz", collector.readyzHandler)
+
+	servers := []*http.Server{newHTTPServer(*addr, nil, web)}
+	if *adminAddr != "" {
+		servers = append(servers, adminServer(*adminAddr, prometheus.DefaultGatherer, web))
+	}
 
 	klog.Infof("listening on %s", *addr)
-	klog.Fatal(http.ListenAndServe(*addr, nil))
+	serveUntilSignal(stop, servers...)
 }
@@ -0,0 +1,702 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/certusone/solana_exporter/pkg/rpc"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func voteAccountsResponse(current, delinquent []string) *rpc.GetVoteAccountsResponse {
+	var resp rpc.GetVoteAccountsResponse
+	for _, pubkey := range current {
+		resp.Result.Current = append(resp.Result.Current, rpc.VoteAccount{VotePubkey: pubkey})
+	}
+	for _, pubkey := range delinquent {
+		resp.Result.Delinquent = append(resp.Result.Delinquent, rpc.VoteAccount{VotePubkey: pubkey})
+	}
+	return &resp
+}
+
+func votePubkeys(accounts []rpc.VoteAccount) []string {
+	var pubkeys []string
+	for _, account := range accounts {
+		pubkeys = append(pubkeys, account.VotePubkey)
+	}
+	return pubkeys
+}
+
+func TestDedupVoteAccounts(t *testing.T) {
+	tests := []struct {
+		name                        string
+		current, delinquent         []string
+		wantCurrent, wantDelinquent []string
+		wantDropped                 uint64
+	}{
+		{
+			name:        "no duplicates",
+			current:     []string{"a", "b"},
+			delinquent:  []string{"c"},
+			wantCurrent: []string{"a", "b"}, wantDelinquent: []string{"c"},
+		},
+		{
+			name:        "duplicate within current keeps the first",
+			current:     []string{"a", "b", "a"},
+			wantCurrent: []string{"a", "b"},
+			wantDropped: 1,
+		},
+		{
+			name:        "duplicate across current and delinquent keeps the current one",
+			current:     []string{"a"},
+			delinquent:  []string{"b", "a", "b"},
+			wantCurrent: []string{"a"}, wantDelinquent: []string{"b"},
+			wantDropped: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &solanaCollector{}
+			resp := voteAccountsResponse(tt.current, tt.delinquent)
+			c.dedupVoteAccounts(resp)
+
+			if got := votePubkeys(resp.Result.Current); !reflect.DeepEqual(got, tt.wantCurrent) {
+				t.Errorf("current = %v, want %v", got, tt.wantCurrent)
+			}
+			if got := votePubkeys(resp.Result.Delinquent); !reflect.DeepEqual(got, tt.wantDelinquent) {
+				t.Errorf("delinquent = %v, want %v", got, tt.wantDelinquent)
+			}
+			if c.droppedDuplicates != tt.wantDropped {
+				t.Errorf("dropped %d duplicates, want %d", c.droppedDuplicates, tt.wantDropped)
+			}
+		})
+	}
+}
+
+func TestNonCirculatingAccountCount(t *testing.T) {
+	tests := []struct {
+		name     string
+		accounts []string
+		want     float64
+	}{
+		{name: "none", accounts: []string{}, want: 0},
+		{name: "several", accounts: []string{"acc1", "acc2", "acc3"}, want: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			node := newFakeNode(t)
+			node.set("getSupply", map[string]interface{}{
+				"context": map[string]interface{}{"slot": 990},
+				"value": map[string]interface{}{"total": 100, "circulating": 60, "nonCirculating": 40,
+					"nonCirculatingAccounts": tt.accounts},
+			})
+			registry := prometheus.NewRegistry()
+			registry.MustRegister(NewSolanaCollector(node.URL, rpc.CommitmentProcessed))
+			families, _ := registry.Gather()
+
+			if got := metricValue(families, "solana_non_circulating_account_count", nil); got != tt.want {
+				t.Errorf("solana_non_circulating_account_count = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidatorVersions(t *testing.T) {
+	tests := []struct {
+		enabled bool
+		want    map[string]string
+	}{
+		{enabled: false, want: map[string]string{"vote1": "", "vote2": ""}},
+		// node2 isn't in gossip.
+		{enabled: true, want: map[string]string{"vote1": "1.9.0", "vote2": "unknown"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprint(tt.enabled), func(t *testing.T) {
+			defer func(v bool) { *validatorVersions = v }(*validatorVersions)
+			*validatorVersions = tt.enabled
+
+			node := newFakeNode(t)
+			node.set("getClusterNodes", []map[string]interface{}{
+				{"pubkey": "node1", "version": "1.9.0", "shredVersion": 8},
+			})
+			registry := prometheus.NewRegistry()
+			registry.MustRegister(NewSolanaCollector(node.URL, rpc.CommitmentProcessed))
+			families, _ := registry.Gather()
+
+			got := make(map[string]string)
+			for _, family := range families {
+				if family.GetName() != "solana_validator_activated_stake" {
+					continue
+				}
+				for _, m := range family.GetMetric() {
+					var pubkey, version string
+					for _, pair := range m.GetLabel() {
+						switch pair.GetName() {
+						case "pubkey":
+							pubkey = pair.GetValue()
+						case "version":
+							version = pair.GetValue()
+						}
+					}
+					got[pubkey] = version
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("versions by vote account = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCreditEfficiency(t *testing.T) {
+	tests := []struct {
+		name           string
+		slotIndex      int
+		wantExpected   float64
+		wantEfficiency float64
+	}{
+		// vote1 earned 50 credits in the epoch so far.
+		{name: "mid epoch", slotIndex: 100, wantExpected: 100, wantEfficiency: 0.5},
+		{name: "first slot", slotIndex: 0, wantExpected: 0, wantEfficiency: -1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			node := newFakeNode(t)
+			node.set("getEpochInfo", map[string]interface{}{
+				"absoluteSlot": 1000, "blockHeight": 900, "epoch": 5, "slotIndex": tt.slotIndex,
+				"slotsInEpoch": 432000, "transactionCount": 7,
+			})
+			registry := prometheus.NewRegistry()
+			registry.MustRegister(NewSolanaCollector(node.URL, rpc.CommitmentProcessed))
+			families, _ := registry.Gather()
+
+			if got := metricValue(families, "solana_validator_expected_credits", nil); got != tt.wantExpected {
+				t.Errorf("solana_validator_expected_credits = %v, want %v", got, tt.wantExpected)
+			}
+			got := metricValue(families, "solana_validator_credit_efficiency", map[string]string{"pubkey": "vote1"})
+			if got != tt.wantEfficiency {
+				t.Errorf("solana_validator_credit_efficiency = %v, want %v", got, tt.wantEfficiency)
+			}
+		})
+	}
+}
+
+func TestValidatorOwned(t *testing.T) {
+	tests := []struct {
+		name       string
+		votePubkey string
+		want       map[string]float64
+	}{
+		{name: "nothing watched", want: map[string]float64{"vote1": -1, "vote2": -1}},
+		{name: "one watched", votePubkey: "vote1", want: map[string]float64{"vote1": 1, "vote2": -1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func(v string) { *votePubkey = v }(*votePubkey)
+			*votePubkey = tt.votePubkey
+
+			node := newFakeNode(t)
+			registry := prometheus.NewRegistry()
+			registry.MustRegister(NewSolanaCollector(node.URL, rpc.CommitmentProcessed))
+			families, _ := registry.Gather()
+
+			for pubkey, want := range tt.want {
+				got := metricValue(families, "solana_validator_owned", map[string]string{"pubkey": pubkey})
+				if got != want {
+					t.Errorf("solana_validator_owned{pubkey=%q} = %v, want %v", pubkey, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestWatchedValidators(t *testing.T) {
+	tests := []struct {
+		votePubkey string
+		want       float64
+	}{
+		{votePubkey: "", want: 0},
+		{votePubkey: "vote1", want: 1},
+		{votePubkey: "vote1, vote2,,", want: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.votePubkey, func(t *testing.T) {
+			defer func(v string) { *votePubkey = v }(*votePubkey)
+			*votePubkey = tt.votePubkey
+
+			node := newFakeNode(t)
+			c := NewSolanaCollector(node.URL, rpc.CommitmentProcessed)
+			registry := prometheus.NewRegistry()
+			registry.MustRegister(c, selfCollector{c})
+			families, _ := registry.Gather()
+
+			if got := metricValue(families, "solana_exporter_watched_validators", nil); got != tt.want {
+				t.Errorf("solana_exporter_watched_validators = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBlockProductionRange(t *testing.T) {
+	node := newFakeNode(t)
+	node.set("getBlockProduction", map[string]interface{}{
+		"context": map[string]interface{}{"slot": 990},
+		"value": map[string]interface{}{
+			"byIdentity": map[string][]int{"node1": {4, 3}},
+			"range":      map[string]int{"firstSlot": 500, "lastSlot": 990},
+		},
+	})
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(NewSolanaCollector(node.URL, rpc.CommitmentProcessed))
+	families, _ := registry.Gather()
+
+	if got := metricValue(families, "solana_block_production_range_slots", nil); got != 491 {
+		t.Errorf("solana_block_production_range_slots = %v, want 491", got)
+	}
+}
+
+func TestValidatorIdentityInfo(t *testing.T) {
+	defer func(v bool) { *validatorVersions = v }(*validatorVersions)
+	// The mapping keeps its two labels whatever else is added to the per-validator metrics.
+	*validatorVersions = true
+
+	node := newFakeNode(t)
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(NewSolanaCollector(node.URL, rpc.CommitmentProcessed))
+	families, _ := registry.Gather()
+
+	got := make(map[string]string)
+	for _, family := range families {
+		if family.GetName() != "solana_validator_identity_info" {
+			continue
+		}
+		for _, m := range family.GetMetric() {
+			labels := make(map[string]string)
+			for _, pair := range m.GetLabel() {
+				labels[pair.GetName()] = pair.GetValue()
+			}
+			if len(labels) != 2 || m.GetGauge().GetValue() != 1 {
+				t.Errorf("series %v = %v, want only pubkey and nodekey set to 1", labels, m.GetGauge().GetValue())
+			}
+			got[labels["pubkey"]] = labels["nodekey"]
+		}
+	}
+	// Delinquent validators are mapped too.
+	if want := map[string]string{"vote1": "node1", "vote2": "node2"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("identities = %v, want %v", got, want)
+	}
+}
+
+func TestSkippedSlotsEstimate(t *testing.T) {
+	tests := []struct {
+		name        string
+		blockHeight int
+		want        float64
+	}{
+		{name: "skipped slots", blockHeight: 900, want: 100},
+		{name: "none skipped", blockHeight: 1000, want: 0},
+		// A block height past the slot is inconsistent and not exported.
+		{name: "inconsistent", blockHeight: 1001, want: -1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			node := newFakeNode(t)
+			node.set("getEpochInfo", map[string]interface{}{
+				"absoluteSlot": 1000, "blockHeight": tt.blockHeight, "epoch": 5, "slotIndex": 100,
+				"slotsInEpoch": 432000, "transactionCount": 7,
+			})
+			registry := prometheus.NewRegistry()
+			registry.MustRegister(NewSolanaCollector(node.URL, rpc.CommitmentProcessed))
+			families, _ := registry.Gather()
+
+			if got := metricValue(families, "solana_node_skipped_slots_estimate", nil); got != tt.want {
+				t.Errorf("solana_node_skipped_slots_estimate = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSlotCountersResetAtEpochBoundary(t *testing.T) {
+	node := newFakeNode(t)
+	registry := prometheus.NewPedanticRegistry()
+	registry.MustRegister(NewSolanaCollector(node.URL, rpc.CommitmentProcessed))
+	// The counters are served along with solana_leader_slots_total of WatchSlots, whose name they can't share.
+	registerSlotMetrics(registry)
+
+	scrapes := []struct {
+		epoch      int
+		production []int
+	}{
+		{epoch: 5, production: []int{4, 3}},
+		{epoch: 5, production: []int{8, 7}},
+		// Block production only covers the current epoch, so a new one starts the counters over.
+		{epoch: 6, production: []int{1, 0}},
+	}
+
+	for i, scrape := range scrapes {
+		node.set("getEpochInfo", map[string]interface{}{
+			"absoluteSlot": 1000, "blockHeight": 900, "epoch": scrape.epoch, "slotIndex": 100,
+			"slotsInEpoch": 432000, "transactionCount": 7,
+		})
+		node.set("getBlockProduction", map[string]interface{}{
+			"context": map[string]interface{}{"slot": 990},
+			"value": map[string]interface{}{
+				"byIdentity": map[string][]int{"node1": scrape.production},
+				"range":      map[string]int{"firstSlot": 0, "lastSlot": 990},
+			},
+		})
+		families, _ := registry.Gather()
+
+		labels := map[string]string{"nodekey": "node1"}
+		if got := metricValue(families, "solana_assigned_leader_slots_total", labels); got != float64(scrape.production[0]) {
+			t.Errorf("scrape %d: solana_assigned_leader_slots_total = %v, want %v", i+1, got, scrape.production[0])
+		}
+		if got := metricValue(families, "solana_produced_slots_total", labels); got != float64(scrape.production[1]) {
+			t.Errorf("scrape %d: solana_produced_slots_total = %v, want %v", i+1, got, scrape.production[1])
+		}
+	}
+}
+
+func TestVoteMetricsWithoutEpochInfo(t *testing.T) {
+	node := newFakeNode(t)
+	// Unanswered methods fail with "method not found".
+	delete(node.results, "getEpochInfo")
+
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(NewSolanaCollector(node.URL, rpc.CommitmentProcessed))
+	families, _ := registry.Gather()
+
+	labels := map[string]string{"pubkey": "vote1"}
+	if got := metricValue(families, "solana_validator_epoch_credits", labels); got != 50 {
+		t.Errorf("solana_validator_epoch_credits = %v, want 50", got)
+	}
+	for _, name := range []string{"solana_validator_expected_credits", "solana_validator_voting_percentage",
+		"solana_validator_credit_efficiency"} {
+		if got := metricValue(families, name, nil); got != -1 {
+			t.Errorf("%s = %v without epoch info, want it left out", name, got)
+		}
+	}
+}
+
+func TestVoteAccountWithoutVotes(t *testing.T) {
+	defer func(v string) { *votePubkey = v }(*votePubkey)
+	*votePubkey = "fresh"
+
+	node := newFakeNode(t)
+	// A vote account created this epoch reports null slots and no epoch credits.
+	node.set("getVoteAccounts", map[string]interface{}{
+		"current": []map[string]interface{}{
+			{"votePubkey": "fresh", "nodePubkey": "node1", "activatedStake": 0, "commission": 5,
+				"epochVoteAccount": false, "lastVote": nil, "rootSlot": nil, "epochCredits": [][]int{}},
+		},
+		"delinquent": []interface{}{},
+	})
+
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(NewSolanaCollector(node.URL, rpc.CommitmentProcessed))
+	families, _ := registry.Gather()
+
+	labels := map[string]string{"pubkey": "fresh"}
+	for _, name := range []string{"solana_validator_last_vote", "solana_validator_root_slot"} {
+		if got := metricValue(families, name, labels); got != -1 {
+			t.Errorf("%s = %v for an account that hasn't voted, want it left out", name, got)
+		}
+	}
+	for _, name := range []string{"solana_validator_epoch_credits", "solana_validator_total_credits"} {
+		if got := metricValue(families, name, labels); got != 0 {
+			t.Errorf("%s = %v, want 0", name, got)
+		}
+	}
+}
+
+func TestDelinquencyTransitions(t *testing.T) {
+	node := newFakeNode(t)
+	account := map[string]interface{}{"votePubkey": "vote1", "nodePubkey": "node1", "activatedStake": 100,
+		"commission": 5, "epochVoteAccount": true, "lastVote": 100, "rootSlot": 90, "epochCredits": [][]int{}}
+	setDelinquent := func(delinquent bool) {
+		accounts := map[string]interface{}{"current": []interface{}{}, "delinquent": []interface{}{}}
+		if delinquent {
+			accounts["delinquent"] = []interface{}{account}
+		} else {
+			accounts["current"] = []interface{}{account}
+		}
+		node.set("getVoteAccounts", accounts)
+	}
+
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(NewSolanaCollector(node.URL, rpc.CommitmentProcessed))
+
+	labels := map[string]string{"pubkey": "vote1"}
+	for i, step := range []struct {
+		delinquent bool
+		want       float64
+	}{
+		{false, 0}, // first sighting doesn't count
+		{true, 1},
+		{true, 1},
+		{false, 2},
+		{false, 2},
+	} {
+		setDelinquent(step.delinquent)
+		families, _ := registry.Gather()
+		if got := metricValue(families, "solana_validator_delinquency_transitions_total", labels); got != step.want {
+			t.Errorf("scrape %d: transitions = %v, want %v", i, got, step.want)
+		}
+	}
+}
+
+func TestIdentityBalance(t *testing.T) {
+	defer func(v string) { *identityPubkey = v }(*identityPubkey)
+	defer func(v bool) { *noVoting = v }(*noVoting)
+	*identityPubkey = "node1"
+	*noVoting = true
+
+	node := newFakeNode(t)
+	var requested string
+	node.handle("getBalance", func(params json.RawMessage) interface{} {
+		requested = string(params)
+		return map[string]interface{}{"context": map[string]interface{}{"slot": 100}, "value": 2500000000}
+	})
+
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(NewSolanaCollector(node.URL, rpc.CommitmentConfirmed))
+	families, _ := registry.Gather()
+
+	if want := `["node1",{"commitment":"confirmed"}]`; requested != want {
+		t.Errorf("requested balance with %s, want %s", requested, want)
+	}
+	if got := metricValue(families, "solana_node_identity_balance", map[string]string{"nodekey": "node1"}); got != 2500000000 {
+		t.Errorf("solana_node_identity_balance = %v, want 2500000000", got)
+	}
+}
+
+func TestRPCPing(t *testing.T) {
+	node := newFakeNode(t)
+	node.setDelay("getHealth", 50*time.Millisecond)
+
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(NewSolanaCollector(node.URL, rpc.CommitmentProcessed))
+	families, _ := registry.Gather()
+
+	if got := metricValue(families, "solana_rpc_ping_seconds", nil); got < 0.05 || got > 1 {
+		t.Errorf("solana_rpc_ping_seconds = %v, want the getHealth round trip of about 0.05", got)
+	}
+
+	// Without an answer there is no round trip to report.
+	delete(node.results, "getHealth")
+	families, _ = registry.Gather()
+	if got := metricValue(families, "solana_rpc_ping_seconds", nil); got != -1 {
+		t.Errorf("solana_rpc_ping_seconds = %v after getHealth failed, want it left out", got)
+	}
+}
+
+func TestExtraEpochCommitment(t *testing.T) {
+	defer func(v string) { *extraEpochCommitment = v }(*extraEpochCommitment)
+	*extraEpochCommitment = "finalized"
+
+	node := newFakeNode(t)
+	node.handle("getEpochInfo", func(params json.RawMessage) interface{} {
+		if strings.Contains(string(params), `"finalized"`) {
+			return map[string]interface{}{"epoch": 4, "absoluteSlot": 960, "blockHeight": 900, "slotIndex": 60, "slotsInEpoch": 100}
+		}
+		return map[string]interface{}{"epoch": 5, "absoluteSlot": 1000, "blockHeight": 930, "slotIndex": 0, "slotsInEpoch": 100}
+	})
+
+	c := NewSolanaCollector(node.URL, rpc.CommitmentProcessed)
+	c.extraCommitment = rpc.CommitmentFinalized
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(c)
+	families, _ := registry.Gather()
+
+	for _, tt := range []struct {
+		name       string
+		commitment string
+		want       float64
+	}{
+		{"solana_current_epoch", "processed", 5},
+		{"solana_current_epoch", "finalized", 4},
+		{"solana_node_skipped_slots_estimate", "processed", 70},
+		{"solana_node_skipped_slots_estimate", "finalized", 60},
+	} {
+		if got := metricValue(families, tt.name, map[string]string{"commitment": tt.commitment}); got != tt.want {
+			t.Errorf("%s{commitment=%q} = %v, want %v", tt.name, tt.commitment, got, tt.want)
+		}
+	}
+}
+
+func TestNodeSlotsBehind(t *testing.T) {
+	node := newFakeNode(t)
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(NewSolanaCollector(node.URL, rpc.CommitmentProcessed))
+
+	labels := map[string]string{"nodekey": "node1"}
+	families, _ := registry.Gather()
+	if got := metricValue(families, "solana_node_slots_behind", labels); got != 0 {
+		t.Errorf("solana_node_slots_behind of a healthy node = %v, want 0", got)
+	}
+
+	// Old nodes answer "behind" without saying by how much, which isn't reported as 0.
+	node.set("getHealth", "behind")
+	families, _ = registry.Gather()
+	if got := metricValue(families, "solana_health_check", labels); got != 0 {
+		t.Errorf("solana_health_check = %v, want 0", got)
+	}
+	if got := metricValue(families, "solana_node_slots_behind", labels); got != -1 {
+		t.Errorf("solana_node_slots_behind = %v without a count, want it left out", got)
+	}
+}
+
+func TestMissingVotePubkeys(t *testing.T) {
+	found := []rpc.VoteAccount{{VotePubkey: "vote1"}, {VotePubkey: "vote3"}}
+	if got, want := missingVotePubkeys([]string{"vote1", "vote2", "vote3", "vote4"}, found), []string{"vote2", "vote4"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("missingVotePubkeys = %v, want %v", got, want)
+	}
+	if got := missingVotePubkeys([]string{"vote1"}, found); got != nil {
+		t.Errorf("missingVotePubkeys = %v, want none", got)
+	}
+}
+
+func TestSeveralWatchedValidators(t *testing.T) {
+	defer func(v string) { *votePubkey = v }(*votePubkey)
+	*votePubkey = "vote1,vote2,vote9"
+
+	node := newFakeNode(t)
+	balances := map[string]int{"node1": 11, "vote1": 12, "node2": 21, "vote2": 22}
+	node.handle("getBalance", func(params json.RawMessage) interface{} {
+		var pubkey []string
+		_ = json.Unmarshal(params, &pubkey)
+		return map[string]interface{}{"context": map[string]interface{}{"slot": 990}, "value": balances[pubkey[0]]}
+	})
+	node.set("getAccountInfo", voteAccountInfo("voter1", "withdrawer1"))
+
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(NewSolanaCollector(node.URL, rpc.CommitmentProcessed))
+	families, _ := registry.Gather()
+
+	for _, tt := range []struct {
+		account, pubkey string
+		want            float64
+	}{
+		{"validator", "vote1", 11},
+		{"vote", "vote1", 12},
+		{"validator", "vote2", 21},
+		{"vote", "vote2", 22},
+	} {
+		if got := metricValue(families, "solana_validator_balance",
+			map[string]string{"account": tt.account, "pubkey": tt.pubkey}); got != tt.want {
+			t.Errorf("solana_validator_balance{account=%q,pubkey=%q} = %v, want %v", tt.account, tt.pubkey, got, tt.want)
+		}
+	}
+
+	// Authorities are checked for every watched validator that was found.
+	if calls := node.callCount("getAccountInfo"); calls != 2 {
+		t.Errorf("getAccountInfo called %d times, want 2", calls)
+	}
+}
+
+func TestVoteDistance(t *testing.T) {
+	if got := voteDistance(1000, 995); got != 5 {
+		t.Errorf("voteDistance(1000, 995) = %d, want 5", got)
+	}
+	// A vote newer than the current slot, which was fetched before it.
+	if got := voteDistance(1000, 1002); got != 0 {
+		t.Errorf("voteDistance(1000, 1002) = %d, want 0", got)
+	}
+
+	node := newFakeNode(t)
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(NewSolanaCollector(node.URL, rpc.CommitmentProcessed))
+	families, _ := registry.Gather()
+
+	for pubkey, want := range map[string]float64{"vote1": 5, "vote2": 300} {
+		if got := metricValue(families, "solana_validator_vote_distance", map[string]string{"pubkey": pubkey}); got != want {
+			t.Errorf("solana_validator_vote_distance{pubkey=%q} = %v, want %v", pubkey, got, want)
+		}
+	}
+
+	// Without the current slot there is nothing to measure against.
+	delete(node.results, "getEpochInfo")
+	families, _ = registry.Gather()
+	if got := metricValue(families, "solana_validator_vote_distance", nil); got != -1 {
+		t.Errorf("solana_validator_vote_distance = %v without epoch info, want no metric", got)
+	}
+}
+
+// A duplicated vote account must not make the registry reject the whole scrape for repeated series.
+func TestCollectWithDuplicateVoteAccount(t *testing.T) {
+	node := newFakeNode(t)
+	account := map[string]interface{}{
+		"votePubkey": "vote1", "nodePubkey": "node1", "activatedStake": 5000, "commission": 5,
+		"epochVoteAccount": true, "lastVote": 995, "rootSlot": 960, "epochCredits": [][]int{{5, 150, 100}},
+	}
+	node.set("getVoteAccounts", map[string]interface{}{
+		"current":    []interface{}{account, account},
+		"delinquent": []interface{}{},
+	})
+
+	registry := prometheus.NewPedanticRegistry()
+	registry.MustRegister(NewSolanaCollector(node.URL, rpc.CommitmentProcessed))
+
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatalf("gather failed: %v", err)
+	}
+
+	if got := metricValue(families, "solana_vote_account_duplicates_total", nil); got != 1 {
+		t.Errorf("solana_vote_account_duplicates_total = %v, want 1", got)
+	}
+	if got := metricValue(families, "solana_active_validators", map[string]string{"state": "current"}); got != 1 {
+		t.Errorf("current validators = %v, want 1", got)
+	}
+}
+
+func TestBlockProductionParams(t *testing.T) {
+	tests := []struct {
+		name       string
+		votePubkey string
+		want       map[string]string
+	}{
+		{name: "all validators", want: map[string]string{"commitment": "processed"}},
+		{name: "one watched validator", votePubkey: "vote1",
+			want: map[string]string{"commitment": "processed", "identity": "node1"}},
+		// The vote pubkeys must not leak into getBlockProduction, which filters by a single identity only.
+		{name: "several watched validators", votePubkey: "vote1,vote2",
+			want: map[string]string{"commitment": "processed"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func(v string) { *votePubkey = v }(*votePubkey)
+			*votePubkey = tt.votePubkey
+
+			node := newFakeNode(t)
+			registry := prometheus.NewRegistry()
+			registry.MustRegister(NewSolanaCollector(node.URL, rpc.CommitmentProcessed))
+			_, _ = registry.Gather()
+
+			// The first call is the one for the watched validators; watching any adds one for the whole cluster.
+			calls := node.paramsOf("getBlockProduction")
+			if len(calls) == 0 {
+				t.Fatal("getBlockProduction wasn't called")
+			}
+			var params []map[string]string
+			if err := json.Unmarshal(calls[0], &params); err != nil {
+				t.Fatal(err)
+			}
+			if len(params) != 1 || !reflect.DeepEqual(params[0], tt.want) {
+				t.Errorf("getBlockProduction params = %s, want [%v]", calls[0], tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeNode is a JSON-RPC endpoint answering from canned results per method, for tests that run collections.
+// Methods without a result are answered with "method not found".
+type fakeNode struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	results  map[string]interface{}
+	handlers map[string]func(params json.RawMessage) interface{}
+	delays   map[string]time.Duration
+	down     bool
+	calls    map[string]int
+	params   map[string][]json.RawMessage
+}
+
+func newFakeNode(t *testing.T) *fakeNode {
+	n := &fakeNode{
+		results: map[string]interface{}{
+			"getEpochInfo": map[string]interface{}{
+				"absoluteSlot": 1000, "blockHeight": 900, "epoch": 5, "slotIndex": 100, "slotsInEpoch": 432000,
+				"transactionCount": 7,
+			},
+			"getVersion":   map[string]interface{}{"solana-core": "1.9.0", "feature-set": 1},
+			"getIdentity":  map[string]interface{}{"identity": "node1"},
+			"getHealth":    "ok",
+			"getSlot":      990,
+			"getBlockTime": time.Now().Unix(),
+			"getSupply": map[string]interface{}{
+				"context": map[string]interface{}{"slot": 990},
+				"value": map[string]interface{}{"total": 100, "circulating": 60, "nonCirculating": 40,
+					"nonCirculatingAccounts": []string{"acc1"}},
+			},
+			"getTransactionCount": 123456,
+			"getRecentPerformanceSamples": []map[string]interface{}{
+				{"slot": 990, "numTransactions": 120000, "numSlots": 150, "samplePeriodSecs": 60},
+			},
+			"getClusterNodes": []map[string]interface{}{
+				{"pubkey": "node1", "version": "1.9.0", "shredVersion": 8, "rpc": "127.0.0.1:8899"},
+				{"pubkey": "node2", "version": "1.9.0", "shredVersion": 8},
+			},
+			"getBlockProduction": map[string]interface{}{
+				"context": map[string]interface{}{"slot": 990},
+				"value": map[string]interface{}{
+					"byIdentity": map[string][]int{"node1": {4, 3}, "node2": {4, 4}},
+					"range":      map[string]int{"firstSlot": 0, "lastSlot": 990},
+				},
+			},
+			"getVoteAccounts": map[string]interface{}{
+				"current": []map[string]interface{}{
+					{"votePubkey": "vote1", "nodePubkey": "node1", "activatedStake": 5000, "commission": 5,
+						"epochVoteAccount": true, "lastVote": 995, "rootSlot": 960,
+						"epochCredits": [][]int{{5, 150, 100}}},
+				},
+				"delinquent": []map[string]interface{}{
+					{"votePubkey": "vote2", "nodePubkey": "node2", "activatedStake": 1000, "commission": 10,
+						"epochVoteAccount": true, "lastVote": 700, "rootSlot": 650,
+						"epochCredits": [][]int{{5, 80, 70}}},
+				},
+			},
+		},
+		handlers: make(map[string]func(params json.RawMessage) interface{}),
+		delays:   make(map[string]time.Duration),
+		calls:    make(map[string]int),
+		params:   make(map[string][]json.RawMessage),
+	}
+
+	n.Server = httptest.NewServer(http.HandlerFunc(n.serve))
+	t.Cleanup(n.Close)
+
+	return n
+}
+
+func (n *fakeNode) serve(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Method string          `json:"method"`
+		Params json.RawMessage `json:"params"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	n.mu.Lock()
+	n.calls[req.Method]++
+	n.params[req.Method] = append(n.params[req.Method], req.Params)
+	down, delay := n.down, n.delays[req.Method]
+	result, ok := n.results[req.Method]
+	if handler, found := n.handlers[req.Method]; found {
+		result, ok = handler(req.Params), true
+	}
+	n.mu.Unlock()
+
+	if down {
+		http.Error(w, "unavailable", http.StatusServiceUnavailable)
+		return
+	}
+
+	select {
+	case <-time.After(delay):
+	case <-r.Context().Done():
+		return
+	}
+
+	if ok && req.Method == "getVoteAccounts" {
+		result = filterVoteAccounts(result, req.Params)
+	}
+
+	resp := map[string]interface{}{"jsonrpc": "2.0", "id": 1}
+	if ok {
+		resp["result"] = result
+	} else {
+		resp["error"] = map[string]interface{}{"code": -32601, "message": "Method not found"}
+	}
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+// filterVoteAccounts applies the votePubkey filter of getVoteAccounts params to a canned result.
+func filterVoteAccounts(result interface{}, params json.RawMessage) interface{} {
+	var config []struct {
+		VotePubkey string `json:"votePubkey"`
+	}
+	if err := json.Unmarshal(params, &config); err != nil || len(config) == 0 || config[0].VotePubkey == "" {
+		return result
+	}
+
+	b, _ := json.Marshal(result)
+	var accounts map[string][]map[string]interface{}
+	if err := json.Unmarshal(b, &accounts); err != nil {
+		return result
+	}
+	for state, list := range accounts {
+		var kept []map[string]interface{}
+		for _, account := range list {
+			if account["votePubkey"] == config[0].VotePubkey {
+				kept = append(kept, account)
+			}
+		}
+		accounts[state] = kept
+	}
+
+	return accounts
+}
+
+func (n *fakeNode) set(method string, result interface{}) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.results[method] = result
+}
+
+// handle answers method with the result of handler, which gets the request params.
+func (n *fakeNode) handle(method string, handler func(params json.RawMessage) interface{}) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.handlers[method] = handler
+}
+
+func (n *fakeNode) setDown(down bool) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.down = down
+}
+
+func (n *fakeNode) setDelay(method string, d time.Duration) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.delays[method] = d
+}
+
+func (n *fakeNode) callCount(method string) int {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.calls[method]
+}
+
+func (n *fakeNode) paramsOf(method string) []json.RawMessage {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return append([]json.RawMessage(nil), n.params[method]...)
+}
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"github.com/certusone/solana_exporter/pkg/rpc"
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/klog/v2"
+)
+
+// collectFinalizationGap emits how far the confirmed slot is ahead of the finalized one. The gap stays at a
+// few dozen slots on a healthy cluster and grows when roots stop advancing. It returns the confirmed slot and
+// the finalized one, which is zero if it couldn't be fetched.
+func (c *solanaCollector) collectFinalizationGap(budget *callBudget, ch chan<- prometheus.Metric) (int64, int64, error) {
+	confirmed, err := c.rpcClient.GetSlot(budget.next(), rpc.CommitmentConfirmed)
+	if err != nil {
+		klog.Errorf("failed to get confirmed slot: %v", err)
+		ch <- prometheus.NewInvalidMetric(c.finalizationGap, err)
+		return 0, 0, err
+	}
+
+	finalized, err := c.rpcClient.GetSlot(budget.next(), rpc.CommitmentFinalized)
+	if err != nil {
+		klog.Errorf("failed to get finalized slot: %v", err)
+		ch <- prometheus.NewInvalidMetric(c.finalizationGap, err)
+		return confirmed, 0, nil
+	}
+
+	ch <- prometheus.MustNewConstMetric(c.finalizationGap, prometheus.GaugeValue, float64(confirmed-finalized))
+
+	return confirmed, finalized, nil
+}
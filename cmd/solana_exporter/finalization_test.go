@@ -0,0 +1,28 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/certusone/solana_exporter/pkg/rpc"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestFinalizationGap(t *testing.T) {
+	node := newFakeNode(t)
+	node.handle("getSlot", func(params json.RawMessage) interface{} {
+		if strings.Contains(string(params), string(rpc.CommitmentFinalized)) {
+			return 968
+		}
+		return 1000
+	})
+
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(NewSolanaCollector(node.URL, rpc.CommitmentProcessed))
+	families, _ := registry.Gather()
+
+	if got := metricValue(families, "solana_confirmation_finalization_gap_slots", nil); got != 32 {
+		t.Errorf("solana_confirmation_finalization_gap_slots = %v, want 32", got)
+	}
+}
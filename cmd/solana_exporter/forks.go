@@ -0,0 +1,86 @@
+package main
+
+import (
+	"flag"
+
+	"github.com/certusone/solana_exporter/pkg/rpc"
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/klog/v2"
+)
+
+var recentForks = flag.Bool("recent-forks", false,
+	"Approximate the number of recent forks from processed slots of the node that never got finalized")
+
+// Number of resolved slot samples solana_recent_forks is counted over.
+const forkSamples = 100
+
+// resolveForkSamples splits the pending processed slot samples into those still above finalized and, for the
+// others, whether they were abandoned, i.e. are missing from the finalized blocks.
+func resolveForkSamples(pending []int64, finalized int64, blocks []int64) ([]int64, []bool) {
+	inChain := make(map[int64]bool, len(blocks))
+	for _, slot := range blocks {
+		inChain[slot] = true
+	}
+
+	var stillPending []int64
+	var abandoned []bool
+	for _, slot := range pending {
+		if slot > finalized {
+			stillPending = append(stillPending, slot)
+			continue
+		}
+		abandoned = append(abandoned, !inChain[slot])
+	}
+
+	return stillPending, abandoned
+}
+
+// collectRecentForks samples the node's processed slot and, once the earlier samples fall behind the finalized
+// slot, checks whether they made it into the finalized chain. There is no RPC method listing forks, so a
+// processed slot that was never finalized is taken as a sign that the node was building on a fork the cluster
+// abandoned. Only one slot is sampled per scrape, which makes this an undercount and a stability signal rather
+// than an exact number.
+func (c *solanaCollector) collectRecentForks(budget *callBudget, ch chan<- prometheus.Metric, finalized int64) {
+	processed, err := c.rpcClient.GetSlot(budget.next(), rpc.CommitmentProcessed)
+	if err != nil {
+		klog.Errorf("failed to get processed slot: %v", err)
+		ch <- prometheus.NewInvalidMetric(c.recentForks, err)
+		return
+	}
+
+	c.forksMu.Lock()
+	defer c.forksMu.Unlock()
+
+	if n := len(c.forkPending); n == 0 || processed > c.forkPending[n-1] {
+		c.forkPending = append(c.forkPending, processed)
+	}
+	// A node that stops finalizing would otherwise grow the pending samples without bound.
+	if n := len(c.forkPending); n > forkSamples {
+		c.forkPending = c.forkPending[n-forkSamples:]
+	}
+
+	if finalized != 0 && c.forkPending[0] <= finalized {
+		blocks, err := c.rpcClient.GetConfirmedBlocks(budget.next(), c.forkPending[0], finalized)
+		if err != nil {
+			klog.Errorf("failed to get finalized blocks: %v", err)
+			ch <- prometheus.NewInvalidMetric(c.recentForks, err)
+			return
+		}
+
+		var resolved []bool
+		c.forkPending, resolved = resolveForkSamples(c.forkPending, finalized, blocks)
+		c.forkOutcomes = append(c.forkOutcomes, resolved...)
+		if n := len(c.forkOutcomes); n > forkSamples {
+			c.forkOutcomes = c.forkOutcomes[n-forkSamples:]
+		}
+	}
+
+	var forks int
+	for _, abandoned := range c.forkOutcomes {
+		if abandoned {
+			forks++
+		}
+	}
+
+	ch <- prometheus.MustNewConstMetric(c.recentForks, prometheus.GaugeValue, float64(forks))
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/certusone/solana_exporter/pkg/rpc"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestResolveForkSamples(t *testing.T) {
+	pending, abandoned := resolveForkSamples([]int64{100, 105, 110, 120}, 110, []int64{101, 105, 107, 110})
+
+	if want := []int64{120}; !reflect.DeepEqual(pending, want) {
+		t.Errorf("pending = %v, want %v", pending, want)
+	}
+	if want := []bool{true, false, false}; !reflect.DeepEqual(abandoned, want) {
+		t.Errorf("abandoned = %v, want %v", abandoned, want)
+	}
+}
+
+func TestCollectRecentForks(t *testing.T) {
+	defer func(v bool) { *recentForks = v }(*recentForks)
+	*recentForks = true
+
+	node := newFakeNode(t)
+	var processed, finalized int64
+	node.handle("getSlot", func(params json.RawMessage) interface{} {
+		switch {
+		case strings.Contains(string(params), `"processed"`):
+			return processed
+		case strings.Contains(string(params), `"finalized"`):
+			return finalized
+		default:
+			return processed - 2
+		}
+	})
+	// Slot 100 was skipped by the finalized chain, 110 made it in.
+	node.set("getConfirmedBlocks", []int64{101, 102, 103, 110, 111})
+
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(NewSolanaCollector(node.URL, rpc.CommitmentProcessed))
+
+	for i, step := range []struct {
+		processed, finalized int64
+		want                 float64
+	}{
+		{100, 90, 0},
+		{110, 105, 1},
+		{120, 115, 1},
+	} {
+		processed, finalized = step.processed, step.finalized
+		families, _ := registry.Gather()
+		if got := metricValue(families, "solana_recent_forks", nil); got != step.want {
+			t.Errorf("scrape %d: solana_recent_forks = %v, want %v", i, got, step.want)
+		}
+	}
+
+	// The first scrape had no sample behind the finalized slot to check.
+	if calls := node.callCount("getConfirmedBlocks"); calls != 2 {
+		t.Errorf("getConfirmedBlocks called %d times, want 2", calls)
+	}
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"k8s.io/klog/v2"
+)
+
+var identitiesFile = flag.String("identities-file", "",
+	"File with vote pubkeys to watch in addition to -votepubkey, one per line (reloaded on SIGHUP)")
+
+// Vote pubkeys loaded from -identities-file. Guarded by configMu like the flags.
+var filePubkeys []string
+
+const base58Alphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+
+// isPubkey reports whether s looks like a base58 encoded 32 byte pubkey, which takes 32 to 44 characters.
+func isPubkey(s string) bool {
+	if len(s) < 32 || len(s) > 44 {
+		return false
+	}
+
+	for _, r := range s {
+		if !strings.ContainsRune(base58Alphabet, r) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// loadIdentitiesFile reads a newline-delimited list of vote pubkeys. Blank lines and lines starting with # are
+// skipped, and any other line that isn't a pubkey fails the whole file.
+func loadIdentitiesFile(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var pubkeys []string
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		entry := strings.TrimSpace(scanner.Text())
+		if entry == "" || strings.HasPrefix(entry, "#") {
+			continue
+		}
+		if !isPubkey(entry) {
+			return nil, fmt.Errorf("%s:%d: invalid pubkey %q", path, line, entry)
+		}
+		pubkeys = append(pubkeys, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return pubkeys, nil
+}
+
+// reloadIdentitiesOnSIGHUP re-reads the identities file whenever the process receives SIGHUP. If the file
+// can't be loaded, the previous list is kept.
+func reloadIdentitiesOnSIGHUP(path string) {
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, syscall.SIGHUP)
+
+	for range sig {
+		klog.Infof("received SIGHUP, reloading %s", path)
+
+		pubkeys, err := loadIdentitiesFile(path)
+		if err != nil {
+			klog.Errorf("failed to reload identities: %v", err)
+			continue
+		}
+
+		configMu.Lock()
+		filePubkeys = pubkeys
+		configMu.Unlock()
+
+		klog.Infof("watching %d vote pubkeys from %s", len(pubkeys), path)
+	}
+}
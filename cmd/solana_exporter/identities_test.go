@@ -0,0 +1,86 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const (
+	testPubkey1 = "Certusm1sa411sMpV9FPqU5dXAYhmmhygvxJ23S6hJ24"
+	testPubkey2 = "9QxCLckBiJc783jnMvXZubK4wH86Eqqvashtrwvcsgkv"
+)
+
+func writeIdentitiesFile(t *testing.T, content string) string {
+	t.Helper()
+
+	dir, err := ioutil.TempDir("", "identities")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	path := filepath.Join(dir, "identities.txt")
+	if err := ioutil.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadIdentitiesFile(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []string
+		wantErr string
+	}{
+		{
+			name:    "pubkeys with comments and blank lines",
+			content: "# validators\n" + testPubkey1 + "\n\n  " + testPubkey2 + "  \n",
+			want:    []string{testPubkey1, testPubkey2},
+		},
+		{name: "empty", content: ""},
+		{name: "not base58", content: testPubkey1 + "\n" + strings.Repeat("0", 40) + "\n", wantErr: ":2: invalid pubkey"},
+		{name: "too short", content: "vote1\n", wantErr: ":1: invalid pubkey"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := loadIdentitiesFile(writeIdentitiesFile(t, tt.content))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("loadIdentitiesFile() error = %v, want one containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("loadIdentitiesFile() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := loadIdentitiesFile(filepath.Join(os.TempDir(), "missing-identities.txt")); err == nil {
+		t.Error("loadIdentitiesFile() of a missing file succeeded")
+	}
+}
+
+func TestWatchedVotePubkeysFromFile(t *testing.T) {
+	defer func(v string) { *votePubkey = v }(*votePubkey)
+	defer func(v []string) { filePubkeys = v }(filePubkeys)
+	*votePubkey = "vote1," + testPubkey1
+	filePubkeys = []string{testPubkey1, testPubkey2}
+
+	want := []string{"vote1", testPubkey1, testPubkey2}
+	if got := watchedVotePubkeys(); !reflect.DeepEqual(got, want) {
+		t.Errorf("watchedVotePubkeys() = %v, want %v", got, want)
+	}
+	if !loadRuntimeConfig().isWatched(testPubkey2) {
+		t.Errorf("%s from the file isn't watched", testPubkey2)
+	}
+}
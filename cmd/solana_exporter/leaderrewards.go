@@ -0,0 +1,124 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/certusone/solana_exporter/pkg/rpc"
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// Maximum number of blocks fetched per scrape to sum up leader rewards. A validator has a few leader
+	// slots per minute at most, so this catches up quickly after a restart without slowing down scrapes.
+	maxRewardBlocksPerScrape = 16
+)
+
+// leaderRewards sums up the fee rewards the watched validators received as leaders in an epoch.
+type leaderRewards struct {
+	epoch int64
+	// Leader slots of each identity in the epoch, in ascending order.
+	slots map[string][]int64
+	// Leader slots of each identity not fetched yet, in ascending order.
+	pending map[string][]int64
+	// Rewards received so far, keyed by identity.
+	lamports map[string]int64
+}
+
+// collectLeaderRewards fetches the blocks of the leader slots the watched validators had since the previous
+// scrape and emits the fee rewards received in the current epoch. Rewards are only in the blocks
+// themselves, so they are fetched a few per scrape and the sum lags behind after a restart. It also emits
+// the number of leader slots left in the epoch, which comes from the same leader schedule.
+func (c *solanaCollector) collectLeaderRewards(ctx context.Context, ch chan<- prometheus.Metric,
+	epoch *rpc.EpochInfo, watched []rpc.VoteAccount) {
+	if epoch == nil || len(watched) == 0 {
+		return
+	}
+
+	c.leaderRewardsMu.Lock()
+	defer c.leaderRewardsMu.Unlock()
+
+	if c.leaderRewards == nil || c.leaderRewards.epoch != epoch.Epoch {
+		state, err := c.newLeaderRewards(ctx, epoch, watched)
+		if err != nil {
+			klog.Errorf("failed to get leader schedule for leader rewards: %v", err)
+			ch <- prometheus.NewInvalidMetric(c.leaderRewardsLamports, err)
+			ch <- prometheus.NewInvalidMetric(c.leaderSlotsRemaining, err)
+			return
+		}
+		c.leaderRewards = state
+	}
+	state := c.leaderRewards
+
+	fetched := 0
+	for _, account := range watched {
+		identity := account.NodePubkey
+		for len(state.pending[identity]) > 0 && fetched < maxRewardBlocksPerScrape {
+			slot := state.pending[identity][0]
+			if slot > epoch.AbsoluteSlot {
+				break
+			}
+
+			rewards, ok, err := c.rpcClient.GetBlockRewards(ctx, slot, rpc.CommitmentConfirmed)
+			fetched++
+			if err != nil {
+				// The block may not be confirmed yet, try again on the next scrape.
+				klog.V(1).Infof("failed to get block %d for leader rewards: %v", slot, err)
+				break
+			}
+
+			if ok {
+				for _, reward := range rewards {
+					if reward.Pubkey == identity && reward.RewardType == "Fee" {
+						state.lamports[identity] += int64(reward.Lamports)
+					}
+				}
+			}
+			state.pending[identity] = state.pending[identity][1:]
+		}
+
+		ch <- prometheus.MustNewConstMetric(c.leaderRewardsLamports, prometheus.GaugeValue,
+			float64(state.lamports[identity]), account.VotePubkey, identity)
+
+		slots := state.slots[identity]
+		passed := sort.Search(len(slots), func(i int) bool { return slots[i] > epoch.AbsoluteSlot })
+		ch <- prometheus.MustNewConstMetric(c.leaderSlotsRemaining, prometheus.GaugeValue,
+			float64(len(slots)-passed), account.VotePubkey, identity)
+	}
+}
+
+// newLeaderRewards looks up the leader slots of the watched validators in the given epoch.
+func (c *solanaCollector) newLeaderRewards(ctx context.Context, epoch *rpc.EpochInfo,
+	watched []rpc.VoteAccount) (*leaderRewards, error) {
+	firstSlot := epoch.AbsoluteSlot - epoch.SlotIndex
+	schedule, err := c.rpcClient.GetLeaderSchedule(ctx, firstSlot)
+	if err != nil {
+		return nil, err
+	}
+	// WatchSlots doesn't run with watched validators, so the schedule's presence is reported from here.
+	if schedule == nil {
+		leaderSchedulePresent.Set(0)
+		return nil, fmt.Errorf("leader schedule for slot %d is not available yet", firstSlot)
+	}
+	leaderSchedulePresent.Set(1)
+
+	state := &leaderRewards{
+		epoch:    epoch.Epoch,
+		slots:    make(map[string][]int64, len(watched)),
+		pending:  make(map[string][]int64, len(watched)),
+		lamports: make(map[string]int64, len(watched)),
+	}
+	for _, account := range watched {
+		var slots []int64
+		for _, index := range schedule[account.NodePubkey] {
+			slots = append(slots, firstSlot+index)
+		}
+		sort.Slice(slots, func(i, j int) bool { return slots[i] < slots[j] })
+		state.slots[account.NodePubkey] = slots
+		state.pending[account.NodePubkey] = slots
+	}
+
+	return state, nil
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/certusone/solana_exporter/pkg/rpc"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestCollectLeaderRewards(t *testing.T) {
+	node := newFakeNode(t)
+	// Relative to the first slot of the epoch, 900.
+	node.set("getLeaderSchedule", map[string][]int64{"node1": {5, 0, 2000}, "node2": {1}})
+	node.handle("getBlock", func(params json.RawMessage) interface{} {
+		var slot int64
+		_ = json.Unmarshal(params, &[]interface{}{&slot})
+		switch slot {
+		case 900:
+			return map[string]interface{}{"rewards": []map[string]interface{}{
+				{"pubkey": "node1", "lamports": 5000, "rewardType": "Fee"},
+				{"pubkey": "node1", "lamports": 7, "rewardType": "Rent"},
+				{"pubkey": "other", "lamports": 100, "rewardType": "Fee"},
+			}}
+		case 905:
+			return map[string]interface{}{"rewards": []map[string]interface{}{
+				{"pubkey": "node1", "lamports": 2500, "rewardType": "Fee"},
+			}}
+		default:
+			t.Errorf("fetched block %d, which isn't a past leader slot of node1", slot)
+			return nil
+		}
+	})
+
+	c := NewSolanaCollector(node.URL, rpc.CommitmentProcessed)
+	epoch := &rpc.EpochInfo{Epoch: 5, AbsoluteSlot: 1000, SlotIndex: 100}
+	watched := []rpc.VoteAccount{{VotePubkey: "vote1", NodePubkey: "node1"}}
+
+	for scrape := 1; scrape <= 2; scrape++ {
+		got := emitted(t, func(ch chan<- prometheus.Metric) {
+			c.collectLeaderRewards(context.Background(), ch, epoch, watched)
+		})
+
+		key := `solana_validator_leader_rewards_lamports{nodekey="node1",pubkey="vote1"}`
+		if got[key] != 7500 {
+			t.Errorf("scrape %d: %s = %v, want 7500", scrape, key, got[key])
+		}
+	}
+
+	// Blocks are fetched once, and the future leader slot not at all.
+	if calls := node.callCount("getBlock"); calls != 2 {
+		t.Errorf("getBlock called %d times, want 2", calls)
+	}
+	if calls := node.callCount("getLeaderSchedule"); calls != 1 {
+		t.Errorf("getLeaderSchedule called %d times, want 1", calls)
+	}
+
+	// A new epoch starts over with its own schedule.
+	epoch = &rpc.EpochInfo{Epoch: 6, AbsoluteSlot: 1000, SlotIndex: 0}
+	node.set("getLeaderSchedule", map[string][]int64{"node1": {}})
+	got := emitted(t, func(ch chan<- prometheus.Metric) {
+		c.collectLeaderRewards(context.Background(), ch, epoch, watched)
+	})
+	if key := `solana_validator_leader_rewards_lamports{nodekey="node1",pubkey="vote1"}`; got[key] != 0 {
+		t.Errorf("new epoch: %s = %v, want 0", key, got[key])
+	}
+}
+
+func TestLeaderSlotsRemaining(t *testing.T) {
+	node := newFakeNode(t)
+	// Relative to the first slot of the epoch, 900. The current slot 1000 is no longer ahead.
+	node.set("getLeaderSchedule", map[string][]int64{"node1": {2000, 5, 100, 101, 1500}})
+	node.set("getBlock", map[string]interface{}{"rewards": []interface{}{}})
+
+	c := NewSolanaCollector(node.URL, rpc.CommitmentProcessed)
+	watched := []rpc.VoteAccount{{VotePubkey: "vote1", NodePubkey: "node1"}}
+	key := `solana_validator_leader_slots_remaining{nodekey="node1",pubkey="vote1"}`
+
+	for _, step := range []struct {
+		absoluteSlot int64
+		want         float64
+	}{
+		{1000, 3},
+		{1001, 2},
+		{2900, 0},
+	} {
+		epoch := &rpc.EpochInfo{Epoch: 5, AbsoluteSlot: step.absoluteSlot, SlotIndex: step.absoluteSlot - 900}
+		got := emitted(t, func(ch chan<- prometheus.Metric) {
+			c.collectLeaderRewards(context.Background(), ch, epoch, watched)
+		})
+		if got[key] != step.want {
+			t.Errorf("at slot %d: %s = %v, want %v", step.absoluteSlot, key, got[key], step.want)
+		}
+	}
+}
+
+// With watched validators WatchSlots doesn't run, so the leader rewards report whether the schedule is there.
+func TestLeaderRewardsSchedulePresent(t *testing.T) {
+	node := newFakeNode(t)
+	c := NewSolanaCollector(node.URL, rpc.CommitmentProcessed)
+	epoch := &rpc.EpochInfo{Epoch: 5, AbsoluteSlot: 1000, SlotIndex: 100}
+	watched := []rpc.VoteAccount{{VotePubkey: "vote1", NodePubkey: "node1"}}
+
+	for _, step := range []struct {
+		name     string
+		schedule interface{}
+		want     float64
+	}{
+		// Shortly after an epoch rollover.
+		{name: "null", schedule: nil, want: 0},
+		{name: "present", schedule: map[string][]int64{"node1": {2000}}, want: 1},
+	} {
+		node.set("getLeaderSchedule", step.schedule)
+		// Without a schedule the rewards fail, only the gauge matters here.
+		ch := make(chan prometheus.Metric, 16)
+		c.collectLeaderRewards(context.Background(), ch, epoch, watched)
+		close(ch)
+
+		if got := testutil.ToFloat64(leaderSchedulePresent); got != step.want {
+			t.Errorf("%s: solana_leader_schedule_present = %v, want %v", step.name, got, step.want)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestHelperExporter runs main with the arguments in SOLANA_EXPORTER_ARGS when started by startExporter.
+func TestHelperExporter(t *testing.T) {
+	args, ok := os.LookupEnv("SOLANA_EXPORTER_ARGS")
+	if !ok {
+		return
+	}
+
+	os.Args = append([]string{"solana_exporter"}, strings.Fields(args)...)
+	main()
+}
+
+// startExporter runs the exporter in a child process listening on a free local port and returns its base URL
+// once it accepts connections.
+func startExporter(t *testing.T, args ...string) string {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := l.Addr().String()
+	l.Close()
+
+	cmd := exec.Command(os.Args[0], "-test.run=^TestHelperExporter$")
+	cmd.Env = append(os.Environ(), "SOLANA_EXPORTER_ARGS="+strings.Join(append(args, "-addr", addr), " "))
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+	})
+
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		conn, err := net.Dial("tcp", addr)
+		if err == nil {
+			conn.Close()
+			return "http://" + addr
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("exporter didn't listen on %s: %v", addr, err)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+func TestOpenMetrics(t *testing.T) {
+	node := newFakeNode(t)
+	url := startExporter(t, "-rpcURI", node.URL)
+
+	tests := []struct {
+		accept string
+		want   string
+	}{
+		{accept: "application/openmetrics-text; version=0.0.1", want: "application/openmetrics-text"},
+		{accept: "text/plain", want: "text/plain"},
+		{accept: "", want: "text/plain"},
+	}
+
+	for _, tt := range tests {
+		req, _ := http.NewRequest("GET", url+"/metrics", nil)
+		if tt.accept != "" {
+			req.Header.Set("Accept", tt.accept)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+
+		if got := resp.Header.Get("Content-Type"); !strings.HasPrefix(got, tt.want) {
+			t.Errorf("Accept %q: Content-Type = %q, want %s", tt.accept, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+var descName = regexp.MustCompile(`fqName: "([^"]+)"`)
+
+// emitted runs collect and returns the value of every series it sent, keyed by metric name and labels, e.g.
+// `solana_validator_authority_changed{authority="voter",pubkey="vote1"}`. Invalid metrics fail the test.
+func emitted(t *testing.T, collect func(ch chan<- prometheus.Metric)) map[string]float64 {
+	t.Helper()
+
+	ch := make(chan prometheus.Metric, 1024)
+	collect(ch)
+	close(ch)
+
+	values := make(map[string]float64)
+	for m := range ch {
+		var pb dto.Metric
+		if err := m.Write(&pb); err != nil {
+			t.Fatalf("invalid metric %s: %v", m.Desc(), err)
+		}
+
+		var labels []string
+		for _, pair := range pb.GetLabel() {
+			labels = append(labels, pair.GetName()+`="`+pair.GetValue()+`"`)
+		}
+		sort.Strings(labels)
+		key := descName.FindStringSubmatch(m.Desc().String())[1]
+		if len(labels) > 0 {
+			key += "{" + strings.Join(labels, ",") + "}"
+		}
+
+		switch {
+		case pb.Gauge != nil:
+			values[key] = pb.GetGauge().GetValue()
+		case pb.Counter != nil:
+			values[key] = pb.GetCounter().GetValue()
+		default:
+			values[key] = pb.GetUntyped().GetValue()
+		}
+	}
+
+	return values
+}
+
+// metricValue returns the value of the series of name with the given labels, -1 if there is none.
+func metricValue(families []*dto.MetricFamily, name string, labels map[string]string) float64 {
+	for _, family := range families {
+		if family.GetName() != name {
+			continue
+		}
+
+	metrics:
+		for _, m := range family.GetMetric() {
+			for _, pair := range m.GetLabel() {
+				if want, ok := labels[pair.GetName()]; ok && want != pair.GetValue() {
+					continue metrics
+				}
+			}
+
+			switch {
+			case m.Gauge != nil:
+				return m.GetGauge().GetValue()
+			case m.Counter != nil:
+				return m.GetCounter().GetValue()
+			case m.Untyped != nil:
+				return m.GetUntyped().GetValue()
+			}
+		}
+	}
+
+	return -1
+}
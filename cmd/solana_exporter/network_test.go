@@ -0,0 +1,39 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestNetworkRPCAddrs(t *testing.T) {
+	for network, want := range map[string]string{
+		"mainnet": "https://api.mainnet-beta.solana.com",
+		"testnet": "https://api.testnet.solana.com",
+		"devnet":  "https://api.devnet.solana.com",
+	} {
+		if got := networkRPCAddrs[network]; got != want {
+			t.Errorf("-network %s uses %q, want %q", network, got, want)
+		}
+	}
+}
+
+// An unknown -network must stop the exporter instead of falling back to some endpoint.
+func TestInvalidNetwork(t *testing.T) {
+	if os.Getenv("SOLANA_EXPORTER_RUN_MAIN") == "1" {
+		os.Args = []string{"solana_exporter", "-network", "localnet"}
+		main()
+		return
+	}
+
+	cmd := exec.Command(os.Args[0], "-test.run=^TestInvalidNetwork$")
+	cmd.Env = append(os.Environ(), "SOLANA_EXPORTER_RUN_MAIN=1")
+	out, err := cmd.CombinedOutput()
+	if _, ok := err.(*exec.ExitError); !ok {
+		t.Fatalf("exporter with -network localnet didn't exit with an error: %v", err)
+	}
+	if !strings.Contains(string(out), `Invalid -network "localnet"`) {
+		t.Errorf("output %q doesn't report the invalid network", out)
+	}
+}
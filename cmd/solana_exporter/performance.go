@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"flag"
+
+	"github.com/certusone/solana_exporter/pkg/rpc"
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/klog/v2"
+)
+
+var perfSamplesLimit = flag.Int("perf-samples-limit", 1,
+	"Number of one minute performance samples the transaction rate is averaged over (at most 720)")
+
+// transactionRate returns the number of transactions per second over all samples, or false if they cover
+// no time at all.
+func transactionRate(samples []rpc.PerformanceSample) (float64, bool) {
+	var transactions, secs int64
+	for _, sample := range samples {
+		transactions += sample.NumTransactions
+		secs += sample.SamplePeriodSecs
+	}
+
+	if secs == 0 {
+		return 0, false
+	}
+
+	return float64(transactions) / float64(secs), true
+}
+
+// slotsPerSample returns the mean number of slots completed per sample.
+func slotsPerSample(samples []rpc.PerformanceSample) float64 {
+	var slots int64
+	for _, sample := range samples {
+		slots += sample.NumSlots
+	}
+
+	return float64(slots) / float64(len(samples))
+}
+
+// collectTransactionCount emits the number of transactions the cluster processed since genesis.
+func (c *solanaCollector) collectTransactionCount(ctx context.Context, ch chan<- prometheus.Metric) {
+	count, err := c.rpcClient.GetTransactionCount(ctx, c.commitment)
+	if err != nil {
+		klog.Errorf("failed to get transaction count: %v", err)
+		ch <- prometheus.NewInvalidMetric(c.transactionCount, err)
+		return
+	}
+
+	ch <- prometheus.MustNewConstMetric(c.transactionCount, prometheus.GaugeValue, float64(count))
+}
+
+// collectPerformance emits the cluster's transaction rate over the most recent -perf-samples-limit
+// performance samples and returns them, nil if they couldn't be fetched.
+func (c *solanaCollector) collectPerformance(ctx context.Context, ch chan<- prometheus.Metric) []rpc.PerformanceSample {
+	samples, err := c.rpcClient.GetRecentPerformanceSamples(ctx, *perfSamplesLimit)
+	if err != nil {
+		klog.Errorf("failed to get performance samples: %v", err)
+		ch <- prometheus.NewInvalidMetric(c.transactionsPerSecond, err)
+		return nil
+	}
+
+	if rate, ok := transactionRate(samples); ok {
+		ch <- prometheus.MustNewConstMetric(c.transactionsPerSecond, prometheus.GaugeValue, rate)
+	}
+	if len(samples) > 0 {
+		ch <- prometheus.MustNewConstMetric(c.slotsPerSample, prometheus.GaugeValue, slotsPerSample(samples))
+	}
+
+	return samples
+}
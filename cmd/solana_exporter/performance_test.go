@@ -0,0 +1,91 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/certusone/solana_exporter/pkg/rpc"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestTransactionRate(t *testing.T) {
+	samples := []rpc.PerformanceSample{
+		{Slot: 200, NumTransactions: 3000, NumSlots: 150, SamplePeriodSecs: 60},
+		{Slot: 50, NumTransactions: 6000, NumSlots: 150, SamplePeriodSecs: 60},
+	}
+	if got, ok := transactionRate(samples); !ok || got != 75 {
+		t.Errorf("transactionRate = %v, %v, want 75, true", got, ok)
+	}
+
+	if _, ok := transactionRate(nil); ok {
+		t.Error("transactionRate of no samples is ok, want false")
+	}
+	if _, ok := transactionRate([]rpc.PerformanceSample{{NumTransactions: 10}}); ok {
+		t.Error("transactionRate of samples covering no time is ok, want false")
+	}
+}
+
+func TestCollectPerformance(t *testing.T) {
+	defer func(v int) { *perfSamplesLimit = v }(*perfSamplesLimit)
+	*perfSamplesLimit = 2
+
+	node := newFakeNode(t)
+	var limit []int
+	node.handle("getRecentPerformanceSamples", func(params json.RawMessage) interface{} {
+		if err := json.Unmarshal(params, &limit); err != nil {
+			t.Errorf("unexpected params %s: %v", params, err)
+		}
+		return []map[string]interface{}{
+			{"slot": 200, "numTransactions": 3000, "numSlots": 150, "samplePeriodSecs": 60},
+			{"slot": 50, "numTransactions": 6000, "numSlots": 150, "samplePeriodSecs": 60},
+		}
+	})
+
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(NewSolanaCollector(node.URL, rpc.CommitmentProcessed))
+	families, _ := registry.Gather()
+
+	if len(limit) != 1 || limit[0] != 2 {
+		t.Errorf("requested samples %v, want [2]", limit)
+	}
+	if got := metricValue(families, "solana_cluster_tps", nil); got != 75 {
+		t.Errorf("solana_cluster_tps = %v, want 75", got)
+	}
+	if got := metricValue(families, "solana_cluster_slots_per_sample", nil); got != 150 {
+		t.Errorf("solana_cluster_slots_per_sample = %v, want 150", got)
+	}
+
+	// Without samples there is no mean.
+	node.handle("getRecentPerformanceSamples", func(json.RawMessage) interface{} { return []interface{}{} })
+	families, _ = registry.Gather()
+	if got := metricValue(families, "solana_cluster_slots_per_sample", nil); got != -1 {
+		t.Errorf("solana_cluster_slots_per_sample = %v without samples, want no metric", got)
+	}
+}
+
+func TestSlotsPerSample(t *testing.T) {
+	samples := []rpc.PerformanceSample{{NumSlots: 150}, {NumSlots: 120}, {NumSlots: 90}}
+	if got := slotsPerSample(samples); got != 120 {
+		t.Errorf("slotsPerSample = %v, want 120", got)
+	}
+}
+
+func TestTransactionCount(t *testing.T) {
+	node := newFakeNode(t)
+	var requested string
+	node.handle("getTransactionCount", func(params json.RawMessage) interface{} {
+		requested = string(params)
+		return 123456
+	})
+
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(NewSolanaCollector(node.URL, rpc.CommitmentConfirmed))
+	families, _ := registry.Gather()
+
+	if want := `[{"commitment":"confirmed"}]`; requested != want {
+		t.Errorf("requested transaction count with %s, want %s", requested, want)
+	}
+	if got := metricValue(families, "solana_network_transaction_count", nil); got != 123456 {
+		t.Errorf("solana_network_transaction_count = %v, want 123456", got)
+	}
+}
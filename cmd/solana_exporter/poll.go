@@ -0,0 +1,60 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/klog/v2"
+)
+
+var backgroundPolling = flag.Bool("background-polling", false,
+	"Collect node metrics every -poll-interval in the background and serve the latest results on scrape")
+
+// poll collects the node metrics and keeps them for scrapes to be served from.
+func (c *solanaCollector) poll() {
+	polled := collectAll(c.collectNow)
+
+	c.polledMu.Lock()
+	c.polled = polled
+	c.lastPoll = time.Now()
+	c.polledMu.Unlock()
+}
+
+// pollLoop polls the node every interval after the initial fetch until ctx is canceled, so scrapes don't make
+// any RPC calls. However many Prometheus servers scrape the exporter, the node sees the same load. Polls keep
+// to the interval however long each takes, and one running past the next tick delays rather than doubles it.
+func (c *solanaCollector) pollLoop(ctx context.Context, interval time.Duration) {
+	klog.Infof("polling node metrics every %v", interval)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			c.poll()
+		}
+	}
+}
+
+// collectPolled serves the metrics of the latest poll, nothing until the first one has finished.
+func (c *solanaCollector) collectPolled(ch chan<- prometheus.Metric) {
+	c.polledMu.Lock()
+	defer c.polledMu.Unlock()
+
+	for _, m := range c.polled {
+		ch <- m
+	}
+}
+
+// lastPollTime returns when the latest poll finished, zero without -background-polling or before the first.
+func (c *solanaCollector) lastPollTime() time.Time {
+	c.polledMu.Lock()
+	defer c.polledMu.Unlock()
+
+	return c.lastPoll
+}
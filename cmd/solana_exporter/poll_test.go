@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/certusone/solana_exporter/pkg/rpc"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Polls keep to the interval even when each of them takes a while, and the exported timestamp is that of the
+// latest one.
+func TestPollLoopTimestamps(t *testing.T) {
+	const (
+		interval = 200 * time.Millisecond
+		slowPoll = 80 * time.Millisecond
+	)
+
+	node := newFakeNode(t)
+	node.setDelay("getEpochInfo", slowPoll)
+	c := NewSolanaCollector(node.URL, rpc.CommitmentProcessed)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan struct{})
+	go func() {
+		c.pollLoop(ctx, interval)
+		close(stopped)
+	}()
+
+	var polls []time.Time
+	deadline := time.Now().Add(5 * interval)
+	for len(polls) < 3 && time.Now().Before(deadline) {
+		if last := c.lastPollTime(); !last.IsZero() && (len(polls) == 0 || last.After(polls[len(polls)-1])) {
+			polls = append(polls, last)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if len(polls) < 3 {
+		t.Fatalf("got %d polls within %v, want 3", len(polls), 5*interval)
+	}
+
+	for i := 1; i < len(polls); i++ {
+		if gap := polls[i].Sub(polls[i-1]); gap < interval-40*time.Millisecond || gap > interval+40*time.Millisecond {
+			t.Errorf("poll %d finished %v after the previous one, want about %v", i, gap, interval)
+		}
+	}
+
+	cancel()
+	select {
+	case <-stopped:
+	case <-time.After(interval + slowPoll + time.Second):
+		t.Fatal("pollLoop didn't return after the context was canceled")
+	}
+
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(selfCollector{c})
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := metricValue(families, "solana_exporter_last_poll_timestamp", nil)
+	if want := float64(c.lastPollTime().Unix()); got != want {
+		t.Errorf("solana_exporter_last_poll_timestamp = %v, want %v", got, want)
+	}
+}
+
+func TestBackgroundPolling(t *testing.T) {
+	defer func(v bool) { *backgroundPolling = v }(*backgroundPolling)
+	*backgroundPolling = true
+
+	node := newFakeNode(t)
+	c := NewSolanaCollector(node.URL, rpc.CommitmentProcessed)
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(c)
+	self := prometheus.NewRegistry()
+	self.MustRegister(selfCollector{c})
+
+	// Nothing is served before the first poll.
+	families, _ := registry.Gather()
+	if got := metricValue(families, "solana_current_epoch", nil); got != -1 {
+		t.Errorf("solana_current_epoch = %v before the first poll, want it left out", got)
+	}
+	families, _ = self.Gather()
+	if got := metricValue(families, "solana_exporter_last_poll_timestamp", nil); got != -1 {
+		t.Errorf("solana_exporter_last_poll_timestamp = %v before the first poll, want it left out", got)
+	}
+
+	before := time.Now().Unix()
+	c.poll()
+	calls := node.callCount("getEpochInfo")
+
+	// Scrapes are served from the poll without calling the node.
+	for i := 0; i < 2; i++ {
+		families, _ = registry.Gather()
+		if got := metricValue(families, "solana_current_epoch", nil); got != 5 {
+			t.Errorf("scrape %d: solana_current_epoch = %v, want 5", i, got)
+		}
+	}
+	if got := node.callCount("getEpochInfo"); got != calls {
+		t.Errorf("scrapes called getEpochInfo %d times, want none", got-calls)
+	}
+
+	families, _ = self.Gather()
+	if got := metricValue(families, "solana_exporter_last_poll_timestamp", nil); got < float64(before) ||
+		got > float64(time.Now().Unix()) {
+		t.Errorf("solana_exporter_last_poll_timestamp = %v, want the time of the poll", got)
+	}
+}
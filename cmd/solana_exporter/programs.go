@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"sync"
+
+	"github.com/certusone/solana_exporter/pkg/rpc"
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/klog/v2"
+)
+
+const (
+	// Maximum number of getProgramAccounts calls in flight at once.
+	programAccountsConcurrency = 4
+)
+
+var programIDs = flag.String("program-id", "",
+	"Comma separated program IDs to export the number of owned accounts of")
+
+// programAccountsCollector emits the number of accounts owned by each program given with -program-id.
+// getProgramAccounts is expensive, so it lives in a collector of its own with a separate timeout.
+type programAccountsCollector struct {
+	rpcClient  *rpc.RPCClient
+	commitment rpc.Commitment
+	programs   []string
+
+	accountCount *prometheus.Desc
+}
+
+func newProgramAccountsCollector(client *rpc.RPCClient, commitment rpc.Commitment, programs []string) *programAccountsCollector {
+	return &programAccountsCollector{
+		rpcClient:  client,
+		commitment: commitment,
+		programs:   programs,
+		accountCount: prometheus.NewDesc(
+			"solana_program_account_count",
+			"Number of accounts owned by the program",
+			[]string{"program"}, nil),
+	}
+}
+
+func (c *programAccountsCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.accountCount
+}
+
+// Collect fetches the programs concurrently. A program that fails is logged and left out, so one slow or
+// unsupported program doesn't fail the whole scrape.
+func (c *programAccountsCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), httpTimeout)
+	defer cancel()
+
+	sem := make(chan struct{}, programAccountsConcurrency)
+	var wg sync.WaitGroup
+	for _, program := range c.programs {
+		wg.Add(1)
+		go func(program string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			count, err := c.rpcClient.GetProgramAccountCount(ctx, program, c.commitment)
+			if err != nil {
+				klog.Errorf("failed to get accounts of program %s: %v", program, err)
+				return
+			}
+
+			ch <- prometheus.MustNewConstMetric(c.accountCount, prometheus.GaugeValue, float64(count), program)
+		}(program)
+	}
+	wg.Wait()
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/certusone/solana_exporter/pkg/rpc"
+)
+
+func TestProgramAccountCount(t *testing.T) {
+	node := newFakeNode(t)
+	// prog3 gets a result that isn't a list of accounts and fails, which must not affect the others.
+	node.handle("getProgramAccounts", func(params json.RawMessage) interface{} {
+		switch {
+		case strings.Contains(string(params), `"prog1"`):
+			return []map[string]interface{}{{"pubkey": "a"}, {"pubkey": "b"}, {"pubkey": "c"}}
+		case strings.Contains(string(params), `"prog2"`):
+			return []map[string]interface{}{}
+		default:
+			return "unexpected"
+		}
+	})
+
+	c := newProgramAccountsCollector(rpc.NewRPCClient(node.URL), rpc.CommitmentFinalized, []string{"prog1", "prog2", "prog3"})
+	got := emitted(t, c.Collect)
+
+	want := map[string]float64{
+		`solana_program_account_count{program="prog1"}`: 3,
+		`solana_program_account_count{program="prog2"}`: 0,
+	}
+	if len(got) != len(want) {
+		t.Errorf("emitted %v, want %v", got, want)
+	}
+	for key, value := range want {
+		if v, ok := got[key]; !ok || v != value {
+			t.Errorf("%s = %v (present %v), want %v", key, v, ok, value)
+		}
+	}
+
+	for _, params := range node.paramsOf("getProgramAccounts") {
+		if !strings.Contains(string(params), `"dataSlice":{"length":0,"offset":0}`) {
+			t.Errorf("getProgramAccounts called with %s, want an empty data slice", params)
+		}
+	}
+}
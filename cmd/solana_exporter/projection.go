@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"flag"
+
+	"github.com/certusone/solana_exporter/pkg/rpc"
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/klog/v2"
+)
+
+var computeProjectedRewards = flag.Bool("compute-projected-rewards", false,
+	"Estimate the inflation rewards the watched validators will earn this epoch")
+
+// Slots per year at the nominal slot time of 400ms, as used by the inflation schedule.
+const slotsPerYear = 365.242199 * 24 * 60 * 60 * 2.5
+
+// epochInflation returns the lamports paid out to stakers for an epoch, given the annual validator inflation
+// rate and the total supply.
+func epochInflation(validatorRate float64, totalSupply int64, slotsInEpoch int64) float64 {
+	return validatorRate * float64(totalSupply) * float64(slotsInEpoch) / slotsPerYear
+}
+
+// projectedRewards splits the epoch's inflation across validators by points, which are their activated stake
+// times the credits earned so far. Credits of all validators grow at much the same pace over the rest of the
+// epoch, so the shares so far stand in for those at the end. It returns the projected rewards per vote
+// pubkey, commission included.
+func (c *solanaCollector) projectedRewards(accounts []rpc.VoteAccount, inflation float64) map[string]float64 {
+	points := make(map[string]float64, len(accounts))
+	var total float64
+	for _, account := range accounts {
+		p := float64(account.ActivatedStake) * float64(c.calcEpochCredits(account.EpochCredits))
+		points[account.VotePubkey] = p
+		total += p
+	}
+
+	if total == 0 {
+		return nil
+	}
+
+	rewards := make(map[string]float64, len(points))
+	for pubkey, p := range points {
+		rewards[pubkey] = inflation * p / total
+	}
+
+	return rewards
+}
+
+// collectProjectedRewards emits the projected rewards of the watched validators, split into the commission
+// paid to the vote account and the rest, which goes to the stakers.
+func (c *solanaCollector) collectProjectedRewards(ctx context.Context, ch chan<- prometheus.Metric,
+	watched []rpc.VoteAccount, set *voteAccountSet, epoch *rpc.EpochInfo, supply *rpc.Supply) {
+	if epoch == nil || supply == nil {
+		return
+	}
+
+	all, err := set.get()
+	if err != nil {
+		klog.Errorf("failed to get vote accounts for projected rewards: %v", err)
+		ch <- prometheus.NewInvalidMetric(c.projectedEpochRewards, err)
+		return
+	}
+
+	rate, err := c.rpcClient.GetInflationRate(ctx)
+	if err != nil {
+		klog.Errorf("failed to get inflation rate: %v", err)
+		ch <- prometheus.NewInvalidMetric(c.projectedEpochRewards, err)
+		return
+	}
+
+	inflation := epochInflation(rate.Validator, int64(supply.Total), epoch.SlotsInEpoch)
+	rewards := c.projectedRewards(all.Result.Current, inflation)
+	for _, account := range watched {
+		reward, ok := rewards[account.VotePubkey]
+		if !ok {
+			continue
+		}
+
+		commission := reward * float64(account.Commission) / 100
+		ch <- prometheus.MustNewConstMetric(c.projectedEpochRewards, prometheus.GaugeValue, commission,
+			account.VotePubkey, account.NodePubkey, "vote")
+		ch <- prometheus.MustNewConstMetric(c.projectedEpochRewards, prometheus.GaugeValue, reward-commission,
+			account.VotePubkey, account.NodePubkey, "stakers")
+	}
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"encoding/json"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/certusone/solana_exporter/pkg/rpc"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestEpochInflation(t *testing.T) {
+	// A 432000 slot epoch lasts about 2.0 days at 400ms per slot, which pays out 5% of 1e9 lamports
+	// times 2.0 / 365.24.
+	if got := epochInflation(0.05, 1000000000, 432000); math.Abs(got-273790.93) > 0.01 {
+		t.Errorf("epochInflation = %v, want 273790.93", got)
+	}
+}
+
+func TestProjectedRewards(t *testing.T) {
+	c := NewSolanaCollector("http://localhost:8899", rpc.CommitmentProcessed)
+	accounts := []rpc.VoteAccount{
+		{VotePubkey: "vote1", ActivatedStake: 100, EpochCredits: [][3]int{{5, 150, 100}}},
+		{VotePubkey: "vote2", ActivatedStake: 300, EpochCredits: [][3]int{{5, 150, 100}}},
+		{VotePubkey: "vote3", ActivatedStake: 200, EpochCredits: [][3]int{{5, 125, 100}}},
+		{VotePubkey: "vote4", ActivatedStake: 500},
+	}
+
+	// Points are 5000, 15000, 5000 and 0.
+	want := map[string]float64{"vote1": 2000, "vote2": 6000, "vote3": 2000, "vote4": 0}
+	if got := c.projectedRewards(accounts, 10000); !reflect.DeepEqual(got, want) {
+		t.Errorf("projectedRewards = %v, want %v", got, want)
+	}
+
+	if got := c.projectedRewards(accounts[3:], 10000); got != nil {
+		t.Errorf("projectedRewards without any points = %v, want nil", got)
+	}
+}
+
+func TestCollectProjectedRewards(t *testing.T) {
+	defer func(v string) { *votePubkey = v }(*votePubkey)
+	defer func(v bool) { *computeProjectedRewards = v }(*computeProjectedRewards)
+	*votePubkey = "vote1"
+	*computeProjectedRewards = true
+
+	node := newFakeNode(t)
+	vote1 := map[string]interface{}{"votePubkey": "vote1", "nodePubkey": "node1", "activatedStake": 5000,
+		"commission": 10, "epochVoteAccount": true, "lastVote": 995, "rootSlot": 960,
+		"epochCredits": [][]int{{5, 150, 100}}}
+	vote3 := map[string]interface{}{"votePubkey": "vote3", "nodePubkey": "node3", "activatedStake": 15000,
+		"commission": 0, "epochVoteAccount": true, "lastVote": 995, "rootSlot": 960,
+		"epochCredits": [][]int{{5, 150, 100}}}
+	node.handle("getVoteAccounts", func(params json.RawMessage) interface{} {
+		if strings.Contains(string(params), `"votePubkey"`) {
+			return map[string]interface{}{"current": []interface{}{vote1}, "delinquent": []interface{}{}}
+		}
+		return map[string]interface{}{"current": []interface{}{vote1, vote3}, "delinquent": []interface{}{}}
+	})
+	node.set("getInflationRate", map[string]interface{}{"total": 0.06, "validator": 0.05, "foundation": 0.01, "epoch": 5})
+	node.set("getSupply", map[string]interface{}{
+		"context": map[string]interface{}{"slot": 990},
+		"value": map[string]interface{}{"total": 500000000000000000, "circulating": 400000000000000000,
+			"nonCirculating": 100000000000000000, "nonCirculatingAccounts": []string{}},
+	})
+
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(NewSolanaCollector(node.URL, rpc.CommitmentProcessed))
+	families, _ := registry.Gather()
+
+	// vote1 has a quarter of the points and keeps its 10% commission of that.
+	share := epochInflation(0.05, 500000000000000000, 432000) / 4
+	for recipient, want := range map[string]float64{"vote": share * 0.1, "stakers": share * 0.9} {
+		got := metricValue(families, "solana_validator_projected_epoch_rewards_lamports",
+			map[string]string{"pubkey": "vote1", "recipient": recipient})
+		if math.Abs(got-want) > 1 {
+			t.Errorf("projected rewards of vote1 for %s = %v, want %v", recipient, got, want)
+		}
+	}
+	if got := metricValue(families, "solana_validator_projected_epoch_rewards_lamports",
+		map[string]string{"pubkey": "vote3"}); got != -1 {
+		t.Errorf("projected rewards of the unwatched vote3 = %v, want them left out", got)
+	}
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/push"
+	"k8s.io/klog/v2"
+)
+
+// parseGroupingLabels parses a comma separated list of name=value pairs.
+func parseGroupingLabels(s string) (map[string]string, error) {
+	labels := make(map[string]string)
+	if s == "" {
+		return labels, nil
+	}
+
+	for _, pair := range strings.Split(s, ",") {
+		kv := strings.SplitN(pair, "=", 2)
+		if len(kv) != 2 || kv[0] == "" {
+			return nil, fmt.Errorf("invalid grouping label %q, expected name=value", pair)
+		}
+		labels[strings.TrimSpace(kv[0])] = strings.TrimSpace(kv[1])
+	}
+
+	return labels, nil
+}
+
+// pushMetrics periodically pushes everything registered with g to the Pushgateway at url.
+func pushMetrics(g prometheus.Gatherer, url, job string, grouping map[string]string, interval time.Duration) {
+	pusher := push.New(url, job).Gatherer(g)
+	for name, value := range grouping {
+		pusher = pusher.Grouping(name, value)
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := pusher.Push(); err != nil {
+			klog.Errorf("failed to push metrics to %s: %v", url, err)
+		} else {
+			klog.V(1).Infof("pushed metrics to %s", url)
+		}
+
+		<-ticker.C
+	}
+}
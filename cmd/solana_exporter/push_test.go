@@ -0,0 +1,76 @@
+package main
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestParseGroupingLabels(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    map[string]string
+		wantErr bool
+	}{
+		{in: "", want: map[string]string{}},
+		{in: "instance=val1", want: map[string]string{"instance": "val1"}},
+		{in: "instance=val1, cluster = mainnet", want: map[string]string{"instance": "val1", "cluster": "mainnet"}},
+		{in: "instance", wantErr: true},
+		{in: "=val1", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := parseGroupingLabels(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseGroupingLabels(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseGroupingLabels(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestPushMetrics(t *testing.T) {
+	type pushed struct {
+		method, path, body string
+	}
+	requests := make(chan pushed, 1)
+	gateway := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		select {
+		case requests <- pushed{r.Method, r.URL.Path, string(body)}:
+		default:
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer gateway.Close()
+
+	registry := prometheus.NewRegistry()
+	gauge := prometheus.NewGauge(prometheus.GaugeOpts{Name: "solana_test_pushed", Help: "Pushed in tests"})
+	gauge.Set(42)
+	registry.MustRegister(gauge)
+
+	go pushMetrics(registry, gateway.URL, "solana_exporter", map[string]string{"instance": "val1"}, time.Hour)
+
+	select {
+	case got := <-requests:
+		if got.method != http.MethodPut {
+			t.Errorf("method = %s, want PUT", got.method)
+		}
+		if want := "/metrics/job/solana_exporter/instance/val1"; got.path != want {
+			t.Errorf("path = %s, want %s", got.path, want)
+		}
+		if !strings.Contains(got.body, "solana_test_pushed") {
+			t.Errorf("pushed body does not contain solana_test_pushed")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("nothing was pushed")
+	}
+}
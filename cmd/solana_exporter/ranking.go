@@ -0,0 +1,203 @@
+package main
+
+import (
+	"context"
+	"sort"
+
+	"github.com/certusone/solana_exporter/pkg/rpc"
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/klog/v2"
+)
+
+// fetchAllVoteAccounts returns the unfiltered vote account set, which is needed to compare watched
+// validators against the rest of the cluster.
+func (c *solanaCollector) fetchAllVoteAccounts(ctx context.Context) (*rpc.GetVoteAccountsResponse, error) {
+	return c.rpcClient.GetAllVoteAccounts(ctx, c.commitment)
+}
+
+// voteAccountSet hands out the unfiltered vote account set, fetching it at most once per scrape.
+type voteAccountSet struct {
+	c      *solanaCollector
+	budget *callBudget
+
+	fetched bool
+	resp    *rpc.GetVoteAccountsResponse
+	err     error
+}
+
+func (s *voteAccountSet) get() (*rpc.GetVoteAccountsResponse, error) {
+	if !s.fetched {
+		s.resp, s.err = s.c.fetchAllVoteAccounts(s.budget.nextHeavy())
+		s.fetched = true
+	}
+
+	return s.resp, s.err
+}
+
+// rankBy sorts accounts in descending order of value and returns the 1-based rank per vote pubkey.
+func rankBy(accounts []rpc.VoteAccount, value func(rpc.VoteAccount) int64) map[string]int {
+	sorted := make([]rpc.VoteAccount, len(accounts))
+	copy(sorted, accounts)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return value(sorted[i]) > value(sorted[j])
+	})
+
+	ranks := make(map[string]int, len(sorted))
+	for i, account := range sorted {
+		ranks[account.VotePubkey] = i + 1
+	}
+
+	return ranks
+}
+
+// collectStakeRank emits the rank of the watched validators among all current validators by activated
+// stake. The percentile is the share of current validators ranked at or below the watched validator.
+func (c *solanaCollector) collectStakeRank(ch chan<- prometheus.Metric, watched []rpc.VoteAccount, set *voteAccountSet) {
+	all, err := set.get()
+	if err != nil {
+		klog.Errorf("failed to get vote accounts for ranking: %v", err)
+		ch <- prometheus.NewInvalidMetric(c.validatorStakeRank, err)
+		ch <- prometheus.NewInvalidMetric(c.validatorStakePercentile, err)
+		return
+	}
+
+	c.emitStakeShare(ch, watched, totalActivatedStake(append(all.Result.Current, all.Result.Delinquent...)))
+
+	current := all.Result.Current
+	if len(current) == 0 {
+		return
+	}
+
+	ranks := rankBy(current, func(a rpc.VoteAccount) int64 { return int64(a.ActivatedStake) })
+	for _, account := range watched {
+		rank, ok := ranks[account.VotePubkey]
+		if !ok {
+			continue
+		}
+
+		ch <- prometheus.MustNewConstMetric(c.validatorStakeRank, prometheus.GaugeValue,
+			float64(rank), account.VotePubkey, account.NodePubkey)
+		ch <- prometheus.MustNewConstMetric(c.validatorStakePercentile, prometheus.GaugeValue,
+			float64(len(current)-rank+1)/float64(len(current))*100.0, account.VotePubkey, account.NodePubkey)
+	}
+}
+
+// totalActivatedStake sums the activated stake of accounts.
+func totalActivatedStake(accounts []rpc.VoteAccount) int64 {
+	var total int64
+	for _, account := range accounts {
+		total += int64(account.ActivatedStake)
+	}
+
+	return total
+}
+
+// emitStakeShare emits the share of total activated stake held by each of accounts. Nothing is emitted if
+// total is zero.
+func (c *solanaCollector) emitStakeShare(ch chan<- prometheus.Metric, accounts []rpc.VoteAccount, total int64) {
+	if total == 0 {
+		return
+	}
+
+	for _, account := range accounts {
+		ch <- prometheus.MustNewConstMetric(c.validatorStakeShare, prometheus.GaugeValue,
+			float64(account.ActivatedStake)/float64(total), account.VotePubkey, account.NodePubkey)
+	}
+}
+
+// collectIsValidator emits whether identity has a vote account, current or delinquent.
+func (c *solanaCollector) collectIsValidator(ch chan<- prometheus.Metric, identity string, set *voteAccountSet) {
+	all, err := set.get()
+	if err != nil {
+		klog.Errorf("failed to get vote accounts to look up node identity: %v", err)
+		ch <- prometheus.NewInvalidMetric(c.nodeIsValidator, err)
+		return
+	}
+
+	var isValidator float64
+	for _, account := range append(all.Result.Current, all.Result.Delinquent...) {
+		if account.NodePubkey == identity {
+			isValidator = 1
+			break
+		}
+	}
+
+	ch <- prometheus.MustNewConstMetric(c.nodeIsValidator, prometheus.GaugeValue, isValidator, identity)
+}
+
+// delinquentStakePercent returns the share of the total activated stake held by delinquent validators, in
+// percent. It reports false if there is no activated stake at all.
+func delinquentStakePercent(current, delinquent []rpc.VoteAccount) (float64, bool) {
+	delinquentStake := totalActivatedStake(delinquent)
+	total := totalActivatedStake(current) + delinquentStake
+	if total == 0 {
+		return 0, false
+	}
+
+	return float64(delinquentStake) / float64(total) * 100.0, true
+}
+
+// collectDelinquentStake emits the share of the cluster's activated stake that is delinquent.
+func (c *solanaCollector) collectDelinquentStake(ch chan<- prometheus.Metric, set *voteAccountSet) {
+	all, err := set.get()
+	if err != nil {
+		klog.Errorf("failed to get vote accounts for delinquent stake: %v", err)
+		ch <- prometheus.NewInvalidMetric(c.clusterDelinquentStake, err)
+		return
+	}
+
+	if percent, ok := delinquentStakePercent(all.Result.Current, all.Result.Delinquent); ok {
+		ch <- prometheus.MustNewConstMetric(c.clusterDelinquentStake, prometheus.GaugeValue, percent)
+	}
+}
+
+// creditsRanks is the epoch credits rank of each watched validator on the previous scrape.
+type creditsRanks struct {
+	epoch int64
+	ranks map[string]int
+}
+
+// collectCreditsRank emits how far each watched validator moved in the ranking of all current validators
+// by epoch credits since the previous scrape, positive if it climbed. The ranking starts over with every
+// epoch, so nothing is emitted on the first scrape of an epoch. Only the watched validators' ranks are kept
+// between scrapes.
+func (c *solanaCollector) collectCreditsRank(ch chan<- prometheus.Metric, watched []rpc.VoteAccount, set *voteAccountSet,
+	epoch *rpc.EpochInfo) {
+	all, err := set.get()
+	if err != nil {
+		klog.Errorf("failed to get vote accounts for credits ranking: %v", err)
+		ch <- prometheus.NewInvalidMetric(c.validatorCreditsRankDelta, err)
+		return
+	}
+
+	ranks := rankBy(all.Result.Current, func(a rpc.VoteAccount) int64 {
+		return int64(c.calcEpochCredits(a.EpochCredits))
+	})
+
+	var currentEpoch int64 = -1
+	if epoch != nil {
+		currentEpoch = epoch.Epoch
+	}
+
+	c.creditsRanksMu.Lock()
+	defer c.creditsRanksMu.Unlock()
+
+	previous := c.creditsRanks
+	c.creditsRanks = creditsRanks{epoch: currentEpoch, ranks: make(map[string]int, len(watched))}
+
+	for _, account := range watched {
+		rank, ok := ranks[account.VotePubkey]
+		if !ok {
+			continue
+		}
+		c.creditsRanks.ranks[account.VotePubkey] = rank
+
+		last, ok := previous.ranks[account.VotePubkey]
+		if !ok || previous.epoch != currentEpoch {
+			continue
+		}
+
+		ch <- prometheus.MustNewConstMetric(c.validatorCreditsRankDelta, prometheus.GaugeValue,
+			float64(last-rank), account.VotePubkey, account.NodePubkey)
+	}
+}
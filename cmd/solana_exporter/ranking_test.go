@@ -0,0 +1,199 @@
+package main
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/certusone/solana_exporter/pkg/rpc"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestRankBy(t *testing.T) {
+	accounts := []rpc.VoteAccount{
+		{VotePubkey: "a", ActivatedStake: 10},
+		{VotePubkey: "b", ActivatedStake: 30},
+		{VotePubkey: "c", ActivatedStake: 20},
+		// Ties keep the order of the response.
+		{VotePubkey: "d", ActivatedStake: 20},
+	}
+
+	got := rankBy(accounts, func(a rpc.VoteAccount) int64 { return int64(a.ActivatedStake) })
+	want := map[string]int{"b": 1, "c": 2, "d": 3, "a": 4}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("rankBy() = %v, want %v", got, want)
+	}
+}
+
+func TestStakeRank(t *testing.T) {
+	defer func(v string) { *votePubkey = v }(*votePubkey)
+	*votePubkey = "vote1"
+
+	var current []map[string]interface{}
+	for pubkey, stake := range map[string]int{"vote1": 5000, "vote2": 9000, "vote3": 1000} {
+		account := voteAccount(pubkey, "node"+pubkey[len("vote"):], 5)
+		account["activatedStake"] = stake
+		current = append(current, account)
+	}
+	node := newFakeNode(t)
+	node.set("getVoteAccounts", map[string]interface{}{
+		"current":    current,
+		"delinquent": []map[string]interface{}{},
+	})
+
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(NewSolanaCollector(node.URL, rpc.CommitmentProcessed))
+	families, _ := registry.Gather()
+
+	labels := map[string]string{"pubkey": "vote1"}
+	if got := metricValue(families, "solana_validator_stake_rank", labels); got != 2 {
+		t.Errorf("solana_validator_stake_rank = %v, want 2", got)
+	}
+	got := metricValue(families, "solana_validator_stake_percentile", labels)
+	if want := 200.0 / 3; math.Abs(got-want) > 1e-9 {
+		t.Errorf("solana_validator_stake_percentile = %v, want %v", got, want)
+	}
+}
+
+func TestStakeShare(t *testing.T) {
+	tests := []struct {
+		votePubkey string
+		want       map[string]float64
+	}{
+		{votePubkey: "", want: map[string]float64{"vote1": 5000.0 / 6000, "vote2": 1000.0 / 6000}},
+		// Only watched validators are emitted, with their share of the whole cluster's stake.
+		{votePubkey: "vote1", want: map[string]float64{"vote1": 5000.0 / 6000, "vote2": -1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.votePubkey, func(t *testing.T) {
+			defer func(v string) { *votePubkey = v }(*votePubkey)
+			*votePubkey = tt.votePubkey
+
+			node := newFakeNode(t)
+			registry := prometheus.NewRegistry()
+			registry.MustRegister(NewSolanaCollector(node.URL, rpc.CommitmentProcessed))
+			families, _ := registry.Gather()
+
+			for pubkey, want := range tt.want {
+				got := metricValue(families, "solana_validator_stake_share", map[string]string{"pubkey": pubkey})
+				if got != want {
+					t.Errorf("solana_validator_stake_share{pubkey=%q} = %v, want %v", pubkey, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestNodeIsValidator(t *testing.T) {
+	tests := []struct {
+		name       string
+		identity   string
+		votePubkey string
+		noVoting   bool
+		want       float64
+		wantCalls  int
+	}{
+		{name: "validator", identity: "node1", want: 1, wantCalls: 1},
+		{name: "rpc node", identity: "node3", want: 0, wantCalls: 1},
+		{name: "no voting", identity: "node1", noVoting: true, want: 1, wantCalls: 1},
+		// The unfiltered set is fetched once and shared with the stake ranking.
+		{name: "watched", identity: "node1", votePubkey: "vote1", want: 1, wantCalls: 2},
+		// Several watched validators are picked out of the unfiltered set, which is reused.
+		{name: "several watched", identity: "node1", votePubkey: "vote1,vote2", want: 1, wantCalls: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func(v string) { *votePubkey = v }(*votePubkey)
+			defer func(v bool) { *noVoting = v }(*noVoting)
+			*votePubkey, *noVoting = tt.votePubkey, tt.noVoting
+
+			node := newFakeNode(t)
+			node.set("getIdentity", map[string]interface{}{"identity": tt.identity})
+			registry := prometheus.NewRegistry()
+			registry.MustRegister(NewSolanaCollector(node.URL, rpc.CommitmentProcessed))
+			families, _ := registry.Gather()
+
+			got := metricValue(families, "solana_node_is_validator", map[string]string{"nodekey": tt.identity})
+			if got != tt.want {
+				t.Errorf("solana_node_is_validator = %v, want %v", got, tt.want)
+			}
+			if calls := node.callCount("getVoteAccounts"); calls != tt.wantCalls {
+				t.Errorf("getVoteAccounts called %d times, want %d", calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestDelinquentStakePercent(t *testing.T) {
+	current := []rpc.VoteAccount{{ActivatedStake: 600}, {ActivatedStake: 150}}
+	delinquent := []rpc.VoteAccount{{ActivatedStake: 250}}
+
+	if got, ok := delinquentStakePercent(current, delinquent); !ok || got != 25 {
+		t.Errorf("delinquentStakePercent() = %v, %v, want 25, true", got, ok)
+	}
+	if got, ok := delinquentStakePercent(current, nil); !ok || got != 0 {
+		t.Errorf("delinquentStakePercent() without delinquents = %v, %v, want 0, true", got, ok)
+	}
+	if _, ok := delinquentStakePercent([]rpc.VoteAccount{{}}, nil); ok {
+		t.Error("delinquentStakePercent() reported a share without any stake")
+	}
+}
+
+func TestClusterDelinquentStake(t *testing.T) {
+	// The share covers the whole cluster, also when only some validators are watched.
+	for _, pubkey := range []string{"", "vote1"} {
+		t.Run(pubkey, func(t *testing.T) {
+			defer func(v string) { *votePubkey = v }(*votePubkey)
+			*votePubkey = pubkey
+
+			node := newFakeNode(t)
+			registry := prometheus.NewRegistry()
+			registry.MustRegister(NewSolanaCollector(node.URL, rpc.CommitmentProcessed))
+			families, _ := registry.Gather()
+
+			want := float64(1000) / float64(6000) * 100.0
+			if got := metricValue(families, "solana_cluster_delinquent_stake_percent", nil); got != want {
+				t.Errorf("solana_cluster_delinquent_stake_percent = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestCreditsRankDelta(t *testing.T) {
+	c := NewSolanaCollector("http://localhost:8899", rpc.CommitmentProcessed)
+
+	scrape := func(epoch int64, credits map[string]int) map[string]float64 {
+		set := &voteAccountSet{fetched: true, resp: &rpc.GetVoteAccountsResponse{}}
+		for _, pubkey := range []string{"a", "b", "c"} {
+			set.resp.Result.Current = append(set.resp.Result.Current, rpc.VoteAccount{
+				VotePubkey: pubkey, NodePubkey: "node-" + pubkey, EpochCredits: [][3]int{{int(epoch), credits[pubkey], 0}},
+			})
+		}
+		watched := []rpc.VoteAccount{set.resp.Result.Current[1]}
+
+		return emitted(t, func(ch chan<- prometheus.Metric) {
+			c.collectCreditsRank(ch, watched, set, &rpc.EpochInfo{Epoch: epoch})
+		})
+	}
+
+	const key = `solana_validator_credits_rank_delta{nodekey="node-b",pubkey="b"}`
+
+	// Nothing to compare with on the first scrape.
+	if got := scrape(5, map[string]int{"a": 100, "b": 50, "c": 80}); len(got) != 0 {
+		t.Errorf("first scrape emitted %v", got)
+	}
+	// b climbs from third to first place.
+	if got := scrape(5, map[string]int{"a": 150, "b": 200, "c": 90}); got[key] != 2 {
+		t.Errorf("after climbing: %s = %v, want 2", key, got[key])
+	}
+	// and falls back to second.
+	if got := scrape(5, map[string]int{"a": 250, "b": 210, "c": 100}); got[key] != -1 {
+		t.Errorf("after falling: %s = %v, want -1", key, got[key])
+	}
+	// The ranking starts over in a new epoch.
+	if got := scrape(6, map[string]int{"a": 10, "b": 0, "c": 5}); len(got) != 0 {
+		t.Errorf("first scrape of an epoch emitted %v", got)
+	}
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/klog/v2"
+)
+
+const (
+	warmupRetryInterval = 5 * time.Second
+)
+
+// warmup performs the initial fetch against the RPC node in the background and marks the collector
+// as ready once it succeeded, retrying until then. With -background-polling, it then keeps polling until
+// ctx is canceled.
+func (c *solanaCollector) warmup(ctx context.Context) {
+	for {
+		if err := c.initialFetch(); err != nil {
+			klog.Infof("initial fetch failed, retrying in %v: %v", warmupRetryInterval, err)
+			select {
+			case <-time.After(warmupRetryInterval):
+				continue
+			case <-ctx.Done():
+				return
+			}
+		}
+
+		atomic.StoreInt32(&c.ready, 1)
+		klog.Info("initial fetch succeeded, exporter is ready")
+		break
+	}
+
+	// The initial fetch was the first poll.
+	if *backgroundPolling {
+		c.pollLoop(ctx, *pollInterval)
+	}
+}
+
+// initialFetch checks that the node answers and then runs a first collection, so that the first scrape
+// doesn't start cold. With -background-polling, that collection is the first poll scrapes are served from.
+// Otherwise it fills the caches of the collectors, like the inflation rewards, and the state that changes
+// are detected against, like the vote account authorities and the credit samples.
+func (c *solanaCollector) initialFetch() error {
+	ctx, cancel := context.WithTimeout(context.Background(), httpTimeout)
+	defer cancel()
+
+	if _, err := c.rpcClient.GetEpochInfo(ctx, c.commitment); err != nil {
+		return err
+	}
+
+	if _, err := c.rpcClient.GetVersion(ctx); err != nil {
+		return err
+	}
+
+	if *backgroundPolling {
+		c.poll()
+		return nil
+	}
+
+	metrics := make(chan prometheus.Metric)
+	go func() {
+		for range metrics {
+		}
+	}()
+	c.collectNow(metrics)
+	close(metrics)
+
+	return nil
+}
+
+// selfTest checks that the RPC endpoint is reachable before the exporter starts serving. An unhealthy
+// node is only logged, as it is expected to recover on its own.
+func (c *solanaCollector) selfTest() error {
+	ctx, cancel := context.WithTimeout(context.Background(), httpTimeout)
+	defer cancel()
+
+	version, err := c.rpcClient.GetVersion(ctx)
+	if err != nil {
+		return fmt.Errorf("RPC endpoint is not reachable: %w", err)
+	}
+
+	healthy, err := c.rpcClient.GetHealth(ctx)
+	if err != nil {
+		klog.Warningf("RPC node running %s is not healthy: %v", *version, err)
+		return nil
+	}
+	if !healthy {
+		klog.Warningf("RPC node running %s is not healthy", *version)
+		return nil
+	}
+
+	klog.Infof("RPC node running %s is reachable and healthy", *version)
+	return nil
+}
+
+func (c *solanaCollector) isReady() bool {
+	return atomic.LoadInt32(&c.ready) == 1
+}
+
+// readyzHandler returns 503 until the initial fetch completed.
+func (c *solanaCollector) readyzHandler(w http.ResponseWriter, _ *http.Request) {
+	if !c.isReady() {
+		http.Error(w, "not ready", http.StatusServiceUnavailable)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write([]byte("ok\n"))
+}
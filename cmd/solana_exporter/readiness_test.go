@@ -0,0 +1,124 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/certusone/solana_exporter/pkg/rpc"
+)
+
+func readyzCode(c *solanaCollector) int {
+	rec := httptest.NewRecorder()
+	c.readyzHandler(rec, httptest.NewRequest("GET", "/readyz", nil))
+	return rec.Code
+}
+
+func TestReadinessTransition(t *testing.T) {
+	node := newFakeNode(t)
+	node.setDown(true)
+	c := NewSolanaCollector(node.URL, rpc.CommitmentProcessed)
+
+	if got := readyzCode(c); got != http.StatusServiceUnavailable {
+		t.Fatalf("before the initial fetch: /readyz = %d, want %d", got, http.StatusServiceUnavailable)
+	}
+
+	if err := c.initialFetch(); err == nil {
+		t.Fatal("initial fetch succeeded against an unavailable node")
+	}
+	if got := readyzCode(c); got != http.StatusServiceUnavailable {
+		t.Fatalf("after a failed initial fetch: /readyz = %d, want %d", got, http.StatusServiceUnavailable)
+	}
+
+	node.setDown(false)
+	c.warmup(context.Background())
+	if got := readyzCode(c); got != http.StatusOK {
+		t.Fatalf("after the initial fetch: /readyz = %d, want %d", got, http.StatusOK)
+	}
+}
+
+func TestSelfTest(t *testing.T) {
+	node := newFakeNode(t)
+	c := NewSolanaCollector(node.URL, rpc.CommitmentProcessed)
+	if err := c.selfTest(); err != nil {
+		t.Errorf("selfTest() against a healthy node = %v", err)
+	}
+
+	// An unhealthy node is reachable and only logged.
+	delete(node.results, "getHealth")
+	if err := c.selfTest(); err != nil {
+		t.Errorf("selfTest() against an unhealthy node = %v", err)
+	}
+
+	node.setDown(true)
+	if err := c.selfTest(); err == nil {
+		t.Error("selfTest() succeeded against an unreachable node")
+	}
+}
+
+// With -fail-on-startup-error, an unreachable endpoint stops the exporter before it serves anything.
+func TestFailOnStartupError(t *testing.T) {
+	if os.Getenv("SOLANA_EXPORTER_RUN_MAIN") == "1" {
+		os.Args = []string{"solana_exporter", "-rpcURI", os.Getenv("SOLANA_EXPORTER_RPC"), "-fail-on-startup-error"}
+		main()
+		return
+	}
+
+	node := newFakeNode(t)
+	node.setDown(true)
+
+	cmd := exec.Command(os.Args[0], "-test.run=^TestFailOnStartupError$")
+	cmd.Env = append(os.Environ(), "SOLANA_EXPORTER_RUN_MAIN=1", "SOLANA_EXPORTER_RPC="+node.URL)
+	out, err := cmd.CombinedOutput()
+	if _, ok := err.(*exec.ExitError); !ok {
+		t.Fatalf("exporter with an unreachable endpoint didn't exit with an error: %v", err)
+	}
+	if !strings.Contains(string(out), "RPC endpoint is not reachable") {
+		t.Errorf("output %q doesn't report the unreachable endpoint", out)
+	}
+}
+
+func TestInitialFetchWarmsCaches(t *testing.T) {
+	tests := []struct {
+		name    string
+		polling bool
+		warm    func(c *solanaCollector, node *fakeNode) bool
+	}{
+		{
+			name: "per-scrape collection",
+			warm: func(c *solanaCollector, node *fakeNode) bool {
+				// The full collection ran, not just the connectivity check.
+				return node.callCount("getVoteAccounts") > 0 && node.callCount("getBlockProduction") > 0 &&
+					atomic.LoadUint64(&c.lastScrapeCalls) > 2
+			},
+		},
+		{
+			name:    "background polling",
+			polling: true,
+			warm: func(c *solanaCollector, _ *fakeNode) bool {
+				return len(c.polled) > 0 && !c.lastPollTime().IsZero()
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func(v bool) { *backgroundPolling = v }(*backgroundPolling)
+			*backgroundPolling = tt.polling
+
+			node := newFakeNode(t)
+			c := NewSolanaCollector(node.URL, rpc.CommitmentProcessed)
+			if err := c.initialFetch(); err != nil {
+				t.Fatal(err)
+			}
+			if !tt.warm(c, node) {
+				t.Error("initial fetch didn't warm the collector")
+			}
+		})
+	}
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"context"
+	"flag"
+
+	"github.com/certusone/solana_exporter/pkg/rpc"
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/klog/v2"
+)
+
+var referenceRPCAddr = flag.String("reference-rpcURI", "",
+	"RPC URI of a node in sync with the cluster to compare the node's slot against, disabled if empty")
+
+// collectReferenceSlotLag emits how many slots the node's confirmed slot is behind that of the reference node.
+// Unlike getHealth, this keeps working while the node is far behind or hasn't caught up since a restart, as long
+// as it answers getSlot. It is briefly negative when the node happens to be ahead of the reference. If the
+// node's slot failed with confirmedErr or the reference doesn't answer, the lag reported by the node's health, if
+// any, is emitted instead.
+func (c *solanaCollector) collectReferenceSlotLag(ctx context.Context, ch chan<- prometheus.Metric, confirmed int64,
+	confirmedErr error, health *rpc.Health) {
+	err := confirmedErr
+	if err == nil {
+		var reference int64
+		reference, err = c.referenceClient.GetSlot(ctx, rpc.CommitmentConfirmed)
+		if err == nil {
+			ch <- prometheus.MustNewConstMetric(c.nodeReferenceSlotLag, prometheus.GaugeValue,
+				float64(reference-confirmed))
+			return
+		}
+		klog.Errorf("failed to get confirmed slot of the reference node: %v", err)
+	}
+
+	switch {
+	case health != nil && health.Healthy:
+		ch <- prometheus.MustNewConstMetric(c.nodeReferenceSlotLag, prometheus.GaugeValue, 0)
+	case health != nil && health.NumSlotsBehind != nil:
+		ch <- prometheus.MustNewConstMetric(c.nodeReferenceSlotLag, prometheus.GaugeValue,
+			float64(*health.NumSlotsBehind))
+	default:
+		ch <- prometheus.NewInvalidMetric(c.nodeReferenceSlotLag, err)
+	}
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/certusone/solana_exporter/pkg/rpc"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+func TestReferenceSlotLagScrapes(t *testing.T) {
+	node := newFakeNode(t)
+	reference := newFakeNode(t)
+	reference.set("getSlot", 1000)
+
+	c := NewSolanaCollector(node.URL, rpc.CommitmentProcessed)
+	c.referenceClient = rpc.NewRPCClient(reference.URL)
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(c)
+
+	families, _ := registry.Gather()
+	if got := metricValue(families, "solana_node_reference_slot_lag", nil); got != 10 {
+		t.Errorf("solana_node_reference_slot_lag = %v, want 10", got)
+	}
+
+	// The node may be slightly ahead of the reference.
+	reference.set("getSlot", 985)
+	families, _ = registry.Gather()
+	if got := metricValue(families, "solana_node_reference_slot_lag", nil); got != -5 {
+		t.Errorf("solana_node_reference_slot_lag = %v with the node ahead, want -5", got)
+	}
+
+	// Without the reference node, the lag falls back to what getHealth reports, nothing for a healthy node.
+	reference.setDown(true)
+	families, err := registry.Gather()
+	if err != nil {
+		t.Errorf("Gather() = %v without the reference node, want the getHealth fallback", err)
+	}
+	if got := metricValue(families, "solana_node_reference_slot_lag", nil); got != 0 {
+		t.Errorf("solana_node_reference_slot_lag = %v without the reference node, want 0 from getHealth", got)
+	}
+	if got := metricValue(families, "solana_epoch_slot_index", nil); got != 100 {
+		t.Errorf("solana_epoch_slot_index = %v, want the node's own metrics to be unaffected", got)
+	}
+}
+
+func TestReferenceSlotLag(t *testing.T) {
+	behind := int64(42)
+
+	tests := []struct {
+		name          string
+		confirmedErr  error
+		referenceDown bool
+		health        *rpc.Health
+		want          float64
+		wantErr       bool
+	}{
+		{name: "both slots", health: &rpc.Health{Healthy: true}, want: 10},
+		// The reference slot takes precedence over what getHealth reports.
+		{name: "both slots of an unhealthy node", health: &rpc.Health{NumSlotsBehind: &behind}, want: 10},
+		{name: "reference down", referenceDown: true, health: &rpc.Health{NumSlotsBehind: &behind}, want: 42},
+		{name: "reference down, healthy node", referenceDown: true, health: &rpc.Health{Healthy: true}, want: 0},
+		{name: "local slot failed", confirmedErr: errors.New("timeout"), health: &rpc.Health{NumSlotsBehind: &behind},
+			want: 42},
+		{name: "unhealthy node without a count", confirmedErr: errors.New("timeout"), health: &rpc.Health{},
+			wantErr: true},
+		{name: "getHealth failed", referenceDown: true, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reference := newFakeNode(t)
+			reference.set("getSlot", 1000)
+			reference.setDown(tt.referenceDown)
+
+			c := NewSolanaCollector(newFakeNode(t).URL, rpc.CommitmentProcessed)
+			c.referenceClient = rpc.NewRPCClient(reference.URL, rpc.WithRetries(0))
+
+			ch := make(chan prometheus.Metric, 1)
+			c.collectReferenceSlotLag(context.Background(), ch, 990, tt.confirmedErr, tt.health)
+			close(ch)
+
+			var m dto.Metric
+			err := (<-ch).Write(&m)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("metric error = %v, want error %v", err, tt.wantErr)
+			}
+			if err == nil && m.GetGauge().GetValue() != tt.want {
+				t.Errorf("solana_node_reference_slot_lag = %v, want %v", m.GetGauge().GetValue(), tt.want)
+			}
+		})
+	}
+}
+
+// The lag is still exported when the node's own slot can't be fetched.
+
+func TestReferenceSlotLagWithoutLocalSlot(t *testing.T) {
+	node := newFakeNode(t)
+	node.set("getSlot", "not a slot")
+	reference := newFakeNode(t)
+	reference.set("getSlot", 1000)
+
+	c := NewSolanaCollector(node.URL, rpc.CommitmentProcessed)
+	c.referenceClient = rpc.NewRPCClient(reference.URL, rpc.WithRetries(0))
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(c)
+
+	// The failed slot fails the scrape, the families gathered anyway are what matters.
+	families, _ := registry.Gather()
+	if got := metricValue(families, "solana_node_reference_slot_lag", nil); got != 0 {
+		t.Errorf("solana_node_reference_slot_lag = %v, want 0 from getHealth", got)
+	}
+	if n := reference.callCount("getSlot"); n != 0 {
+		t.Errorf("reference getSlot was called %d times without a local slot to compare", n)
+	}
+}
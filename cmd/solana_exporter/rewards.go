@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"strconv"
+	"time"
+
+	"github.com/certusone/solana_exporter/pkg/rpc"
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/klog/v2"
+)
+
+// How long missing reward entries are cached before the rewards are fetched again. Rewards are paid out over
+// the first blocks of an epoch, so an account due a reward may have none for a while after the rollover.
+const missingRewardTTL = 5 * time.Minute
+
+var timestampCached = flag.Bool("timestamp-cached", false,
+	"Export cached values with the time they were fetched instead of the scrape time")
+
+// cachedMetric attaches the time a cached value was fetched to m if -timestamp-cached is set. fetchedAt is
+// zero for values fetched during the current scrape, which keep the scrape time.
+func cachedMetric(m prometheus.Metric, fetchedAt time.Time) prometheus.Metric {
+	if !*timestampCached || fetchedAt.IsZero() {
+		return m
+	}
+
+	return prometheus.NewMetricWithTimestamp(fetchedAt, m)
+}
+
+// inflationRewards fetches the inflation rewards of pubkeys for the given epoch. Rewards only change once per
+// epoch, so they are cached until the epoch or the set of pubkeys changes, or for missingRewardTTL if any
+// of them has no reward yet. If the rewards come from the cache, it also returns when they were fetched.
+func (c *solanaCollector) inflationRewards(ctx context.Context, pubkeys []string,
+	epoch int64) (map[string]*rpc.InflationReward, time.Time, error) {
+	c.rewardsMu.Lock()
+	defer c.rewardsMu.Unlock()
+
+	if c.rewardsEpoch == epoch {
+		cached := true
+		for _, pubkey := range pubkeys {
+			reward, ok := c.rewards[pubkey]
+			if !ok || (reward == nil && time.Since(c.rewardsFetchedAt) >= missingRewardTTL) {
+				cached = false
+				break
+			}
+		}
+		if cached {
+			return c.rewards, c.rewardsFetchedAt, nil
+		}
+	}
+
+	rewards, err := c.rpcClient.GetInflationReward(ctx, pubkeys, epoch)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+
+	c.rewardsEpoch = epoch
+	c.rewardsFetchedAt = time.Now()
+	c.rewards = make(map[string]*rpc.InflationReward, len(pubkeys))
+	for i, pubkey := range pubkeys {
+		c.rewards[pubkey] = rewards[i]
+	}
+
+	return c.rewards, time.Time{}, nil
+}
+
+// collectInflationRewards emits the inflation rewards the watched vote accounts received for the previous
+// epoch. Accounts without a reward entry, e.g. because they weren't staked, are left out.
+func (c *solanaCollector) collectInflationRewards(ctx context.Context, ch chan<- prometheus.Metric, epoch *rpc.EpochInfo,
+	watched []string) {
+	if epoch == nil || epoch.Epoch == 0 {
+		return
+	}
+
+	rewards, fetchedAt, err := c.inflationRewards(ctx, watched, epoch.Epoch-1)
+	if err != nil {
+		klog.Errorf("failed to get inflation rewards: %v", err)
+		ch <- prometheus.NewInvalidMetric(c.inflationReward, err)
+		ch <- prometheus.NewInvalidMetric(c.rewardCommission, err)
+		ch <- prometheus.NewInvalidMetric(c.rewardPostBalance, err)
+		ch <- prometheus.NewInvalidMetric(c.epochReward, err)
+		ch <- prometheus.NewInvalidMetric(c.epochRewardPostBalance, err)
+		return
+	}
+
+	for _, pubkey := range watched {
+		reward := rewards[pubkey]
+		if reward == nil {
+			continue
+		}
+
+		rewardEpoch := strconv.FormatInt(reward.Epoch, 10)
+		// solana_validator_inflation_reward and its post balance are deprecated aliases of the epoch reward series,
+		// exported until dashboards have moved over.
+		ch <- cachedMetric(prometheus.MustNewConstMetric(c.inflationReward, prometheus.GaugeValue,
+			float64(reward.Amount), pubkey, rewardEpoch), fetchedAt)
+		ch <- cachedMetric(prometheus.MustNewConstMetric(c.rewardPostBalance, prometheus.GaugeValue,
+			float64(reward.PostBalance), pubkey, rewardEpoch), fetchedAt)
+		ch <- cachedMetric(prometheus.MustNewConstMetric(c.epochReward, prometheus.GaugeValue,
+			float64(reward.Amount), pubkey, rewardEpoch), fetchedAt)
+		ch <- cachedMetric(prometheus.MustNewConstMetric(c.epochRewardPostBalance, prometheus.GaugeValue,
+			float64(reward.PostBalance), pubkey, rewardEpoch), fetchedAt)
+		if reward.Commission != nil {
+			ch <- cachedMetric(prometheus.MustNewConstMetric(c.rewardCommission, prometheus.GaugeValue,
+				commissionValue(*reward.Commission), pubkey, rewardEpoch), fetchedAt)
+		}
+	}
+}
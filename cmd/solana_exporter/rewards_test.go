@@ -0,0 +1,177 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/certusone/solana_exporter/pkg/rpc"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+func TestCollectInflationRewards(t *testing.T) {
+	defer func(v string) { *votePubkey = v }(*votePubkey)
+	*votePubkey = "vote1"
+
+	node := newFakeNode(t)
+	node.set("getInflationReward", []map[string]interface{}{
+		{"epoch": 4, "effectiveSlot": 432000, "amount": 2500, "postBalance": 1002500, "commission": 5},
+	})
+	c := NewSolanaCollector(node.URL, rpc.CommitmentProcessed)
+	epoch := &rpc.EpochInfo{Epoch: 5}
+
+	for scrape := 1; scrape <= 2; scrape++ {
+		got := emitted(t, func(ch chan<- prometheus.Metric) {
+			c.collectInflationRewards(context.Background(), ch, epoch, loadRuntimeConfig().watched)
+		})
+
+		want := map[string]float64{
+			`solana_validator_inflation_reward{epoch="4",pubkey="vote1"}`:              2500,
+			`solana_validator_inflation_reward_commission{epoch="4",pubkey="vote1"}`:   5,
+			`solana_validator_inflation_reward_post_balance{epoch="4",pubkey="vote1"}`: 1002500,
+		}
+		for key, value := range want {
+			if got[key] != value {
+				t.Errorf("scrape %d: %s = %v, want %v", scrape, key, got[key], value)
+			}
+		}
+	}
+
+	// The rewards of an epoch don't change, so they are only fetched once.
+	if calls := node.callCount("getInflationReward"); calls != 1 {
+		t.Errorf("getInflationReward called %d times, want 1", calls)
+	}
+}
+
+func TestCollectInflationRewardsWithoutReward(t *testing.T) {
+	defer func(v string) { *votePubkey = v }(*votePubkey)
+	*votePubkey = "vote1"
+
+	node := newFakeNode(t)
+	node.set("getInflationReward", []interface{}{nil})
+	c := NewSolanaCollector(node.URL, rpc.CommitmentProcessed)
+
+	collect := func() map[string]float64 {
+		return emitted(t, func(ch chan<- prometheus.Metric) {
+			c.collectInflationRewards(context.Background(), ch, &rpc.EpochInfo{Epoch: 5}, loadRuntimeConfig().watched)
+		})
+	}
+	if got := collect(); len(got) != 0 {
+		t.Errorf("emitted %v for an account without a reward, want nothing", got)
+	}
+
+	// The reward is paid out later in the epoch. A missing entry is kept for a while only.
+	node.set("getInflationReward", []map[string]interface{}{
+		{"epoch": 4, "effectiveSlot": 432000, "amount": 2500, "postBalance": 1002500},
+	})
+	if got := collect(); len(got) != 0 || node.callCount("getInflationReward") != 1 {
+		t.Errorf("emitted %v right after the missing reward was fetched, want it cached", got)
+	}
+
+	c.rewardsFetchedAt = c.rewardsFetchedAt.Add(-missingRewardTTL)
+	key := `solana_validator_inflation_reward{epoch="4",pubkey="vote1"}`
+	if got := collect(); got[key] != 2500 {
+		t.Errorf("%s = %v once the missing reward expired, want 2500", key, got[key])
+	}
+	if n := node.callCount("getInflationReward"); n != 2 {
+		t.Errorf("getInflationReward called %d times, want 2", n)
+	}
+}
+
+func TestTimestampCached(t *testing.T) {
+	for _, enabled := range []bool{false, true} {
+		t.Run(fmt.Sprint(enabled), func(t *testing.T) {
+			defer func(v string) { *votePubkey = v }(*votePubkey)
+			defer func(v bool) { *timestampCached = v }(*timestampCached)
+			*votePubkey, *timestampCached = "vote1", enabled
+
+			node := newFakeNode(t)
+			node.set("getInflationReward", []map[string]interface{}{
+				{"epoch": 4, "effectiveSlot": 432000, "amount": 2500, "postBalance": 1002500},
+			})
+			c := NewSolanaCollector(node.URL, rpc.CommitmentProcessed)
+
+			for scrape := 1; scrape <= 2; scrape++ {
+				before := time.Now()
+				ch := make(chan prometheus.Metric, 16)
+				c.collectInflationRewards(context.Background(), ch, &rpc.EpochInfo{Epoch: 5}, loadRuntimeConfig().watched)
+				close(ch)
+
+				for m := range ch {
+					var pb dto.Metric
+					if err := m.Write(&pb); err != nil {
+						t.Fatal(err)
+					}
+
+					// Only values served from the cache carry their fetch time.
+					wantTimestamp := enabled && scrape == 2
+					if (pb.TimestampMs != nil) != wantTimestamp {
+						t.Errorf("scrape %d: %s has timestamp %v, want one %v", scrape, m.Desc(), pb.TimestampMs, wantTimestamp)
+					}
+					if pb.TimestampMs != nil && pb.GetTimestampMs() >= before.UnixNano()/int64(time.Millisecond) {
+						t.Errorf("scrape %d: timestamp %d isn't the earlier fetch time", scrape, pb.GetTimestampMs())
+					}
+				}
+				time.Sleep(5 * time.Millisecond)
+			}
+		})
+	}
+}
+
+func TestEpochRewards(t *testing.T) {
+	defer func(v string) { *votePubkey = v }(*votePubkey)
+	*votePubkey = "vote1,vote2"
+
+	node := newFakeNode(t)
+	// vote2 wasn't staked in the previous epoch and got no reward.
+	node.set("getInflationReward", []interface{}{
+		map[string]interface{}{"epoch": 4, "effectiveSlot": 432000, "amount": 2500, "postBalance": 1002500,
+			"commission": 5},
+		nil,
+	})
+
+	registry := prometheus.NewPedanticRegistry()
+	registry.MustRegister(NewSolanaCollector(node.URL, rpc.CommitmentProcessed))
+
+	for i := 0; i < 2; i++ {
+		// The other calls for watched validators aren't answered by the fake node, only the rewards matter.
+		families, _ := registry.Gather()
+
+		for name, want := range map[string]float64{
+			"solana_validator_epoch_reward_lamports":     2500,
+			"solana_validator_epoch_reward_post_balance": 1002500,
+		} {
+			if got := metricValue(families, name, map[string]string{"pubkey": "vote1", "epoch": "4"}); got != want {
+				t.Errorf("gather %d: %s{pubkey=vote1} = %v, want %v", i, name, got, want)
+			}
+			if got := metricValue(families, name, map[string]string{"pubkey": "vote2"}); got != -1 {
+				t.Errorf("gather %d: %s{pubkey=vote2} = %v, want no series", i, name, got)
+			}
+		}
+	}
+
+	// Rewards only change once per epoch.
+	if n := node.callCount("getInflationReward"); n != 1 {
+		t.Errorf("getInflationReward was called %d times, want once", n)
+	}
+
+	// The older names are deprecated aliases with the same series.
+	families, _ := registry.Gather()
+	for alias, name := range map[string]string{
+		"solana_validator_inflation_reward":              "solana_validator_epoch_reward_lamports",
+		"solana_validator_inflation_reward_post_balance": "solana_validator_epoch_reward_post_balance",
+	} {
+		labels := map[string]string{"pubkey": "vote1", "epoch": "4"}
+		if got, want := metricValue(families, alias, labels), metricValue(families, name, labels); got != want {
+			t.Errorf("%s = %v, want the value of %s, %v", alias, got, name, want)
+		}
+		for _, family := range families {
+			if family.GetName() == alias && !strings.HasPrefix(family.GetHelp(), "Deprecated") {
+				t.Errorf("help of %s = %q, want it deprecated", alias, family.GetHelp())
+			}
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// headerList collects the values of a repeatable "Name: value" flag.
+type headerList []string
+
+func (l *headerList) String() string {
+	return strings.Join(*l, ", ")
+}
+
+func (l *headerList) Set(value string) error {
+	if _, _, err := parseHeader(value); err != nil {
+		return err
+	}
+	*l = append(*l, value)
+	return nil
+}
+
+var (
+	rpcHeaderList  headerList
+	rpcBearerToken = flag.String("rpc-bearer-token", "",
+		"Bearer token sent with every RPC request, prefer -rpc-token-file to keep it out of the process list")
+)
+
+func init() {
+	flag.Var(&rpcHeaderList, "rpc-header",
+		`Header sent with every RPC request as "Name: value", e.g. an API key of the RPC provider (repeatable)`)
+}
+
+func parseHeader(s string) (string, string, error) {
+	i := strings.Index(s, ":")
+	if i < 0 {
+		return "", "", fmt.Errorf("header %q must be given as \"Name: value\"", s)
+	}
+
+	name, value := strings.TrimSpace(s[:i]), strings.TrimSpace(s[i+1:])
+	if name == "" || strings.ContainsAny(name, " \t") {
+		return "", "", fmt.Errorf("invalid header name %q", name)
+	}
+
+	return name, value, nil
+}
+
+// rpcHeaders returns the headers given with -rpc-header and -rpc-bearer-token, sent with every RPC request
+// and the WebSocket handshake. Only one way to set the Authorization header may be used.
+func rpcHeaders() (http.Header, error) {
+	h := make(http.Header)
+	for _, s := range rpcHeaderList {
+		name, value, err := parseHeader(s)
+		if err != nil {
+			return nil, err
+		}
+		h.Add(name, value)
+	}
+
+	authorization := h.Get("Authorization") != ""
+	if *rpcBearerToken != "" {
+		if authorization {
+			return nil, errors.New("-rpc-bearer-token can't be combined with an Authorization -rpc-header")
+		}
+		h.Set("Authorization", "Bearer "+*rpcBearerToken)
+		authorization = true
+	}
+	if authorization && *rpcTokenFile != "" {
+		return nil, errors.New("-rpc-token-file can't be combined with -rpc-bearer-token or an Authorization -rpc-header")
+	}
+
+	return h, nil
+}
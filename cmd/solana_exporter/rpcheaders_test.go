@@ -0,0 +1,75 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRPCHeaders(t *testing.T) {
+	defer func(v headerList) { rpcHeaderList = v }(rpcHeaderList)
+	defer func(v string) { *rpcBearerToken = v }(*rpcBearerToken)
+	defer func(v string) { *rpcTokenFile = v }(*rpcTokenFile)
+
+	tests := []struct {
+		name        string
+		headers     []string
+		bearerToken string
+		tokenFile   string
+		want        map[string]string
+		wantErr     string
+	}{
+		{name: "api key", headers: []string{"x-api-key: key1 ", "X-Tenant:a"},
+			want: map[string]string{"X-Api-Key": "key1", "X-Tenant": "a"}},
+		{name: "bearer token", headers: []string{"x-api-key: key1"}, bearerToken: "token1",
+			want: map[string]string{"X-Api-Key": "key1", "Authorization": "Bearer token1"}},
+		{name: "two authorizations", headers: []string{"Authorization: Basic abc"}, bearerToken: "token1",
+			wantErr: "-rpc-bearer-token"},
+		{name: "token file and header", headers: []string{"Authorization: Basic abc"}, tokenFile: "token.txt",
+			wantErr: "-rpc-token-file"},
+		{name: "token file and api key", headers: []string{"x-api-key: key1"}, tokenFile: "token.txt",
+			want: map[string]string{"X-Api-Key": "key1"}},
+	}
+
+	for _, tt := range tests {
+		rpcHeaderList = nil
+		for _, h := range tt.headers {
+			if err := rpcHeaderList.Set(h); err != nil {
+				t.Fatalf("%s: Set(%q) = %v", tt.name, h, err)
+			}
+		}
+		*rpcBearerToken, *rpcTokenFile = tt.bearerToken, tt.tokenFile
+
+		h, err := rpcHeaders()
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("%s: rpcHeaders() = %v, want an error about %s", tt.name, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if len(h) != len(tt.want) {
+			t.Errorf("%s: got headers %v, want %v", tt.name, h, tt.want)
+		}
+		for name, value := range tt.want {
+			if h.Get(name) != value {
+				t.Errorf("%s: %s = %q, want %q", tt.name, name, h.Get(name), value)
+			}
+		}
+	}
+}
+
+func TestParseHeader(t *testing.T) {
+	for _, s := range []string{"x-api-key", ": value", "X Api Key: value"} {
+		if _, _, err := parseHeader(s); err == nil {
+			t.Errorf("parseHeader(%q) succeeded, want an error", s)
+		}
+	}
+
+	// The value may contain colons itself.
+	if name, value, err := parseHeader("X-Endpoint: http://node:8899"); err != nil || name != "X-Endpoint" ||
+		value != "http://node:8899" {
+		t.Errorf("parseHeader() = %q, %q, %v, want X-Endpoint, http://node:8899", name, value, err)
+	}
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"sync/atomic"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// collectCapped runs collect and forwards its metrics to ch until max series have been emitted. From then
+// on, per-validator series are dropped while aggregates are still passed on.
+func (c *solanaCollector) collectCapped(ch chan<- prometheus.Metric, max int) {
+	metrics := make(chan prometheus.Metric)
+	capped := make(chan bool)
+
+	go func() {
+		var emitted int
+		var dropped bool
+		for m := range metrics {
+			if emitted >= max && c.isPerValidator(m.Desc()) {
+				dropped = true
+				continue
+			}
+			emitted++
+			ch <- m
+		}
+		capped <- dropped
+	}()
+
+	c.collect(metrics)
+	close(metrics)
+
+	if <-capped {
+		atomic.AddUint64(&c.cappedScrapes, 1)
+	}
+}
+
+// isPerValidator reports whether desc describes a series emitted once per validator, whose number grows
+// with the size of the cluster.
+func (c *solanaCollector) isPerValidator(desc *prometheus.Desc) bool {
+	switch desc {
+	case c.validatorActivatedStake, c.validatorLastVote, c.validatorRootSlot, c.validatorDelinquent,
+		c.totalLeaderSlots, c.totalProducedSlots, c.leaderSlotsCounter, c.producedSlotsCounter,
+		c.validatorEpochCredits, c.validatorPctVote, c.validatorTotalCredits, c.validatorAccountBalance,
+		c.validatorCommissionOver, c.validatorCreditEfficiency, c.validatorOwned, c.validatorStakeRank,
+		c.validatorStakePercentile, c.validatorDelinquentFor, c.validatorIdentityInfo, c.validatorStakeShare,
+		c.validatorCreditRate, c.validatorVoteLatency, c.validatorCommission,
+		c.validatorCreditsRankDelta, c.delinquencyTransitions, c.leaderSlotsRemaining,
+		c.skipRateVsCluster, c.projectedEpochRewards, c.validatorSkipRate, c.validatorVoteDistance,
+		c.validatorBalance, c.validatorAuthorityChanged, c.inflationReward, c.rewardCommission,
+		c.rewardPostBalance, c.epochReward, c.epochRewardPostBalance, c.leaderRewardsLamports:
+		return true
+	}
+
+	return false
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/certusone/solana_exporter/pkg/rpc"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestMaxSeries(t *testing.T) {
+	tests := []struct {
+		name        string
+		maxSeries   int
+		wantStake   bool
+		wantCapped1 float64
+		wantCapped2 float64
+	}{
+		{name: "unlimited", maxSeries: 0, wantStake: true},
+		{name: "room for everything", maxSeries: 10000, wantStake: true},
+		{name: "capped", maxSeries: 3, wantCapped1: 1, wantCapped2: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func(v int) { *maxSeries = v }(*maxSeries)
+			*maxSeries = tt.maxSeries
+
+			node := newFakeNode(t)
+			c := NewSolanaCollector(node.URL, rpc.CommitmentProcessed)
+			registry := prometheus.NewRegistry()
+			registry.MustRegister(c)
+			// The counter is served apart from the node metrics, so it is gathered once the scrape is done.
+			self := prometheus.NewRegistry()
+			self.MustRegister(selfCollector{c})
+
+			for scrape, wantCapped := range []float64{tt.wantCapped1, tt.wantCapped2} {
+				families, _ := registry.Gather()
+
+				stake := metricValue(families, "solana_validator_activated_stake", map[string]string{"pubkey": "vote1"})
+				if (stake != -1) != tt.wantStake {
+					t.Errorf("scrape %d: per-validator series emitted %v, want %v", scrape+1, stake != -1, tt.wantStake)
+				}
+				// Aggregates are never dropped.
+				if got := metricValue(families, "solana_active_validators", map[string]string{"state": "current"}); got != 1 {
+					t.Errorf("scrape %d: solana_active_validators = %v, want 1", scrape+1, got)
+				}
+				selfFamilies, _ := self.Gather()
+				if got := metricValue(selfFamilies, "solana_exporter_series_capped_total", nil); got != wantCapped {
+					t.Errorf("scrape %d: solana_exporter_series_capped_total = %v, want %v", scrape+1, got, wantCapped)
+				}
+			}
+		})
+	}
+}
+
+// Every metric labeled with a vote pubkey is capped and served on the validators shard, including those only
+// emitted for watched validators.
+func TestPerValidatorDescs(t *testing.T) {
+	c := NewSolanaCollector("http://localhost:8899", rpc.CommitmentProcessed)
+	descs := make(chan *prometheus.Desc, 256)
+	c.Describe(descs)
+	close(descs)
+
+	labelsRE := regexp.MustCompile(`variableLabels: \[([^\]]*)\]`)
+	for desc := range descs {
+		m := labelsRE.FindStringSubmatch(desc.String())
+		if m == nil {
+			t.Fatalf("no variable labels in %s", desc)
+		}
+		hasPubkey := false
+		for _, label := range strings.Fields(m[1]) {
+			hasPubkey = hasPubkey || label == "pubkey"
+		}
+		// The pubkey of token accounts isn't a validator's.
+		if hasPubkey && desc != c.tokenAccountBalance && !c.isPerValidator(desc) {
+			t.Errorf("%s isn't listed as per-validator", desc)
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"flag"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var shardMetrics = flag.Bool("shard-metrics", false,
+	"Also serve per-validator metrics on /metrics/validators and all other node metrics on /metrics/cluster")
+
+// sharedCollection serves the metrics of one collection of a solanaCollector for interval, so that /metrics
+// and the shards don't each run the collection, which would multiply the load on the node and advance the
+// state kept between scrapes, like credit rates or stuck slots, once per endpoint. Scrapes arriving while a
+// collection runs wait for it and get its result.
+type sharedCollection struct {
+	c        *solanaCollector
+	interval time.Duration
+
+	mu          sync.Mutex
+	metrics     []prometheus.Metric
+	collectedAt time.Time
+}
+
+func newSharedCollection(c *solanaCollector, interval time.Duration) *sharedCollection {
+	return &sharedCollection{c: c, interval: interval}
+}
+
+func (s *sharedCollection) Describe(ch chan<- *prometheus.Desc) {
+	s.c.Describe(ch)
+}
+
+func (s *sharedCollection) Collect(ch chan<- prometheus.Metric) {
+	for _, m := range s.get() {
+		ch <- m
+	}
+}
+
+// get returns the metrics of the latest collection, running a new one once interval has passed. With
+// -background-polling, the polled metrics are already shared and are passed on as they are.
+func (s *sharedCollection) get() []prometheus.Metric {
+	if *backgroundPolling {
+		return collectAll(s.c.Collect)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.metrics == nil || time.Since(s.collectedAt) >= s.interval {
+		s.metrics = collectAll(s.c.Collect)
+		s.collectedAt = time.Now()
+	}
+
+	return s.metrics
+}
+
+// collectAll runs collect and returns the metrics it emitted.
+func collectAll(collect func(chan<- prometheus.Metric)) []prometheus.Metric {
+	ch := make(chan prometheus.Metric)
+	done := make(chan []prometheus.Metric)
+	go func() {
+		var metrics []prometheus.Metric
+		for m := range ch {
+			metrics = append(metrics, m)
+		}
+		done <- metrics
+	}()
+
+	collect(ch)
+	close(ch)
+
+	return <-done
+}
+
+// shardCollector passes on either the per-validator metrics of a shared collection or all the others, so they
+// can be served by separate registries.
+type shardCollector struct {
+	s          *sharedCollection
+	validators bool
+}
+
+func (s shardCollector) Describe(ch chan<- *prometheus.Desc) {
+	descs := make(chan *prometheus.Desc)
+	go func() {
+		s.s.Describe(descs)
+		close(descs)
+	}()
+
+	for desc := range descs {
+		if s.s.c.isPerValidator(desc) == s.validators {
+			ch <- desc
+		}
+	}
+}
+
+func (s shardCollector) Collect(ch chan<- prometheus.Metric) {
+	for _, m := range s.s.get() {
+		if s.s.c.isPerValidator(m.Desc()) == s.validators {
+			ch <- m
+		}
+	}
+}
+
+// registerers registers collectors with all of its registerers at once.
+type registerers []prometheus.Registerer
+
+func (rs registerers) Register(c prometheus.Collector) error {
+	for _, r := range rs {
+		if err := r.Register(c); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (rs registerers) MustRegister(cs ...prometheus.Collector) {
+	for _, r := range rs {
+		r.MustRegister(cs...)
+	}
+}
+
+func (rs registerers) Unregister(c prometheus.Collector) bool {
+	unregistered := true
+	for _, r := range rs {
+		unregistered = r.Unregister(c) && unregistered
+	}
+
+	return unregistered
+}
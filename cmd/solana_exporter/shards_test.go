@@ -0,0 +1,124 @@
+package main
+
+import (
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/certusone/solana_exporter/pkg/rpc"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func metricNames(t *testing.T, g prometheus.Gatherer) map[string]bool {
+	families, err := g.Gather()
+	if err != nil {
+		t.Fatalf("gather failed: %v", err)
+	}
+
+	names := make(map[string]bool)
+	for _, family := range families {
+		names[family.GetName()] = true
+	}
+	return names
+}
+
+func sortedNames(names map[string]bool) []string {
+	var sorted []string
+	for name := range names {
+		sorted = append(sorted, name)
+	}
+	sort.Strings(sorted)
+	return sorted
+}
+
+func TestShardsShareCollection(t *testing.T) {
+	tests := []struct {
+		name    string
+		polling bool
+	}{
+		{name: "per-scrape collection"},
+		{name: "background polling", polling: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func(v bool) { *backgroundPolling = v }(*backgroundPolling)
+			*backgroundPolling = tt.polling
+
+			node := newFakeNode(t)
+			c := NewSolanaCollector(node.URL, rpc.CommitmentProcessed)
+			if tt.polling {
+				c.poll()
+			}
+
+			const interval = 200 * time.Millisecond
+			shared := newSharedCollection(c, interval)
+			all, validators, cluster := prometheus.NewRegistry(), prometheus.NewRegistry(), prometheus.NewRegistry()
+			all.MustRegister(shared)
+			validators.MustRegister(shardCollector{s: shared, validators: true})
+			cluster.MustRegister(shardCollector{s: shared, validators: false})
+
+			allNames, validatorNames, clusterNames := metricNames(t, all), metricNames(t, validators), metricNames(t, cluster)
+
+			// getEpochInfo is called once per collection.
+			if got := node.callCount("getEpochInfo"); got != 1 {
+				t.Errorf("scraping all endpoints ran %d collections, want 1", got)
+			}
+
+			if len(validatorNames) == 0 || len(clusterNames) == 0 {
+				t.Fatalf("validators shard has %d metrics, cluster shard %d, want both", len(validatorNames),
+					len(clusterNames))
+			}
+			for name := range validatorNames {
+				if clusterNames[name] {
+					t.Errorf("%s is in both shards", name)
+				}
+				if !allNames[name] {
+					t.Errorf("%s of the validators shard is missing from /metrics", name)
+				}
+			}
+			for name := range clusterNames {
+				if !allNames[name] {
+					t.Errorf("%s of the cluster shard is missing from /metrics", name)
+				}
+			}
+			if len(validatorNames)+len(clusterNames) != len(allNames) {
+				t.Errorf("shards have %v and %v, /metrics has %v", sortedNames(validatorNames),
+					sortedNames(clusterNames), sortedNames(allNames))
+			}
+
+			// Once the interval is over, the next scrape runs a new collection, unless polls are served.
+			time.Sleep(interval)
+			metricNames(t, cluster)
+			metricNames(t, validators)
+			want := 2
+			if tt.polling {
+				want = 1
+			}
+			if got := node.callCount("getEpochInfo"); got != want {
+				t.Errorf("after the interval, %d collections ran, want %d", got, want)
+			}
+		})
+	}
+}
+
+func TestRegisterers(t *testing.T) {
+	a, b := prometheus.NewRegistry(), prometheus.NewRegistry()
+	rs := registerers{a, b}
+
+	gauge := prometheus.NewGauge(prometheus.GaugeOpts{Name: "test_gauge", Help: "A test gauge"})
+	rs.MustRegister(gauge)
+	if !metricNames(t, a)["test_gauge"] || !metricNames(t, b)["test_gauge"] {
+		t.Error("test_gauge isn't registered with both registries")
+	}
+
+	if err := rs.Register(gauge); err == nil {
+		t.Error("registering test_gauge twice succeeded")
+	}
+	if !rs.Unregister(gauge) {
+		t.Error("unregistering test_gauge failed")
+	}
+	if metricNames(t, a)["test_gauge"] || metricNames(t, b)["test_gauge"] {
+		t.Error("test_gauge is still registered")
+	}
+}
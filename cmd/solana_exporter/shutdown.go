@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"k8s.io/klog/v2"
+)
+
+var (
+	shutdownTimeout = flag.Duration("shutdown-timeout", 10*time.Second,
+		"How long in-flight scrapes are given to finish on SIGTERM or SIGINT before the HTTP server is closed")
+	httpReadTimeout = flag.Duration("http-read-timeout", 10*time.Second,
+		"Maximum duration for reading an HTTP request (no limit if 0)")
+	httpWriteTimeout = flag.Duration("http-write-timeout", 30*time.Second,
+		"Maximum duration for serving an HTTP request, must exceed -collect-timeout (no limit if 0)")
+)
+
+// newHTTPServer returns a server for handler on addr with the -http-read-timeout and -http-write-timeout,
+// and the TLS and basic auth settings of web.
+func newHTTPServer(addr string, handler http.Handler, web *webConfig) *http.Server {
+	if handler == nil {
+		handler = http.DefaultServeMux
+	}
+
+	return &http.Server{
+		Addr:         addr,
+		Handler:      web.handler(handler),
+		TLSConfig:    web.tlsConfig(),
+		ReadTimeout:  *httpReadTimeout,
+		WriteTimeout: *httpWriteTimeout,
+	}
+}
+
+// listenAndServe serves srv over HTTPS if it has a TLS configuration, plain HTTP otherwise.
+func listenAndServe(srv *http.Server) error {
+	if srv.TLSConfig != nil {
+		return srv.ListenAndServeTLS("", "")
+	}
+
+	return srv.ListenAndServe()
+}
+
+// serveUntilSignal runs the servers until the process receives SIGTERM or SIGINT. It then calls stop to end
+// the background work and shuts the servers down, waiting up to -shutdown-timeout for in-flight requests.
+// A server that fails to listen is fatal.
+func serveUntilSignal(stop context.CancelFunc, servers ...*http.Server) {
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, syscall.SIGTERM, os.Interrupt)
+
+	errs := make(chan error, len(servers))
+	for _, srv := range servers {
+		go func(srv *http.Server) {
+			errs <- listenAndServe(srv)
+		}(srv)
+	}
+
+	select {
+	case err := <-errs:
+		klog.Fatal(err)
+	case s := <-sig:
+		klog.Infof("received %v, shutting down", s)
+	}
+
+	stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), *shutdownTimeout)
+	defer cancel()
+	for _, srv := range servers {
+		if err := srv.Shutdown(ctx); err != nil {
+			klog.Errorf("failed to shut down HTTP server on %s: %v", srv.Addr, err)
+		}
+	}
+
+	klog.Info("shut down")
+	klog.Flush()
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/certusone/solana_exporter/pkg/rpc"
+)
+
+func TestNewHTTPServer(t *testing.T) {
+	defer func(v time.Duration) { *httpReadTimeout = v }(*httpReadTimeout)
+	defer func(v time.Duration) { *httpWriteTimeout = v }(*httpWriteTimeout)
+	*httpReadTimeout, *httpWriteTimeout = 5*time.Second, 20*time.Second
+
+	srv := newHTTPServer(":9090", nil, &webConfig{})
+	if srv.Addr != ":9090" || srv.ReadTimeout != 5*time.Second || srv.WriteTimeout != 20*time.Second {
+		t.Errorf("got server on %q with read timeout %v and write timeout %v, want :9090, 5s and 20s",
+			srv.Addr, srv.ReadTimeout, srv.WriteTimeout)
+	}
+}
+
+func TestWatchSlotsStopsOnShutdown(t *testing.T) {
+	node := newFakeNode(t)
+	c := NewSolanaCollector(node.URL, rpc.CommitmentProcessed)
+
+	ctx, stop := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		c.WatchSlots(ctx)
+		close(done)
+	}()
+
+	stop()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("WatchSlots didn't return after the context was canceled")
+	}
+}
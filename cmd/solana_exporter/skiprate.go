@@ -0,0 +1,63 @@
+package main
+
+import (
+	"github.com/certusone/solana_exporter/pkg/rpc"
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/klog/v2"
+)
+
+// skipRate returns the share of leader slots without a produced block. There is no rate without leader slots.
+func skipRate(leaderSlots, producedSlots int) (float64, bool) {
+	if leaderSlots <= 0 {
+		return 0, false
+	}
+
+	return float64(leaderSlots-producedSlots) / float64(leaderSlots), true
+}
+
+// skipRatesVsCluster returns how much the skip rate of each of the given identities is above that of the whole
+// cluster in production. The cluster rate is over all leader slots, so validators with more slots weigh more.
+// Identities without leader slots so far are left out.
+func skipRatesVsCluster(production rpc.BlockResult, identities []string) map[string]float64 {
+	clusterRate, ok := skipRate(production.Totals())
+	if !ok {
+		return nil
+	}
+
+	rates := make(map[string]float64)
+	for _, identity := range identities {
+		val, exist := production[identity]
+		if !exist {
+			continue
+		}
+		if rate, ok := skipRate(val[0], val[1]); ok {
+			rates[identity] = rate - clusterRate
+		}
+	}
+
+	return rates
+}
+
+// collectSkipRateVsCluster emits the skip rate of the watched validators relative to the cluster average, from
+// the block production of the whole cluster or the error fetching it.
+func (c *solanaCollector) collectSkipRateVsCluster(ch chan<- prometheus.Metric, watched []rpc.VoteAccount,
+	production *rpc.GetBlockProductionResponse, err error) {
+	if err != nil {
+		klog.Errorf("failed to get cluster block production: %v", err)
+		ch <- prometheus.NewInvalidMetric(c.skipRateVsCluster, err)
+		return
+	}
+
+	identities := make([]string, 0, len(watched))
+	for _, account := range watched {
+		identities = append(identities, account.NodePubkey)
+	}
+
+	rates := skipRatesVsCluster(production.Result.Value.ByIdentity, identities)
+	for _, account := range watched {
+		if rate, ok := rates[account.NodePubkey]; ok {
+			ch <- prometheus.MustNewConstMetric(c.skipRateVsCluster, prometheus.GaugeValue, rate,
+				account.VotePubkey, account.NodePubkey)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/certusone/solana_exporter/pkg/rpc"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestSkipRatesVsCluster(t *testing.T) {
+	// The cluster skipped 10 of its 40 leader slots.
+	production := rpc.BlockResult{"node1": {8, 4}, "node2": {30, 25}, "node3": {2, 1}, "node4": {0, 0}}
+
+	got := skipRatesVsCluster(production, []string{"node1", "node2", "node4", "missing"})
+	want := map[string]float64{"node1": 0.5 - 0.25, "node2": float64(5)/float64(30) - 0.25}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("skipRatesVsCluster = %v, want %v", got, want)
+	}
+
+	if got := skipRatesVsCluster(rpc.BlockResult{"node1": {0, 0}}, []string{"node1"}); got != nil {
+		t.Errorf("skipRatesVsCluster without leader slots = %v, want nil", got)
+	}
+}
+
+func TestCollectSkipRateVsCluster(t *testing.T) {
+	defer func(v string) { *votePubkey = v }(*votePubkey)
+	*votePubkey = "vote1"
+
+	node := newFakeNode(t)
+	// Vote accounts are fetched for vote1 only, while block production covers the whole cluster.
+	node.set("getVoteAccounts", map[string]interface{}{
+		"current": []map[string]interface{}{
+			{"votePubkey": "vote1", "nodePubkey": "node1", "activatedStake": 5000, "commission": 5,
+				"epochVoteAccount": true, "lastVote": 995, "rootSlot": 960, "epochCredits": [][]int{{5, 150, 100}}},
+		},
+		"delinquent": []interface{}{},
+	})
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(NewSolanaCollector(node.URL, rpc.CommitmentProcessed))
+	families, _ := registry.Gather()
+
+	// node1 skipped 1 of 4 leader slots, the cluster 1 of 8.
+	if got := metricValue(families, "solana_validator_skip_rate_vs_cluster",
+		map[string]string{"pubkey": "vote1", "nodekey": "node1"}); got != 0.125 {
+		t.Errorf("solana_validator_skip_rate_vs_cluster = %v, want 0.125", got)
+	}
+	if got := metricValue(families, "solana_validator_skip_rate_vs_cluster",
+		map[string]string{"nodekey": "node2"}); got != -1 {
+		t.Errorf("solana_validator_skip_rate_vs_cluster = %v for an unwatched validator, want it left out", got)
+	}
+}
+
+// Several watched validators have the whole cluster's block production fetched anyway, which is reused.
+func TestSkipRateVsClusterReusesBlockProduction(t *testing.T) {
+	for _, tt := range []struct {
+		votePubkey string
+		wantCalls  int
+	}{
+		{votePubkey: "vote1", wantCalls: 2},
+		{votePubkey: "vote1,vote2", wantCalls: 1},
+	} {
+		t.Run(tt.votePubkey, func(t *testing.T) {
+			defer func(v string) { *votePubkey = v }(*votePubkey)
+			*votePubkey = tt.votePubkey
+
+			node := newFakeNode(t)
+			registry := prometheus.NewRegistry()
+			registry.MustRegister(NewSolanaCollector(node.URL, rpc.CommitmentProcessed))
+			families, _ := registry.Gather()
+
+			// node1 skipped 1 of 4 leader slots, the cluster 1 of 8.
+			if got := metricValue(families, "solana_validator_skip_rate_vs_cluster",
+				map[string]string{"pubkey": "vote1"}); got != 0.125 {
+				t.Errorf("solana_validator_skip_rate_vs_cluster{pubkey=vote1} = %v, want 0.125", got)
+			}
+			if n := node.callCount("getBlockProduction"); n != tt.wantCalls {
+				t.Errorf("getBlockProduction called %d times, want %d", n, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestValidatorSkipRate(t *testing.T) {
+	node := newFakeNode(t)
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(NewSolanaCollector(node.URL, rpc.CommitmentProcessed))
+	families, _ := registry.Gather()
+
+	// node1 produced 3 of its 4 leader slots in epoch 5, node2 all of its 4.
+	for nodekey, want := range map[string]float64{"node1": 0.25, "node2": 0} {
+		if got := metricValue(families, "solana_validator_skip_rate",
+			map[string]string{"nodekey": nodekey, "epoch": "5"}); got != want {
+			t.Errorf("solana_validator_skip_rate{nodekey=%q,epoch=\"5\"} = %v, want %v", nodekey, got, want)
+		}
+	}
+
+	// Without epoch info there is no epoch to label the rate with.
+	delete(node.results, "getEpochInfo")
+	families, _ = registry.Gather()
+	if got := metricValue(families, "solana_validator_skip_rate", nil); got != -1 {
+		t.Errorf("solana_validator_skip_rate = %v without epoch info, want it left out", got)
+	}
+}
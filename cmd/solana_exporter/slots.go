@@ -16,47 +16,54 @@ const (
 var (
 	totalTransactionsTotal = prometheus.NewGauge(prometheus.GaugeOpts{
 		Name: "solana_confirmed_transactions_total",
-		Help: "Total number of transactions processed since genesis (max confirmation)",
+		Help: "Total number of transactions processed since genesis (finalized)",
 	})
 
 	confirmedSlotHeight = prometheus.NewGauge(prometheus.GaugeOpts{
 		Name: "solana_confirmed_slot_height",
-		Help: "Last confirmed slot height processed by watcher routine (max confirmation)",
+		Help: "Last confirmed slot height processed by watcher routine (finalized)",
 	})
 
 	currentEpochNumber = prometheus.NewGauge(prometheus.GaugeOpts{
 		Name: "solana_confirmed_epoch_number",
-		Help: "Current epoch (max confirmation)",
+		Help: "Current epoch (finalized)",
 	})
 
 	epochFirstSlot = prometheus.NewGauge(prometheus.GaugeOpts{
 		Name: "solana_confirmed_epoch_first_slot",
-		Help: "Current epoch's first slot (max confirmation)",
+		Help: "Current epoch's first slot (finalized)",
 	})
 
 	epochLastSlot = prometheus.NewGauge(prometheus.GaugeOpts{
 		Name: "solana_confirmed_epoch_last_slot",
-		Help: "Current epoch's last slot (max confirmation)",
+		Help: "Current epoch's last slot (finalized)",
+	})
+
+	leaderSchedulePresent = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "solana_leader_schedule_present",
+		Help: "Whether getLeaderSchedule returned a schedule for the current epoch (finalized)",
 	})
 
 	leaderSlotsTotal = prometheus.NewCounterVec(
 		prometheus.CounterOpts{
 			Name: "solana_leader_slots_total",
-			Help: "Number of leader slots per leader, grouped by skip status (max confirmation)",
+			Help: "Number of leader slots per leader, grouped by skip status (finalized)",
 		},
 		[]string{"status", "nodekey"})
 )
 
-func init() {
-	prometheus.MustRegister(totalTransactionsTotal)
-	prometheus.MustRegister(confirmedSlotHeight)
-	prometheus.MustRegister(currentEpochNumber)
-	prometheus.MustRegister(epochFirstSlot)
-	prometheus.MustRegister(epochLastSlot)
-	prometheus.MustRegister(leaderSlotsTotal)
+func registerSlotMetrics(r prometheus.Registerer) {
+	r.MustRegister(totalTransactionsTotal)
+	r.MustRegister(confirmedSlotHeight)
+	r.MustRegister(currentEpochNumber)
+	r.MustRegister(epochFirstSlot)
+	r.MustRegister(epochLastSlot)
+	r.MustRegister(leaderSchedulePresent)
+	r.MustRegister(leaderSlotsTotal)
 }
 
-func (c *solanaCollector) WatchSlots() {
+// WatchSlots counts the leader slots of each validator by skip status until ctx is done.
+func (c *solanaCollector) WatchSlots(ctx context.Context) {
 	var (
 		// Current mapping of relative slot numbers to leader public keys.
 		epochSlots map[int64]string
@@ -67,13 +74,18 @@ func (c *solanaCollector) WatchSlots() {
 	)
 
 	ticker := time.NewTicker(slotPacerSchedule)
+	defer ticker.Stop()
 
 	for {
-		<-ticker.C
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return
+		}
 
 		// Get current slot height and epoch info
-		ctx, cancel := context.WithTimeout(context.Background(), httpTimeout)
-		info, err := c.rpcClient.GetEpochInfo(ctx, rpc.CommitmentMax)
+		reqCtx, cancel := context.WithTimeout(ctx, httpTimeout)
+		info, err := c.rpcClient.GetEpochInfo(reqCtx, rpc.CommitmentFinalized)
 		if err != nil {
 			klog.Infof("failed to fetch info info, retrying: %v", err)
 			cancel()
@@ -95,7 +107,7 @@ func (c *solanaCollector) WatchSlots() {
 		if epochNumber != info.Epoch {
 			klog.Infof("new epoch at slot %d: %d (previous: %d)", firstSlot, info.Epoch, epochNumber)
 
-			epochSlots, err = c.fetchLeaderSlots(firstSlot)
+			epochSlots, err = c.fetchLeaderSlots(ctx, firstSlot)
 			if err != nil {
 				klog.Errorf("failed to request leader schedule, retrying: %v", err)
 				continue
@@ -121,8 +133,8 @@ func (c *solanaCollector) WatchSlots() {
 		rangeStart := firstSlot + watermark
 		rangeEnd := firstSlot + info.SlotIndex - 1
 
-		ctx, cancel = context.WithTimeout(context.Background(), httpTimeout)
-		cfm, err := c.rpcClient.GetConfirmedBlocks(ctx, rangeStart, rangeEnd)
+		reqCtx, cancel = context.WithTimeout(ctx, httpTimeout)
+		cfm, err := c.rpcClient.GetConfirmedBlocks(reqCtx, rangeStart, rangeEnd)
 		if err != nil {
 			klog.Errorf("failed to request confirmed blocks at %d, retrying: %v", watermark, err)
 			cancel()
@@ -168,12 +180,19 @@ func (c *solanaCollector) WatchSlots() {
 	}
 }
 
-func (c *solanaCollector) fetchLeaderSlots(epochSlot int64) (map[int64]string, error) {
-	sch, err := c.rpcClient.GetLeaderSchedule(context.Background(), epochSlot)
+func (c *solanaCollector) fetchLeaderSlots(ctx context.Context, epochSlot int64) (map[int64]string, error) {
+	sch, err := c.rpcClient.GetLeaderSchedule(ctx, epochSlot)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get leader schedule: %w", err)
 	}
 
+	// The schedule can be null shortly after an epoch rollover.
+	if sch == nil {
+		leaderSchedulePresent.Set(0)
+		return nil, fmt.Errorf("leader schedule for slot %d is not available yet", epochSlot)
+	}
+	leaderSchedulePresent.Set(1)
+
 	slots := make(map[int64]string)
 
 	for pk, sch := range sch {
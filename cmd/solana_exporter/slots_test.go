@@ -0,0 +1,40 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/certusone/solana_exporter/pkg/rpc"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestLeaderSchedulePresent(t *testing.T) {
+	tests := []struct {
+		name     string
+		schedule interface{}
+		want     float64
+		wantErr  bool
+	}{
+		{name: "present", schedule: map[string][]int{"node1": {0, 1, 2, 3}, "node2": {4, 5, 6, 7}}, want: 1},
+		// Shortly after an epoch rollover.
+		{name: "null", schedule: nil, want: 0, wantErr: true},
+		{name: "present again", schedule: map[string][]int{"node1": {0, 1}}, want: 1},
+	}
+
+	node := newFakeNode(t)
+	c := NewSolanaCollector(node.URL, rpc.CommitmentProcessed)
+
+	for _, tt := range tests {
+		node.set("getLeaderSchedule", tt.schedule)
+		slots, err := c.fetchLeaderSlots(context.Background(), 864000)
+		if (err != nil) != tt.wantErr {
+			t.Fatalf("%s: fetchLeaderSlots() error = %v, want error %v", tt.name, err, tt.wantErr)
+		}
+		if err == nil && slots[0] != "node1" {
+			t.Errorf("%s: leader of slot 0 = %q, want node1", tt.name, slots[0])
+		}
+		if got := testutil.ToFloat64(leaderSchedulePresent); got != tt.want {
+			t.Errorf("%s: solana_leader_schedule_present = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"sync"
+	"time"
+
+	"github.com/certusone/solana_exporter/pkg/rpc"
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/klog/v2"
+)
+
+var (
+	stakeAccounts = flag.Bool("stake-accounts", false,
+		"Export the stake accounts delegated to each -votepubkey validator and their activating and deactivating stake (expensive)")
+	stakeAccountsTTL = flag.Duration("stake-accounts-ttl", time.Hour,
+		"How long the delegated stake accounts are cached within an epoch")
+)
+
+// stakeSummary sums up the stake accounts delegated to a validator in an epoch.
+type stakeSummary struct {
+	// Accounts whose stake isn't fully deactivated.
+	accounts int
+	// Stake that becomes active or inactive at the end of the epoch, in lamports.
+	activating, deactivating int64
+}
+
+// summarizeStake sorts delegations into activating, deactivating and other stake in epoch. Stake activated and
+// deactivated in the same epoch never becomes active and counts as neither. Warmup and cooldown are ignored, so
+// stake held back from activating or deactivating by the cluster's rate limit doesn't show.
+func summarizeStake(delegations []rpc.Delegation, epoch int64) stakeSummary {
+	var s stakeSummary
+	for _, d := range delegations {
+		deactivated := d.DeactivationEpoch != -1
+		if deactivated && d.DeactivationEpoch < epoch {
+			continue
+		}
+
+		s.accounts++
+		switch {
+		case d.ActivationEpoch == epoch && !deactivated:
+			s.activating += d.Stake
+		case d.ActivationEpoch != epoch && deactivated:
+			s.deactivating += d.Stake
+		}
+	}
+
+	return s
+}
+
+type stakeSample struct {
+	stakeSummary
+	epoch     int64
+	fetchedAt time.Time
+}
+
+// stakeAccountsCollector emits the stake accounts delegated to each watched validator. Like the delegator
+// count, this takes a getProgramAccounts call over the whole stake program per validator, so results are cached
+// for -stake-accounts-ttl or until the epoch changes.
+type stakeAccountsCollector struct {
+	rpcClient  *rpc.RPCClient
+	commitment rpc.Commitment
+
+	mu      sync.Mutex
+	samples map[string]stakeSample
+
+	accounts     *prometheus.Desc
+	activating   *prometheus.Desc
+	deactivating *prometheus.Desc
+}
+
+func newStakeAccountsCollector(client *rpc.RPCClient, commitment rpc.Commitment) *stakeAccountsCollector {
+	return &stakeAccountsCollector{
+		rpcClient:  client,
+		commitment: commitment,
+		samples:    make(map[string]stakeSample),
+		accounts: prometheus.NewDesc(
+			"solana_validator_delegated_stake_accounts_count",
+			"Number of stake accounts delegated to the vote account whose stake isn't fully deactivated",
+			[]string{"pubkey"}, nil),
+		activating: prometheus.NewDesc(
+			"solana_validator_activating_stake",
+			"Stake delegated to the vote account in the current epoch, in lamports",
+			[]string{"pubkey"}, nil),
+		deactivating: prometheus.NewDesc(
+			"solana_validator_deactivating_stake",
+			"Stake deactivated from the vote account in the current epoch, in lamports",
+			[]string{"pubkey"}, nil),
+	}
+}
+
+func (c *stakeAccountsCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.accounts
+	ch <- c.activating
+	ch <- c.deactivating
+}
+
+// Collect fetches the stake accounts of the watched validators one after another. A validator whose stake
+// accounts can't be fetched is logged and left out.
+func (c *stakeAccountsCollector) Collect(ch chan<- prometheus.Metric) {
+	configMu.RLock()
+	watched := watchedVotePubkeys()
+	configMu.RUnlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), httpTimeout)
+	defer cancel()
+
+	info, err := c.rpcClient.GetEpochInfo(ctx, c.commitment)
+	if err != nil {
+		klog.Errorf("failed to get epoch info for stake accounts: %v", err)
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, pubkey := range watched {
+		sample, ok := c.samples[pubkey]
+		fetchedAt := sample.fetchedAt
+		if !ok || sample.epoch != info.Epoch || time.Since(sample.fetchedAt) > *stakeAccountsTTL {
+			delegations, err := c.rpcClient.GetStakeDelegations(ctx, pubkey, c.commitment)
+			if err != nil {
+				klog.Errorf("failed to get stake accounts of %s: %v", pubkey, err)
+				continue
+			}
+
+			sample = stakeSample{stakeSummary: summarizeStake(delegations, info.Epoch), epoch: info.Epoch,
+				fetchedAt: time.Now()}
+			c.samples[pubkey] = sample
+			fetchedAt = time.Time{}
+		}
+
+		ch <- cachedMetric(prometheus.MustNewConstMetric(c.accounts, prometheus.GaugeValue,
+			float64(sample.accounts), pubkey), fetchedAt)
+		ch <- cachedMetric(prometheus.MustNewConstMetric(c.activating, prometheus.GaugeValue,
+			float64(sample.activating), pubkey), fetchedAt)
+		ch <- cachedMetric(prometheus.MustNewConstMetric(c.deactivating, prometheus.GaugeValue,
+			float64(sample.deactivating), pubkey), fetchedAt)
+	}
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"testing"
+	"time"
+
+	"github.com/certusone/solana_exporter/pkg/rpc"
+)
+
+func TestSummarizeStake(t *testing.T) {
+	delegations := []rpc.Delegation{
+		{Stake: 100, ActivationEpoch: 2, DeactivationEpoch: -1},
+		{Stake: 200, ActivationEpoch: 5, DeactivationEpoch: -1},
+		{Stake: 400, ActivationEpoch: 3, DeactivationEpoch: 5},
+		// Deactivated before the epoch, so no longer counted.
+		{Stake: 800, ActivationEpoch: 1, DeactivationEpoch: 4},
+		// Activated and deactivated in the same epoch, so it never becomes active.
+		{Stake: 1600, ActivationEpoch: 5, DeactivationEpoch: 5},
+	}
+
+	want := stakeSummary{accounts: 4, activating: 200, deactivating: 400}
+	if got := summarizeStake(delegations, 5); got != want {
+		t.Errorf("summarizeStake = %+v, want %+v", got, want)
+	}
+}
+
+// stakeAccountData encodes the stake and epochs of a delegation the way getProgramAccounts returns the slice
+// of a stake account.
+func stakeAccountData(stake, activation, deactivation uint64) map[string]interface{} {
+	b := make([]byte, 24)
+	binary.LittleEndian.PutUint64(b[0:], stake)
+	binary.LittleEndian.PutUint64(b[8:], activation)
+	binary.LittleEndian.PutUint64(b[16:], deactivation)
+
+	return map[string]interface{}{"account": map[string]interface{}{
+		"data": []string{base64.StdEncoding.EncodeToString(b), "base64"},
+	}}
+}
+
+func TestStakeAccountsCollector(t *testing.T) {
+	defer func(v string) { *votePubkey = v }(*votePubkey)
+	defer func(v time.Duration) { *stakeAccountsTTL = v }(*stakeAccountsTTL)
+	*votePubkey, *stakeAccountsTTL = "vote1", time.Hour
+
+	node := newFakeNode(t)
+	node.set("getProgramAccounts", []map[string]interface{}{
+		stakeAccountData(100, 2, 1<<64-1),
+		stakeAccountData(200, 5, 1<<64-1),
+		stakeAccountData(400, 3, 5),
+	})
+	c := newStakeAccountsCollector(rpc.NewRPCClient(node.URL), rpc.CommitmentFinalized)
+
+	want := map[string]float64{
+		`solana_validator_delegated_stake_accounts_count{pubkey="vote1"}`: 3,
+		`solana_validator_activating_stake{pubkey="vote1"}`:               200,
+		`solana_validator_deactivating_stake{pubkey="vote1"}`:             400,
+	}
+	for scrape := 1; scrape <= 2; scrape++ {
+		got := emitted(t, c.Collect)
+		for name, value := range want {
+			if got[name] != value {
+				t.Errorf("scrape %d: %s = %v, want %v", scrape, name, got[name], value)
+			}
+		}
+	}
+	if calls := node.callCount("getProgramAccounts"); calls != 1 {
+		t.Errorf("getProgramAccounts called %d times, want 1", calls)
+	}
+
+	// A new epoch fetches the stake accounts again, even within the TTL.
+	node.set("getEpochInfo", map[string]interface{}{
+		"absoluteSlot": 432100, "blockHeight": 432000, "epoch": 6, "slotIndex": 100, "slotsInEpoch": 432000,
+		"transactionCount": 7,
+	})
+	got := emitted(t, c.Collect)
+	if calls := node.callCount("getProgramAccounts"); calls != 2 {
+		t.Errorf("getProgramAccounts called %d times after the epoch changed, want 2", calls)
+	}
+	if name := `solana_validator_activating_stake{pubkey="vote1"}`; got[name] != 0 {
+		t.Errorf("%s = %v in the next epoch, want 0", name, got[name])
+	}
+	if name := `solana_validator_delegated_stake_accounts_count{pubkey="vote1"}`; got[name] != 2 {
+		t.Errorf("%s = %v in the next epoch, want 2", name, got[name])
+	}
+}
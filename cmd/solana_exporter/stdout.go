@@ -0,0 +1,51 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"io"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/expfmt"
+	"k8s.io/klog/v2"
+)
+
+var stdoutInterval = flag.Duration("stdout-interval", 0,
+	"Interval at which all metrics are written to stdout in the text exposition format (disabled if 0)")
+
+// writeMetrics writes everything registered with g to w in the text exposition format, followed by an empty
+// line separating it from the next write. Metrics are still written if some of them failed to be gathered.
+func writeMetrics(w io.Writer, g prometheus.Gatherer) error {
+	mfs, err := g.Gather()
+	if err != nil {
+		klog.Errorf("failed to gather some metrics: %v", err)
+	}
+
+	// Buffer the output so that it reaches w in a single write.
+	var buf bytes.Buffer
+	enc := expfmt.NewEncoder(&buf, expfmt.FmtText)
+	for _, mf := range mfs {
+		if err := enc.Encode(mf); err != nil {
+			return err
+		}
+	}
+	buf.WriteString("\n")
+
+	_, err = w.Write(buf.Bytes())
+	return err
+}
+
+// writeMetricsLoop periodically writes everything registered with g to w.
+func writeMetricsLoop(w io.Writer, g prometheus.Gatherer, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := writeMetrics(w, g); err != nil {
+			klog.Errorf("failed to write metrics: %v", err)
+		}
+
+		<-ticker.C
+	}
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestWriteMetrics(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	gauge := prometheus.NewGauge(prometheus.GaugeOpts{Name: "test_gauge", Help: "A test gauge"})
+	gauge.Set(42)
+	registry.MustRegister(gauge)
+
+	var buf bytes.Buffer
+	if err := writeMetrics(&buf, registry); err != nil {
+		t.Fatal(err)
+	}
+	if err := writeMetrics(&buf, registry); err != nil {
+		t.Fatal(err)
+	}
+
+	want := "# HELP test_gauge A test gauge\n# TYPE test_gauge gauge\ntest_gauge 42\n\n"
+	if got := buf.String(); got != want+want {
+		t.Errorf("wrote %q, want %q", got, want+want)
+	}
+}
+
+func TestWriteMetricsGatherError(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	gauge := prometheus.NewGauge(prometheus.GaugeOpts{Name: "test_gauge", Help: "A test gauge"})
+	registry.MustRegister(gauge, failingCollector{})
+
+	var buf bytes.Buffer
+	if err := writeMetrics(&buf, registry); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "test_gauge 0\n") {
+		t.Errorf("wrote %q, want the metrics that were gathered", buf.String())
+	}
+}
+
+// failingCollector emits a single invalid metric.
+type failingCollector struct{}
+
+var failingDesc = prometheus.NewDesc("test_failing", "Always fails", nil, nil)
+
+func (failingCollector) Describe(ch chan<- *prometheus.Desc) { ch <- failingDesc }
+
+func (failingCollector) Collect(ch chan<- prometheus.Metric) {
+	ch <- prometheus.NewInvalidMetric(failingDesc, errors.New("gather failed"))
+}
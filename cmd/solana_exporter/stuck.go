@@ -0,0 +1,37 @@
+package main
+
+import (
+	"flag"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var slotStuckScrapes = flag.Int("slot-stuck-scrapes", 3,
+	"Number of consecutive scrapes without a new confirmed slot after which the node is reported stuck")
+
+// observeSlot records the confirmed slot of a scrape and returns for how many consecutive scrapes it hasn't
+// advanced.
+func (c *solanaCollector) observeSlot(slot int64) int {
+	c.slotMu.Lock()
+	defer c.slotMu.Unlock()
+
+	if c.lastSlot != 0 && slot <= c.lastSlot {
+		c.stalledScrapes++
+	} else {
+		c.stalledScrapes = 0
+	}
+	c.lastSlot = slot
+
+	return c.stalledScrapes
+}
+
+// collectSlotStuck emits whether the confirmed slot hasn't advanced for more than -slot-stuck-scrapes
+// scrapes. A frozen node can keep answering getHealth with ok, so this catches what the health check doesn't.
+func (c *solanaCollector) collectSlotStuck(ch chan<- prometheus.Metric, confirmed int64) {
+	var stuck float64
+	if c.observeSlot(confirmed) > *slotStuckScrapes {
+		stuck = 1
+	}
+
+	ch <- prometheus.MustNewConstMetric(c.slotStuck, prometheus.GaugeValue, stuck)
+}
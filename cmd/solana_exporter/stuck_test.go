@@ -0,0 +1,34 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/certusone/solana_exporter/pkg/rpc"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestSlotStuck(t *testing.T) {
+	defer func(v int) { *slotStuckScrapes = v }(*slotStuckScrapes)
+	*slotStuckScrapes = 1
+
+	node := newFakeNode(t)
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(NewSolanaCollector(node.URL, rpc.CommitmentProcessed))
+
+	for i, step := range []struct {
+		slot int
+		want float64
+	}{
+		{1000, 0},
+		{1000, 0}, // one scrape without progress is within -slot-stuck-scrapes
+		{1000, 1},
+		{999, 1}, // going back isn't progress either
+		{1001, 0},
+	} {
+		node.set("getSlot", step.slot)
+		families, _ := registry.Gather()
+		if got := metricValue(families, "solana_node_slot_stuck", nil); got != step.want {
+			t.Errorf("scrape %d at slot %d: solana_node_slot_stuck = %v, want %v", i, step.slot, got, step.want)
+		}
+	}
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"time"
+
+	"github.com/certusone/solana_exporter/pkg/rpc"
+	"k8s.io/klog/v2"
+)
+
+// scrapeSummary collects what a scrape saw, for a single log line at the end of it.
+type scrapeSummary struct {
+	epoch    *rpc.EpochInfo
+	accounts *rpc.GetVoteAccountsResponse
+}
+
+// log writes the summary at the verbosity given with -summary-v. Values that couldn't be fetched are
+// logged as -1.
+func (s *scrapeSummary) log(start time.Time) {
+	if !klog.V(klog.Level(*summaryVerbosity)).Enabled() {
+		return
+	}
+
+	epoch, slot := int64(-1), int64(-1)
+	if s.epoch != nil {
+		epoch, slot = s.epoch.Epoch, s.epoch.AbsoluteSlot
+	}
+
+	validators, delinquent := -1, -1
+	if s.accounts != nil {
+		delinquent = len(s.accounts.Result.Delinquent)
+		validators = len(s.accounts.Result.Current) + delinquent
+	}
+
+	klog.V(klog.Level(*summaryVerbosity)).Infof("scrape done: epoch=%d slot=%d validators=%d delinquent=%d duration=%s",
+		epoch, slot, validators, delinquent, time.Since(start).Round(time.Millisecond))
+}
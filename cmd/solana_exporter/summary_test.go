@@ -0,0 +1,85 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/certusone/solana_exporter/pkg/rpc"
+	"k8s.io/klog/v2"
+)
+
+// captureLogs sends klog output at verbosity v to the returned buffer until the test ends.
+func captureLogs(t *testing.T, v string) *bytes.Buffer {
+	t.Helper()
+
+	var buf bytes.Buffer
+	for name, value := range map[string]string{"logtostderr": "false", "alsologtostderr": "false", "v": v} {
+		old := flag.Lookup(name).Value.String()
+		if err := flag.Set(name, value); err != nil {
+			t.Fatal(err)
+		}
+		name := name
+		t.Cleanup(func() { _ = flag.Set(name, old) })
+	}
+	klog.SetOutput(&buf)
+	t.Cleanup(func() {
+		klog.Flush()
+		klog.SetOutput(os.Stderr)
+	})
+
+	return &buf
+}
+
+func TestScrapeSummary(t *testing.T) {
+	accounts := &rpc.GetVoteAccountsResponse{}
+	accounts.Result.Current = []rpc.VoteAccount{{VotePubkey: "vote1"}, {VotePubkey: "vote2"}}
+	accounts.Result.Delinquent = []rpc.VoteAccount{{VotePubkey: "vote3"}}
+
+	tests := []struct {
+		name    string
+		summary scrapeSummary
+		want    string
+	}{
+		{
+			name:    "complete",
+			summary: scrapeSummary{epoch: &rpc.EpochInfo{Epoch: 5, AbsoluteSlot: 1000}, accounts: accounts},
+			want:    "scrape done: epoch=5 slot=1000 validators=3 delinquent=1",
+		},
+		{
+			name: "nothing fetched",
+			want: "scrape done: epoch=-1 slot=-1 validators=-1 delinquent=-1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logs := captureLogs(t, "1")
+
+			tt.summary.log(time.Now())
+			klog.Flush()
+
+			if !strings.Contains(logs.String(), tt.want) {
+				t.Errorf("logged %q, want it to contain %q", logs.String(), tt.want)
+			}
+		})
+	}
+}
+
+func TestScrapeSummaryVerbosity(t *testing.T) {
+	defer func(v int) { *summaryVerbosity = v }(*summaryVerbosity)
+	*summaryVerbosity = 2
+
+	logs := captureLogs(t, "1")
+
+	var summary scrapeSummary
+	summary.log(time.Now())
+	klog.Flush()
+
+	if strings.Contains(logs.String(), "scrape done") {
+		t.Errorf("summary logged at -v=1 with -summary-v=2: %q", logs.String())
+	}
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"strconv"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/klog/v2"
+)
+
+// collectTokenAccounts emits the balance of each SPL token account given with -token-accounts, labeled with
+// the mint and owner from the jsonParsed account data.
+func (c *solanaCollector) collectTokenAccounts(budget *callBudget, ch chan<- prometheus.Metric, pubkeys []string) {
+	for _, pubkey := range pubkeys {
+		info, err := c.rpcClient.GetAccountInfo(budget.next(), pubkey, c.commitment)
+		if err != nil {
+			klog.Errorf("failed to get token account %s: %v", pubkey, err)
+			ch <- prometheus.NewInvalidMetric(c.tokenAccountBalance, err)
+			continue
+		}
+
+		account, err := info.TokenAccount()
+		if err != nil {
+			klog.Errorf("failed to decode token account %s: %v", pubkey, err)
+			ch <- prometheus.NewInvalidMetric(c.tokenAccountBalance, err)
+			continue
+		}
+
+		amount, err := strconv.ParseFloat(account.TokenAmount.UIAmountString, 64)
+		if err != nil {
+			klog.Errorf("failed to parse amount of token account %s: %v", pubkey, err)
+			ch <- prometheus.NewInvalidMetric(c.tokenAccountBalance, err)
+			continue
+		}
+
+		ch <- prometheus.MustNewConstMetric(c.tokenAccountBalance, prometheus.GaugeValue,
+			amount, pubkey, account.Mint, account.Owner)
+	}
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/certusone/solana_exporter/pkg/rpc"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestTokenAccountBalance(t *testing.T) {
+	defer func(v string) { *tokenAccounts = v }(*tokenAccounts)
+	*tokenAccounts = "token1"
+
+	node := newFakeNode(t)
+	node.set("getAccountInfo", map[string]interface{}{
+		"context": map[string]interface{}{"slot": 990},
+		"value": map[string]interface{}{
+			"lamports": 2039280, "owner": "TokenkegQfeZyiNwAJbNbGKPFXCWuBvf9Ss623VQ5DA", "executable": false,
+			"data": map[string]interface{}{
+				"program": "spl-token", "space": 165,
+				"parsed": map[string]interface{}{"type": "account", "info": map[string]interface{}{
+					"mint": "mint1", "owner": "owner1", "state": "initialized",
+					"tokenAmount": map[string]interface{}{"amount": "1500000", "decimals": 6, "uiAmountString": "1.5"},
+				}},
+			},
+		},
+	})
+
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(NewSolanaCollector(node.URL, rpc.CommitmentProcessed))
+	families, _ := registry.Gather()
+
+	labels := map[string]string{"pubkey": "token1", "mint": "mint1", "owner": "owner1"}
+	if got := metricValue(families, "solana_token_account_balance", labels); got != 1.5 {
+		t.Errorf("solana_token_account_balance = %v, want 1.5", got)
+	}
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"time"
+)
+
+// observeHealth records the outcome of a health check of the node with the given identity. An unhealthy
+// or unreachable node followed by a healthy one is taken as a restart, so the time the node was first seen
+// healthy approximates its uptime.
+func (c *solanaCollector) observeHealth(identity string, healthy bool, now time.Time) {
+	c.firstSeenMu.Lock()
+	defer c.firstSeenMu.Unlock()
+
+	if !healthy {
+		c.nodeDown = true
+		return
+	}
+
+	if c.firstSeen.IsZero() || c.nodeDown {
+		c.firstSeen = now
+		c.nodeDown = false
+	}
+	c.firstSeenNode = identity
+}
+
+// nodeFirstSeenAt returns when the node was first seen healthy since its last outage along with its
+// identity, or the zero time if it hasn't been yet.
+func (c *solanaCollector) nodeFirstSeenAt() (time.Time, string) {
+	c.firstSeenMu.Lock()
+	defer c.firstSeenMu.Unlock()
+
+	return c.firstSeen, c.firstSeenNode
+}
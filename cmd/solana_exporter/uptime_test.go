@@ -0,0 +1,39 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/certusone/solana_exporter/pkg/rpc"
+)
+
+func TestObserveHealth(t *testing.T) {
+	c := NewSolanaCollector("http://localhost:8899", rpc.CommitmentProcessed)
+	start := time.Unix(1600000000, 0)
+
+	steps := []struct {
+		healthy bool
+		want    time.Time
+	}{
+		// Nothing is reported until the node is healthy once.
+		{healthy: false, want: time.Time{}},
+		{healthy: true, want: start.Add(time.Minute)},
+		{healthy: true, want: start.Add(time.Minute)},
+		// An outage keeps the last time until the node recovers, which counts as a restart.
+		{healthy: false, want: start.Add(time.Minute)},
+		{healthy: true, want: start.Add(4 * time.Minute)},
+		{healthy: true, want: start.Add(4 * time.Minute)},
+	}
+
+	for i, step := range steps {
+		c.observeHealth("node1", step.healthy, start.Add(time.Duration(i)*time.Minute))
+
+		got, identity := c.nodeFirstSeenAt()
+		if !got.Equal(step.want) {
+			t.Errorf("check %d (healthy %v): first seen %v, want %v", i, step.healthy, got, step.want)
+		}
+		if !got.IsZero() && identity != "node1" {
+			t.Errorf("check %d: identity %q, want node1", i, identity)
+		}
+	}
+}
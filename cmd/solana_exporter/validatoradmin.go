@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"flag"
+
+	"github.com/certusone/solana_exporter/pkg/rpc"
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/klog/v2"
+)
+
+var adminSocket = flag.String("admin-socket", "",
+	"Path to the validator's admin RPC socket (admin.rpc in the ledger directory) to export its start time and progress")
+
+// validatorAdminCollector emits metrics only available from the validator's admin RPC socket.
+type validatorAdminCollector struct {
+	client *rpc.AdminClient
+
+	startTime     *prometheus.Desc
+	startProgress *prometheus.Desc
+}
+
+func newValidatorAdminCollector(socketPath string) *validatorAdminCollector {
+	return &validatorAdminCollector{
+		client: rpc.NewAdminClient(socketPath),
+		startTime: prometheus.NewDesc(
+			"solana_validator_start_time_seconds",
+			"Unix timestamp at which the validator process started",
+			nil, nil),
+		startProgress: prometheus.NewDesc(
+			"solana_validator_start_progress",
+			"Startup stage the validator is in, always 1",
+			[]string{"stage"}, nil),
+	}
+}
+
+func (c *validatorAdminCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.startTime
+	ch <- c.startProgress
+}
+
+func (c *validatorAdminCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), httpTimeout)
+	defer cancel()
+
+	start, err := c.client.StartTime(ctx)
+	if err != nil {
+		klog.Errorf("failed to get validator start time: %v", err)
+		ch <- prometheus.NewInvalidMetric(c.startTime, err)
+	} else {
+		ch <- prometheus.MustNewConstMetric(c.startTime, prometheus.GaugeValue, float64(start.Unix()))
+	}
+
+	stage, err := c.client.StartProgress(ctx)
+	if err != nil {
+		klog.Errorf("failed to get validator start progress: %v", err)
+		ch <- prometheus.NewInvalidMetric(c.startProgress, err)
+	} else {
+		ch <- prometheus.MustNewConstMetric(c.startProgress, prometheus.GaugeValue, 1, stage)
+	}
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"errors"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sync"
+
+	"golang.org/x/crypto/bcrypt"
+	"gopkg.in/yaml.v2"
+)
+
+var (
+	tlsCertFile   = flag.String("tls-cert", "", "Certificate file to serve HTTPS with, requires -tls-key")
+	tlsKeyFile    = flag.String("tls-key", "", "Private key file of -tls-cert")
+	webConfigFile = flag.String("web-config", "",
+		"YAML file with TLS and basic auth settings for the HTTP endpoints, as read by the Prometheus exporter-toolkit")
+)
+
+// webConfig is the subset of the exporter-toolkit web configuration the exporter supports. Passwords are
+// stored as bcrypt hashes.
+type webConfig struct {
+	TLSServerConfig struct {
+		CertFile string `yaml:"cert_file"`
+		KeyFile  string `yaml:"key_file"`
+	} `yaml:"tls_server_config"`
+	BasicAuthUsers map[string]string `yaml:"basic_auth_users"`
+
+	// Credentials that passed the bcrypt check, by their SHA-256 hash, so that each scrape doesn't pay for it.
+	authorizedMu sync.Mutex
+	authorized   map[[sha256.Size]byte]bool
+}
+
+// loadWebConfig reads -web-config, if given, and merges -tls-cert and -tls-key into it. Settings the
+// exporter doesn't support are rejected rather than ignored, as they are likely meant to restrict access.
+func loadWebConfig() (*webConfig, error) {
+	var config webConfig
+	if *webConfigFile != "" {
+		b, err := ioutil.ReadFile(*webConfigFile)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := yaml.UnmarshalStrict(b, &config); err != nil {
+			return nil, fmt.Errorf("failed to decode %s: %w", *webConfigFile, err)
+		}
+	}
+
+	if *tlsCertFile != "" || *tlsKeyFile != "" {
+		if config.TLSServerConfig.CertFile != "" || config.TLSServerConfig.KeyFile != "" {
+			return nil, errors.New("-tls-cert and -tls-key can't be combined with tls_server_config in -web-config")
+		}
+		config.TLSServerConfig.CertFile, config.TLSServerConfig.KeyFile = *tlsCertFile, *tlsKeyFile
+	}
+
+	tlsConfig := config.TLSServerConfig
+	if (tlsConfig.CertFile == "") != (tlsConfig.KeyFile == "") {
+		return nil, errors.New("a TLS certificate and key must be given together")
+	}
+	if tlsConfig.CertFile != "" {
+		if _, err := tls.LoadX509KeyPair(tlsConfig.CertFile, tlsConfig.KeyFile); err != nil {
+			return nil, fmt.Errorf("failed to load TLS certificate: %w", err)
+		}
+	}
+
+	for user, hash := range config.BasicAuthUsers {
+		if _, err := bcrypt.Cost([]byte(hash)); err != nil {
+			return nil, fmt.Errorf("password of %q must be a bcrypt hash: %w", user, err)
+		}
+	}
+	config.authorized = make(map[[sha256.Size]byte]bool)
+
+	return &config, nil
+}
+
+// tlsConfig returns the TLS configuration of a server, nil to serve plain HTTP. The certificate is read again
+// on every handshake, so a renewed certificate is picked up without a restart.
+func (w *webConfig) tlsConfig() *tls.Config {
+	if w.TLSServerConfig.CertFile == "" {
+		return nil
+	}
+
+	certFile, keyFile := w.TLSServerConfig.CertFile, w.TLSServerConfig.KeyFile
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		GetCertificate: func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+			cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+			if err != nil {
+				return nil, err
+			}
+			return &cert, nil
+		},
+	}
+}
+
+// handler requires basic auth for everything but /readyz if any users are configured. The readiness check
+// stays open so that probes don't need credentials; it only reveals whether the exporter is ready.
+func (w *webConfig) handler(h http.Handler) http.Handler {
+	if len(w.BasicAuthUsers) == 0 {
+		return h
+	}
+
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/readyz" && !w.checkAuth(r) {
+			rw.Header().Set("WWW-Authenticate", `Basic realm="solana_exporter"`)
+			http.Error(rw, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+			return
+		}
+
+		h.ServeHTTP(rw, r)
+	})
+}
+
+// checkAuth reports whether r carries the credentials of a configured user. bcrypt is slow by design, so
+// credentials that passed are remembered; failed attempts are checked again every time.
+func (w *webConfig) checkAuth(r *http.Request) bool {
+	user, password, ok := r.BasicAuth()
+	if !ok {
+		return false
+	}
+
+	hash, ok := w.BasicAuthUsers[user]
+	if !ok {
+		return false
+	}
+
+	key := sha256.Sum256([]byte(user + "\x00" + password))
+	w.authorizedMu.Lock()
+	cached := w.authorized[key]
+	w.authorizedMu.Unlock()
+	if cached {
+		return true
+	}
+
+	if bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) != nil {
+		return false
+	}
+
+	w.authorizedMu.Lock()
+	w.authorized[key] = true
+	w.authorizedMu.Unlock()
+
+	return true
+}
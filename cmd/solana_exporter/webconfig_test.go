@@ -0,0 +1,132 @@
+package main
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// writeWebConfig writes content to a temporary file and points -web-config at it for the rest of the test.
+func writeWebConfig(t *testing.T, content string) {
+	dir, err := ioutil.TempDir("", "webconfig")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	path := filepath.Join(dir, "web.yml")
+	if err := ioutil.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	old := *webConfigFile
+	*webConfigFile = path
+	t.Cleanup(func() { *webConfigFile = old })
+}
+
+func TestLoadWebConfig(t *testing.T) {
+	defer func(v string) { *tlsCertFile = v }(*tlsCertFile)
+
+	hash, err := bcrypt.GenerateFromPassword([]byte("secret"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		content string
+		tlsCert string
+		wantErr string
+	}{
+		{name: "bcrypt password", content: "basic_auth_users:\n  prometheus: " + string(hash) + "\n"},
+		// The hash htpasswd writes.
+		{name: "htpasswd hash", content: "basic_auth_users:\n  prometheus: $2y" + string(hash[3:]) + "\n"},
+		{name: "JSON layout", content: `{"basic_auth_users": {"prometheus": "` + string(hash) + `"}}`},
+		{
+			name:    "SHA-256 password",
+			content: "basic_auth_users:\n  prometheus: 2bb80d537b1da3e38bd30361aa855686bde0eacd7162fef6a25fe97bf527a25b\n",
+			wantErr: "must be a bcrypt hash",
+		},
+		{
+			name:    "unsupported setting",
+			content: "tls_server_config:\n  client_auth_type: RequireAndVerifyClientCert\n",
+			wantErr: "client_auth_type",
+		},
+		{name: "key without certificate", content: "tls_server_config:\n  key_file: tls.key\n", wantErr: "together"},
+		{name: "certificate flag without key", content: "{}", tlsCert: "tls.crt", wantErr: "together"},
+		{
+			name:    "flags and config",
+			content: "tls_server_config:\n  cert_file: a.crt\n  key_file: a.key\n",
+			tlsCert: "tls.crt",
+			wantErr: "can't be combined",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			writeWebConfig(t, tt.content)
+			*tlsCertFile = tt.tlsCert
+
+			config, err := loadWebConfig()
+			if tt.wantErr == "" && err != nil {
+				t.Fatalf("loadWebConfig() error = %v", err)
+			}
+			if tt.wantErr == "" && config.tlsConfig() != nil {
+				t.Error("got a TLS configuration without a certificate")
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("loadWebConfig() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestWebConfigBasicAuth(t *testing.T) {
+	hash, err := bcrypt.GenerateFromPassword([]byte("secret"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writeWebConfig(t, "basic_auth_users:\n  prometheus: "+string(hash)+"\n")
+
+	web, err := loadWebConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := web.handler(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+
+	tests := []struct {
+		name       string
+		path       string
+		user       string
+		password   string
+		wantStatus int
+	}{
+		{name: "valid credentials", path: "/metrics", user: "prometheus", password: "secret", wantStatus: http.StatusOK},
+		{name: "valid credentials again", path: "/metrics", user: "prometheus", password: "secret",
+			wantStatus: http.StatusOK},
+		{name: "wrong password", path: "/metrics", user: "prometheus", password: "guess",
+			wantStatus: http.StatusUnauthorized},
+		{name: "unknown user", path: "/metrics", user: "admin", password: "secret", wantStatus: http.StatusUnauthorized},
+		{name: "no credentials", path: "/metrics", wantStatus: http.StatusUnauthorized},
+		{name: "readiness probe", path: "/readyz", wantStatus: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest("GET", tt.path, nil)
+		if tt.user != "" {
+			req.SetBasicAuth(tt.user, tt.password)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+
+		if rec.Code != tt.wantStatus {
+			t.Errorf("%s: status = %d, want %d", tt.name, rec.Code, tt.wantStatus)
+		}
+	}
+}
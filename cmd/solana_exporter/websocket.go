@@ -0,0 +1,132 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"sync"
+	"time"
+
+	"github.com/certusone/solana_exporter/pkg/rpc"
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/klog/v2"
+)
+
+var wsAddr = flag.String("ws-uri", "",
+	"Solana PubSub WebSocket URI (ws:// or wss://) to follow slots and votes in real time, disabled if empty")
+
+// Delay before a failed subscription is opened again.
+const wsRetryInterval = 5 * time.Second
+
+var (
+	wsSlot = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "solana_ws_slot",
+		Help: "Latest slot the node started processing, from slotSubscribe",
+	})
+
+	wsRootSlot = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "solana_ws_root_slot",
+		Help: "Latest root of the node, from slotSubscribe",
+	})
+
+	wsLastVote = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "solana_ws_validator_last_vote",
+			Help: "Latest slot the watched validator voted on, from voteSubscribe",
+		},
+		[]string{"pubkey"})
+
+	wsVoteLag = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "solana_ws_validator_vote_lag_slots",
+			Help: "Number of slots the latest vote of the watched validator is behind solana_ws_slot",
+		},
+		[]string{"pubkey"})
+)
+
+func registerWSMetrics(r prometheus.Registerer) {
+	r.MustRegister(wsSlot)
+	r.MustRegister(wsRootSlot)
+	r.MustRegister(wsLastVote)
+	r.MustRegister(wsVoteLag)
+}
+
+// wsVotes holds the latest vote of each watched validator seen on the WebSocket.
+type wsVotes struct {
+	mu    sync.Mutex
+	votes map[string]int64
+	// Runtime config the votes are filtered with, reloaded on every slot rather than for each of the many votes.
+	cfg runtimeConfig
+}
+
+func newWSVotes() *wsVotes {
+	return &wsVotes{votes: make(map[string]int64), cfg: loadRuntimeConfig()}
+}
+
+func (v *wsVotes) observeVote(vote rpc.Vote) {
+	if len(vote.Slots) == 0 || vote.VotePubkey == "" {
+		return
+	}
+
+	slot := vote.Slots[len(vote.Slots)-1]
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if !v.cfg.isWatched(vote.VotePubkey) {
+		return
+	}
+	if slot > v.votes[vote.VotePubkey] {
+		v.votes[vote.VotePubkey] = slot
+		wsLastVote.WithLabelValues(vote.VotePubkey).Set(float64(slot))
+	}
+}
+
+// observeSlot updates the slots and the vote lag of the watched validators. Votes are filtered with the runtime
+// config reloaded here from then on, and validators no longer watched are dropped along with their series.
+func (v *wsVotes) observeSlot(info rpc.SlotInfo) {
+	wsSlot.Set(float64(info.Slot))
+	wsRootSlot.Set(float64(info.Root))
+
+	cfg := loadRuntimeConfig()
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.cfg = cfg
+	for pubkey, vote := range v.votes {
+		if !cfg.isWatched(pubkey) {
+			delete(v.votes, pubkey)
+			wsLastVote.DeleteLabelValues(pubkey)
+			wsVoteLag.DeleteLabelValues(pubkey)
+			continue
+		}
+		wsVoteLag.WithLabelValues(pubkey).Set(float64(info.Slot - vote))
+	}
+}
+
+// WatchWebSocket follows slot and vote notifications until ctx is canceled, which update their metrics as they
+// come in rather than on scrape. Failed subscriptions are opened again after wsRetryInterval.
+func (c *solanaCollector) WatchWebSocket(ctx context.Context, client *rpc.WSClient) {
+	votes := newWSVotes()
+
+	go keepSubscribed(ctx, "slotSubscribe", func() error {
+		return client.SubscribeSlots(ctx, votes.observeSlot)
+	})
+
+	keepSubscribed(ctx, "voteSubscribe", func() error {
+		return client.SubscribeVotes(ctx, votes.observeVote)
+	})
+}
+
+func keepSubscribed(ctx context.Context, method string, subscribe func() error) {
+	for {
+		err := subscribe()
+		if ctx.Err() != nil {
+			return
+		}
+
+		klog.Warningf("%s failed, resubscribing in %v: %v", method, wsRetryInterval, err)
+		select {
+		case <-time.After(wsRetryInterval):
+		case <-ctx.Done():
+			return
+		}
+	}
+}
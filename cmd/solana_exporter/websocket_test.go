@@ -0,0 +1,110 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/certusone/solana_exporter/pkg/rpc"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestWSVotes(t *testing.T) {
+	defer func(v string) { *votePubkey = v }(*votePubkey)
+	*votePubkey = "vote1"
+	defer wsLastVote.Reset()
+	defer wsVoteLag.Reset()
+
+	votes := newWSVotes()
+	votes.observeVote(rpc.Vote{VotePubkey: "vote1", Slots: []int64{98, 99}})
+	// Votes may arrive out of order, an older one doesn't move the latest vote back.
+	votes.observeVote(rpc.Vote{VotePubkey: "vote1", Slots: []int64{97}})
+	votes.observeVote(rpc.Vote{VotePubkey: "vote3", Slots: []int64{99}})
+	votes.observeVote(rpc.Vote{Slots: []int64{100}})
+	votes.observeSlot(rpc.SlotInfo{Parent: 99, Root: 68, Slot: 100})
+
+	if got := testutil.ToFloat64(wsSlot); got != 100 {
+		t.Errorf("solana_ws_slot = %v, want 100", got)
+	}
+	if got := testutil.ToFloat64(wsRootSlot); got != 68 {
+		t.Errorf("solana_ws_root_slot = %v, want 68", got)
+	}
+	if got := testutil.ToFloat64(wsLastVote.WithLabelValues("vote1")); got != 99 {
+		t.Errorf("solana_ws_validator_last_vote = %v, want 99", got)
+	}
+	if got := testutil.ToFloat64(wsVoteLag.WithLabelValues("vote1")); got != 1 {
+		t.Errorf("solana_ws_validator_vote_lag_slots = %v, want 1", got)
+	}
+	if got := testutil.CollectAndCount(wsLastVote); got != 1 {
+		t.Errorf("got %d last vote series, want only the watched validator's", got)
+	}
+}
+
+// Validators dropped from the watched ones by a reload lose their votes and series on the next slot.
+
+func TestWSVotesPrunedOnReload(t *testing.T) {
+	defer func(v string) { *votePubkey = v }(*votePubkey)
+	*votePubkey = "vote1,vote2"
+	defer wsLastVote.Reset()
+	defer wsVoteLag.Reset()
+
+	votes := newWSVotes()
+	votes.observeVote(rpc.Vote{VotePubkey: "vote1", Slots: []int64{98, 99}})
+	votes.observeVote(rpc.Vote{VotePubkey: "vote2", Slots: []int64{97}})
+	votes.observeVote(rpc.Vote{VotePubkey: "vote3", Slots: []int64{99}})
+	votes.observeSlot(rpc.SlotInfo{Slot: 100})
+
+	if got := testutil.CollectAndCount(wsVoteLag); got != 2 {
+		t.Fatalf("got %d vote lag series before the reload, want 2", got)
+	}
+
+	configMu.Lock()
+	err := applyConfig(map[string]string{"votepubkey": "vote1"}, true)
+	configMu.Unlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	votes.observeSlot(rpc.SlotInfo{Slot: 101})
+	votes.observeVote(rpc.Vote{VotePubkey: "vote2", Slots: []int64{100}})
+
+	var pubkeys []string
+	for pubkey := range votes.votes {
+		pubkeys = append(pubkeys, pubkey)
+	}
+	sort.Strings(pubkeys)
+	if !reflect.DeepEqual(pubkeys, []string{"vote1"}) {
+		t.Errorf("votes kept for %v, want [vote1]", pubkeys)
+	}
+	if got := testutil.CollectAndCount(wsLastVote); got != 1 {
+		t.Errorf("got %d last vote series after the reload, want 1", got)
+	}
+	if got := testutil.ToFloat64(wsVoteLag.WithLabelValues("vote1")); got != 2 {
+		t.Errorf("vote lag of vote1 = %v, want 2", got)
+	}
+}
+
+func TestKeepSubscribedStopsOnShutdown(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	subscribed := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		keepSubscribed(ctx, "slotSubscribe", func() error {
+			close(subscribed)
+			<-ctx.Done()
+			return errors.New("connection closed")
+		})
+		close(done)
+	}()
+
+	<-subscribed
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Error("keepSubscribed didn't return on shutdown")
+	}
+}
@@ -3,6 +3,11 @@ module github.com/certusone/solana_exporter
 go 1.13
 
 require (
+	github.com/gorilla/websocket v1.4.2
 	github.com/prometheus/client_golang v1.4.0
+	github.com/prometheus/client_model v0.2.0
+	github.com/prometheus/common v0.9.1
+	golang.org/x/crypto v0.0.0-20200622213623-75b288015ac9
+	gopkg.in/yaml.v2 v2.2.5
 	k8s.io/klog/v2 v2.4.0
 )
@@ -0,0 +1,151 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+type (
+	// AccountData holds account data requested with jsonParsed encoding. The node falls back to base64 for
+	// accounts of programs it has no parser for, in which case only Raw and Encoding are set.
+	AccountData struct {
+		Program string
+		Type    string
+		Info    json.RawMessage
+		Space   int
+
+		Raw      string
+		Encoding string
+	}
+
+	parsedAccountData struct {
+		Program string `json:"program"`
+		Parsed  struct {
+			Type string          `json:"type"`
+			Info json.RawMessage `json:"info"`
+		} `json:"parsed"`
+		Space int `json:"space"`
+	}
+
+	AccountInfo struct {
+		Data       AccountData `json:"data"`
+		Executable bool        `json:"executable"`
+		Lamports   Int64       `json:"lamports"`
+		Owner      string      `json:"owner"`
+		RentEpoch  int64       `json:"rentEpoch"`
+	}
+
+	GetAccountInfoResponse struct {
+		Result struct {
+			Context struct {
+				Slot int64 `json:"slot"`
+			} `json:"context"`
+			Value *AccountInfo `json:"value"`
+		} `json:"result"`
+		Error rpcError `json:"error"`
+	}
+
+	AuthorizedVoter struct {
+		AuthorizedVoter string `json:"authorizedVoter"`
+		Epoch           int64  `json:"epoch"`
+	}
+
+	VoteAccountState struct {
+		AuthorizedVoters     []AuthorizedVoter `json:"authorizedVoters"`
+		AuthorizedWithdrawer string            `json:"authorizedWithdrawer"`
+		Commission           int               `json:"commission"`
+		NodePubkey           string            `json:"nodePubkey"`
+	}
+
+	TokenAmount struct {
+		// Raw amount without decimals, as a string to avoid precision loss
+		Amount   string `json:"amount"`
+		Decimals int    `json:"decimals"`
+		// Amount with decimals applied
+		UIAmountString string `json:"uiAmountString"`
+	}
+
+	TokenAccountState struct {
+		Mint        string      `json:"mint"`
+		Owner       string      `json:"owner"`
+		State       string      `json:"state"`
+		IsNative    bool        `json:"isNative"`
+		TokenAmount TokenAmount `json:"tokenAmount"`
+	}
+)
+
+func (d *AccountData) UnmarshalJSON(b []byte) error {
+	// Unparsed data is returned as [data, encoding].
+	var raw []string
+	if err := json.Unmarshal(b, &raw); err == nil {
+		if len(raw) != 2 {
+			return fmt.Errorf("unexpected account data %s", string(b))
+		}
+		*d = AccountData{Raw: raw[0], Encoding: raw[1]}
+		return nil
+	}
+
+	var parsed parsedAccountData
+	if err := json.Unmarshal(b, &parsed); err != nil {
+		return err
+	}
+
+	*d = AccountData{
+		Program:  parsed.Program,
+		Type:     parsed.Parsed.Type,
+		Info:     parsed.Parsed.Info,
+		Space:    parsed.Space,
+		Encoding: "jsonParsed",
+	}
+	return nil
+}
+
+// https://docs.solana.com/developing/clients/jsonrpc-api#getaccountinfo
+func (c *RPCClient) GetAccountInfo(ctx context.Context, pubkey string, commitment Commitment) (*AccountInfo, error) {
+	params := []interface{}{pubkey, map[string]string{"encoding": "jsonParsed", "commitment": string(commitment)}}
+
+	var resp GetAccountInfoResponse
+	if err := c.rpcRequest(ctx, formatRPCRequest("getAccountInfo", params), &resp); err != nil {
+		return nil, err
+	}
+
+	if resp.Error.Code != 0 {
+		return nil, newRPCError("getAccountInfo", resp.Error)
+	}
+	observeContextSlot("getAccountInfo", resp.Result.Context.Slot)
+
+	if resp.Result.Value == nil {
+		return nil, fmt.Errorf("account %s not found", pubkey)
+	}
+
+	return resp.Result.Value, nil
+}
+
+// VoteState decodes the parsed data of a vote program account.
+func (a *AccountInfo) VoteState() (*VoteAccountState, error) {
+	if a.Data.Program != "vote" {
+		return nil, fmt.Errorf("not a vote account (program %q)", a.Data.Program)
+	}
+
+	var state VoteAccountState
+	if err := json.Unmarshal(a.Data.Info, &state); err != nil {
+		return nil, fmt.Errorf("failed to decode vote account state: %w", err)
+	}
+
+	return &state, nil
+}
+
+// TokenAccount decodes the parsed data of an SPL token account.
+func (a *AccountInfo) TokenAccount() (*TokenAccountState, error) {
+	if a.Data.Program != "spl-token" && a.Data.Program != "spl-token-2022" || a.Data.Type != "account" {
+		return nil, fmt.Errorf("not a token account (program %q, type %q)", a.Data.Program, a.Data.Type)
+	}
+
+	var state TokenAccountState
+	if err := json.Unmarshal(a.Data.Info, &state); err != nil {
+		return nil, fmt.Errorf("failed to decode token account state: %w", err)
+	}
+
+	return &state, nil
+}
@@ -0,0 +1,90 @@
+package rpc
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestAccountDataUnmarshal(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		want    AccountData
+		wantErr bool
+	}{
+		{
+			name: "jsonParsed",
+			data: `{"program":"vote","parsed":{"type":"vote","info":{"commission":5}},"space":3731}`,
+			want: AccountData{Program: "vote", Type: "vote", Info: json.RawMessage(`{"commission":5}`), Space: 3731,
+				Encoding: "jsonParsed"},
+		},
+		{
+			name: "base64 fallback",
+			data: `["AQIDBA==","base64"]`,
+			want: AccountData{Raw: "AQIDBA==", Encoding: "base64"},
+		},
+		{name: "malformed fallback", data: `["AQIDBA=="]`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got AccountData
+			err := json.Unmarshal([]byte(tt.data), &got)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Unmarshal() error = %v, want error %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if got.Program != tt.want.Program || got.Type != tt.want.Type || string(got.Info) != string(tt.want.Info) ||
+				got.Space != tt.want.Space || got.Raw != tt.want.Raw || got.Encoding != tt.want.Encoding {
+				t.Errorf("Unmarshal() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTokenAccount(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     string
+		wantMint string
+		wantErr  bool
+	}{
+		{
+			name: "spl-token",
+			data: `{"program":"spl-token","parsed":{"type":"account","info":{"mint":"mint1","owner":"owner1",` +
+				`"state":"initialized","tokenAmount":{"amount":"1500000","decimals":6,"uiAmountString":"1.5"}}},"space":165}`,
+			wantMint: "mint1",
+		},
+		{
+			name: "spl-token-2022",
+			data: `{"program":"spl-token-2022","parsed":{"type":"account","info":{"mint":"mint2","owner":"owner1",` +
+				`"tokenAmount":{"amount":"1","decimals":0,"uiAmountString":"1"}}},"space":170}`,
+			wantMint: "mint2",
+		},
+		{
+			name:    "token mint",
+			data:    `{"program":"spl-token","parsed":{"type":"mint","info":{"decimals":6}},"space":82}`,
+			wantErr: true,
+		},
+		{name: "unparsed", data: `["AQIDBA==","base64"]`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var info AccountInfo
+			if err := json.Unmarshal([]byte(tt.data), &info.Data); err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := info.TokenAccount()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("TokenAccount() error = %v, want error %v", err, tt.wantErr)
+			}
+			if err == nil && got.Mint != tt.wantMint {
+				t.Errorf("mint = %q, want %q", got.Mint, tt.wantMint)
+			}
+		})
+	}
+}
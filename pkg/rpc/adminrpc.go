@@ -0,0 +1,97 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"time"
+)
+
+type (
+	// AdminClient talks to the admin RPC a validator serves on a unix socket in its ledger directory
+	// (admin.rpc). It uses the same JSON-RPC framing as the HTTP API, one request per connection.
+	AdminClient struct {
+		socketPath string
+	}
+
+	// systemTime is how the validator serializes a point in time.
+	systemTime struct {
+		Secs  int64 `json:"secs_since_epoch"`
+		Nanos int64 `json:"nanos_since_epoch"`
+	}
+
+	adminResponse struct {
+		Result json.RawMessage `json:"result"`
+		Error  rpcError        `json:"error"`
+	}
+)
+
+func NewAdminClient(socketPath string) *AdminClient {
+	return &AdminClient{socketPath: socketPath}
+}
+
+func (c *AdminClient) call(ctx context.Context, method string, v interface{}) error {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "unix", c.socketPath)
+	if err != nil {
+		return fmt.Errorf("admin RPC call failed: %w", err)
+	}
+	defer conn.Close()
+
+	if deadline, ok := ctx.Deadline(); ok {
+		if err := conn.SetDeadline(deadline); err != nil {
+			return err
+		}
+	}
+
+	if _, err := io.Copy(conn, formatRPCRequest(method, []interface{}{})); err != nil {
+		return fmt.Errorf("admin RPC call failed: %w", err)
+	}
+
+	var resp adminResponse
+	if err := json.NewDecoder(conn).Decode(&resp); err != nil {
+		return fmt.Errorf("failed to decode admin RPC response: %w", err)
+	}
+
+	if resp.Error.Code != 0 {
+		return fmt.Errorf("admin RPC error: %d %v", resp.Error.Code, resp.Error.Message)
+	}
+
+	return json.Unmarshal(resp.Result, v)
+}
+
+// StartTime returns when the validator process started.
+func (c *AdminClient) StartTime(ctx context.Context) (time.Time, error) {
+	var t systemTime
+	if err := c.call(ctx, "startTime", &t); err != nil {
+		return time.Time{}, err
+	}
+
+	return time.Unix(t.Secs, t.Nanos), nil
+}
+
+// StartProgress returns the startup stage the validator is in, e.g. "DownloadingSnapshot" or "Running".
+// Stages carrying details are reported by name only.
+func (c *AdminClient) StartProgress(ctx context.Context) (string, error) {
+	var raw json.RawMessage
+	if err := c.call(ctx, "startProgress", &raw); err != nil {
+		return "", err
+	}
+
+	var stage string
+	if err := json.Unmarshal(raw, &stage); err == nil {
+		return stage, nil
+	}
+
+	var detailed map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &detailed); err != nil || len(detailed) != 1 {
+		return "", fmt.Errorf("unexpected start progress %s", raw)
+	}
+	for stage := range detailed {
+		return stage, nil
+	}
+
+	return "", nil
+}
@@ -0,0 +1,107 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// serveAdmin answers admin RPC requests on a unix socket with the canned result of each method and returns
+// the socket path. Methods without a result are answered with "method not found".
+func serveAdmin(t *testing.T, results map[string]string) string {
+	t.Helper()
+
+	// Unix socket paths are limited to around 100 bytes, which t.TempDir() can exceed.
+	dir, err := ioutil.TempDir("", "admin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	path := filepath.Join(dir, "admin.rpc")
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+
+			var req struct {
+				Method string `json:"method"`
+			}
+			if err := json.NewDecoder(conn).Decode(&req); err == nil {
+				if result, ok := results[req.Method]; ok {
+					_, _ = conn.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":` + result + `}`))
+				} else {
+					_, _ = conn.Write([]byte(`{"jsonrpc":"2.0","id":1,"error":{"code":-32601,"message":"Method not found"}}`))
+				}
+			}
+			conn.Close()
+		}
+	}()
+
+	return path
+}
+
+func TestAdminStartTime(t *testing.T) {
+	c := NewAdminClient(serveAdmin(t, map[string]string{
+		"startTime": `{"secs_since_epoch":1600000000,"nanos_since_epoch":500}`,
+	}))
+
+	got, err := c.StartTime(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := time.Unix(1600000000, 500); !got.Equal(want) {
+		t.Errorf("StartTime() = %v, want %v", got, want)
+	}
+}
+
+func TestAdminStartProgress(t *testing.T) {
+	tests := []struct {
+		result  string
+		want    string
+		wantErr bool
+	}{
+		{result: `"Running"`, want: "Running"},
+		{result: `{"DownloadingSnapshot":{"slot":100,"rpc_addr":"127.0.0.1:8899"}}`, want: "DownloadingSnapshot"},
+		{result: `{"A":1,"B":2}`, wantErr: true},
+		{result: `42`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		c := NewAdminClient(serveAdmin(t, map[string]string{"startProgress": tt.result}))
+
+		got, err := c.StartProgress(context.Background())
+		if (err != nil) != tt.wantErr {
+			t.Errorf("StartProgress() with %s: error = %v, want error %v", tt.result, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("StartProgress() with %s = %q, want %q", tt.result, got, tt.want)
+		}
+	}
+}
+
+func TestAdminErrors(t *testing.T) {
+	c := NewAdminClient(serveAdmin(t, nil))
+	if _, err := c.StartTime(context.Background()); err == nil {
+		t.Error("StartTime() succeeded for an unknown method")
+	}
+
+	c = NewAdminClient(filepath.Join(os.TempDir(), "no-such-admin.rpc"))
+	if _, err := c.StartTime(context.Background()); err == nil {
+		t.Error("StartTime() succeeded without a socket")
+	}
+}
@@ -0,0 +1,75 @@
+package rpc
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestIsAuthError(t *testing.T) {
+	tests := []struct {
+		err  rpcError
+		want bool
+	}{
+		{err: rpcError{Code: -32600, Message: "Unauthorized"}, want: true},
+		{err: rpcError{Code: -32603, Message: "method not allowed for this API key"}, want: true},
+		{err: rpcError{Code: -32052, Message: "API key is not valid"}, want: true},
+		{err: rpcError{Code: -32000, Message: "Method getVoteAccounts is not allowed on this plan"}, want: true},
+		{err: rpcError{Code: -32000, Message: "Invalid API key"}, want: true},
+		{err: rpcError{Code: -32005, Message: "Node is behind by 120 slots"}, want: false},
+		{err: rpcError{Code: -32602, Message: "Invalid params"}, want: false},
+		// Nodes answer methods they don't serve with this whatever the key.
+		{err: rpcError{Code: -32601, Message: "Method not found"}, want: false},
+	}
+
+	for _, tt := range tests {
+		if got := isAuthError(tt.err); got != tt.want {
+			t.Errorf("isAuthError(%d %q) = %v, want %v", tt.err.Code, tt.err.Message, got, tt.want)
+		}
+	}
+}
+
+func TestAuthErrorsCounted(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		body   string
+		want   float64
+	}{
+		{name: "HTTP 401", status: http.StatusUnauthorized, want: 1},
+		{name: "HTTP 403", status: http.StatusForbidden, want: 1},
+		{
+			name:   "JSON-RPC not allowed",
+			status: http.StatusOK,
+			body:   `{"jsonrpc":"2.0","id":1,"error":{"code":-32000,"message":"Method not allowed"}}`,
+			want:   1,
+		},
+		{
+			name:   "JSON-RPC node error",
+			status: http.StatusOK,
+			body:   `{"jsonrpc":"2.0","id":1,"error":{"code":-32005,"message":"Node is unhealthy"}}`,
+			want:   0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer srv.Close()
+
+			before := testutil.ToFloat64(authErrorsTotal)
+			if _, err := NewRPCClient(srv.URL).GetIdentity(context.Background()); err == nil {
+				t.Fatal("GetIdentity succeeded, want an error")
+			}
+			if got := testutil.ToFloat64(authErrorsTotal) - before; got != tt.want {
+				t.Errorf("counted %v authorization errors, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -2,8 +2,6 @@ package rpc
 
 import (
 	"context"
-	"encoding/json"
-	"fmt"
 )
 
 type (
@@ -12,7 +10,7 @@ type (
 			ApiVersion string `json:"apiVersion"`
 			Slot       int    `json:"slot"`
 		} `json:"context"`
-		Value int `json:"value"`
+		Value Int64 `json:"value"`
 	}
 
 	GetBalanceResponse struct {
@@ -23,19 +21,15 @@ type (
 
 // https://docs.solana.com/developing/clients/jsonrpc-api#getbalance
 func (c *RPCClient) GetBalance(ctx context.Context, params []interface{}) (*GetBalanceResponse, error) {
-	body, err := c.rpcRequest(ctx, formatRPCRequest("getBalance", params))
-	if err != nil {
-		return nil, fmt.Errorf("RPC call failed: %v", err)
-	}
-
 	var resp GetBalanceResponse
-	if err = json.Unmarshal(body, &resp); err != nil {
-		return nil, fmt.Errorf("failed to decode response body: %w", err)
+	if err := c.rpcRequest(ctx, formatRPCRequest("getBalance", params), &resp); err != nil {
+		return nil, err
 	}
 
 	if resp.Error.Code != 0 {
-		return nil, fmt.Errorf("RPC error: %d %v", resp.Error.Code, resp.Error.Message)
+		return nil, newRPCError("getBalance", resp.Error)
 	}
+	observeContextSlot("getBalance", int64(resp.Result.Context.Slot))
 
 	return &resp, nil
 }
@@ -0,0 +1,61 @@
+package rpc
+
+import (
+	"context"
+)
+
+const (
+	// JSON-RPC error codes for slots without a block, or whose block was purged from the node's ledger.
+	rpcCodeSlotSkipped             = -32007
+	rpcCodeLongTermStorageSlotSkip = -32009
+)
+
+type (
+	Reward struct {
+		Pubkey      string `json:"pubkey"`
+		Lamports    Int64  `json:"lamports"`
+		PostBalance Int64  `json:"postBalance"`
+		// Fee, Rent, Voting or Staking
+		RewardType string `json:"rewardType"`
+		Commission *int   `json:"commission"`
+	}
+
+	GetBlockResponse struct {
+		Result *struct {
+			Rewards []Reward `json:"rewards"`
+		} `json:"result"`
+		Error rpcError `json:"error"`
+	}
+)
+
+// GetBlockRewards returns the rewards credited in the block of slot, without any transactions. It returns
+// ok=false if the slot was skipped or its block is no longer available.
+//
+// https://docs.solana.com/developing/clients/jsonrpc-api#getblock
+func (c *RPCClient) GetBlockRewards(ctx context.Context, slot int64, commitment Commitment) ([]Reward, bool, error) {
+	config := map[string]interface{}{
+		"commitment":                     string(commitment),
+		"transactionDetails":             "none",
+		"rewards":                        true,
+		"maxSupportedTransactionVersion": 0,
+	}
+
+	var resp GetBlockResponse
+	if err := c.rpcRequest(ctx, formatRPCRequest("getBlock", []interface{}{slot, config}), &resp); err != nil {
+		return nil, false, err
+	}
+
+	switch resp.Error.Code {
+	case 0:
+	case rpcCodeSlotSkipped, rpcCodeLongTermStorageSlotSkip:
+		return nil, false, nil
+	default:
+		return nil, false, newRPCError("getBlock", resp.Error)
+	}
+
+	if resp.Result == nil {
+		return nil, false, nil
+	}
+
+	return resp.Result.Rewards, true, nil
+}
@@ -2,8 +2,6 @@ package rpc
 
 import (
 	"context"
-	"encoding/json"
-	"fmt"
 )
 
 type (
@@ -31,19 +29,31 @@ type (
 
 // https://docs.solana.com/developing/clients/jsonrpc-api#getblockproduction
 func (c *RPCClient) GetBlockProduction(ctx context.Context, params []interface{}) (*GetBlockProductionResponse, error) {
-	body, err := c.rpcRequest(ctx, formatRPCRequest("getBlockProduction", params))
-	if err != nil {
-		return nil, fmt.Errorf("RPC call failed: %v", err)
-	}
-
 	var resp GetBlockProductionResponse
-	if err = json.Unmarshal(body, &resp); err != nil {
-		return nil, fmt.Errorf("failed to decode response body: %w", err)
+	if err := c.rpcRequest(ctx, formatRPCRequest("getBlockProduction", params), &resp); err != nil {
+		return nil, err
 	}
 
 	if resp.Error.Code != 0 {
-		return nil, fmt.Errorf("RPC error: %d %v", resp.Error.Code, resp.Error.Message)
+		return nil, newRPCError("getBlockProduction", resp.Error)
 	}
+	observeContextSlot("getBlockProduction", int64(resp.Result.Context.Slot))
 
 	return &resp, nil
 }
+
+// Totals sums the leader slots and produced blocks over all identities.
+func (r BlockResult) Totals() (leaderSlots, producedSlots int) {
+	for _, val := range r {
+		leaderSlots += val[0]
+		producedSlots += val[1]
+	}
+
+	return leaderSlots, producedSlots
+}
+
+// RangeSlots returns the number of slots covered by the block production range, which can be less than the
+// full epoch early on.
+func (p BlockProduction) RangeSlots() int {
+	return p.Value.Range.LastSlot - p.Value.Range.FirstSlot + 1
+}
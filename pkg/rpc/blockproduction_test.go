@@ -0,0 +1,46 @@
+package rpc
+
+import "testing"
+
+func TestBlockResultTotals(t *testing.T) {
+	tests := []struct {
+		name         string
+		byIdentity   BlockResult
+		wantLeader   int
+		wantProduced int
+	}{
+		{name: "empty"},
+		{name: "one identity", byIdentity: BlockResult{"node1": {4, 3}}, wantLeader: 4, wantProduced: 3},
+		{
+			name:       "several identities",
+			byIdentity: BlockResult{"node1": {4, 3}, "node2": {8, 8}, "node3": {12, 0}},
+			wantLeader: 24, wantProduced: 11,
+		},
+	}
+
+	for _, tt := range tests {
+		leader, produced := tt.byIdentity.Totals()
+		if leader != tt.wantLeader || produced != tt.wantProduced {
+			t.Errorf("%s: Totals() = %d, %d, want %d, %d", tt.name, leader, produced, tt.wantLeader, tt.wantProduced)
+		}
+	}
+}
+
+func TestBlockProductionRangeSlots(t *testing.T) {
+	tests := []struct {
+		first, last int
+		want        int
+	}{
+		// Right after the epoch boundary only the first slot is covered.
+		{first: 864000, last: 864000, want: 1},
+		{first: 864000, last: 864099, want: 100},
+	}
+
+	for _, tt := range tests {
+		var p BlockProduction
+		p.Value.Range.FirstSlot, p.Value.Range.LastSlot = tt.first, tt.last
+		if got := p.RangeSlots(); got != tt.want {
+			t.Errorf("RangeSlots() of %d-%d = %d, want %d", tt.first, tt.last, got, tt.want)
+		}
+	}
+}
@@ -2,9 +2,6 @@ package rpc
 
 import (
 	"context"
-	"encoding/json"
-	"fmt"
-	"k8s.io/klog/v2"
 )
 
 type (
@@ -16,20 +13,13 @@ type (
 
 // https://docs.solana.com/developing/clients/jsonrpc-api#getblocktime
 func (c *RPCClient) GetBlockTime(ctx context.Context, slot int64) (int64, error) {
-	body, err := c.rpcRequest(ctx, formatRPCRequest("getBlockTime", []interface{}{slot}))
-	if err != nil {
-		return 0, fmt.Errorf("RPC call failed: %w", err)
-	}
-
-	klog.V(2).Infof("getBlockTime response: %v", string(body))
-
 	var resp GetBlockTimeResponse
-	if err = json.Unmarshal(body, &resp); err != nil {
-		return 0, fmt.Errorf("failed to decode response body: %w", err)
+	if err := c.rpcRequest(ctx, formatRPCRequest("getBlockTime", []interface{}{slot}), &resp); err != nil {
+		return 0, err
 	}
 
 	if resp.Error.Code != 0 {
-		return 0, fmt.Errorf("RPC error: %d %v", resp.Error.Code, resp.Error.Message)
+		return 0, newRPCError("getBlockTime", resp.Error)
 	}
 
 	return resp.Result, nil
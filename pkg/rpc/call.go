@@ -0,0 +1,30 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+)
+
+type CallResponse struct {
+	Result json.RawMessage `json:"result"`
+	Error  rpcError        `json:"error"`
+}
+
+// Call sends an arbitrary JSON-RPC request and returns the undecoded result, for methods without a typed
+// wrapper in this package.
+func (c *RPCClient) Call(ctx context.Context, method string, params []interface{}) (json.RawMessage, error) {
+	if params == nil {
+		params = []interface{}{}
+	}
+
+	var resp CallResponse
+	if err := c.rpcRequest(ctx, formatRPCRequest(method, params), &resp); err != nil {
+		return nil, err
+	}
+
+	if resp.Error.Code != 0 {
+		return nil, newRPCError(method, resp.Error)
+	}
+
+	return resp.Result, nil
+}
@@ -0,0 +1,20 @@
+package rpc
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+type callCounterKey struct{}
+
+// WithCallCounter returns a context that counts the HTTP requests sent with it or any context derived from
+// it in n, including retries.
+func WithCallCounter(ctx context.Context, n *uint64) context.Context {
+	return context.WithValue(ctx, callCounterKey{}, n)
+}
+
+func countCall(ctx context.Context) {
+	if n, ok := ctx.Value(callCounterKey{}).(*uint64); ok {
+		atomic.AddUint64(n, 1)
+	}
+}
@@ -0,0 +1,46 @@
+package rpc
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestWithCallCounter(t *testing.T) {
+	var requests int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		requests++
+		if requests == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		_, _ = w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":"ok"}`))
+	}))
+	defer srv.Close()
+
+	c := NewRPCClient(srv.URL, WithRetries(2))
+
+	var n uint64
+	ctx, cancel := context.WithCancel(WithCallCounter(context.Background(), &n))
+	defer cancel()
+
+	// The first call is retried once and both requests count, also through a derived context.
+	if _, err := c.GetHealth(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.GetHealth(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if n != 3 {
+		t.Errorf("counted %d requests, want 3", n)
+	}
+
+	// Calls without a counter aren't counted anywhere.
+	if _, err := c.GetHealth(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if n != 3 {
+		t.Errorf("counted %d requests after a call without counter, want 3", n)
+	}
+}
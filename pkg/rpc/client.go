@@ -4,21 +4,44 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"fmt"
 	"io"
 	"io/ioutil"
 	"k8s.io/klog/v2"
 	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
 )
 
 type (
 	RPCClient struct {
-		httpClient http.Client
-		rpcAddr    string
+		httpClient   http.Client
+		rpcAddr      string
+		maxBodyBytes int64
+		retries      int
+		// Endpoints to fail over to, in order, after the primary one at rpcAddr.
+		fallbackAddrs []string
+		// Index into the primary and fallback endpoints of the one requests are sent to.
+		active int32
+		// Longest Retry-After that is honored, zero to always use the regular backoff.
+		maxRetryAfter time.Duration
+		// Source of the bearer token, nil if requests aren't authenticated.
+		tokenFile *TokenFile
+		// Additional headers sent with every request, like the API keys of RPC providers.
+		headers http.Header
+		// Whether the full vote account set is fetched as separate calls for current and delinquent accounts.
+		splitVoteAccounts bool
 	}
 
+	// Option configures optional behaviour of an RPCClient.
+	Option func(*RPCClient)
+
 	rpcError struct {
-		Message string `json:"message"`
-		Code    int64  `json:"id"`
+		Message string          `json:"message"`
+		Code    int64           `json:"code"`
+		Data    json.RawMessage `json:"data"`
 	}
 
 	rpcRequest struct {
@@ -28,6 +51,12 @@ type (
 		Params  []interface{} `json:"params"`
 	}
 
+	// requestBody is an encoded JSON-RPC request, along with its method for metrics.
+	requestBody struct {
+		*bytes.Reader
+		method string
+	}
+
 	Commitment string
 )
 
@@ -35,6 +64,7 @@ func (c Commitment) MarshalJSON() ([]byte, error) {
 	return json.Marshal(map[string]string{"commitment": string(c)})
 }
 
+// Deprecated commitment levels, which newer nodes reject. ParseCommitment maps them onto the current ones.
 const (
 	// Most recent block confirmed by supermajority of the cluster as having reached maximum lockout.
 	CommitmentMax Commitment = "max"
@@ -46,16 +76,121 @@ const (
 	CommitmentRecent Commitment = "recent"
 )
 
-func NewRPCClient(rpcAddr string) *RPCClient {
+const (
+	// The node will query its most recent block. Note that the block may still be skipped by the cluster.
+	CommitmentProcessed Commitment = "processed"
+	// Most recent block that has been voted on by supermajority of the cluster.
+	CommitmentConfirmed Commitment = "confirmed"
+	// Most recent block confirmed by supermajority of the cluster as having reached maximum lockout.
+	CommitmentFinalized Commitment = "finalized"
+)
+
+// ParseCommitment validates a commitment level name. The deprecated names are accepted for backwards
+// compatibility and mapped onto their current equivalents.
+func ParseCommitment(s string) (Commitment, error) {
+	switch Commitment(s) {
+	case CommitmentProcessed, CommitmentRecent:
+		return CommitmentProcessed, nil
+	case CommitmentConfirmed, CommitmentSingleGossip:
+		return CommitmentConfirmed, nil
+	case CommitmentFinalized, CommitmentMax, CommitmentRoot:
+		return CommitmentFinalized, nil
+	}
+
+	return "", fmt.Errorf("unknown commitment level %q", s)
+}
+
+// newRPCError converts a JSON-RPC error object returned for method into an error, counting it along with
+// authorization failures.
+func newRPCError(method string, e rpcError) error {
+	class := classifyRPCError(e)
+	rpcErrorsTotal.WithLabelValues(string(class), method, strconv.FormatInt(e.Code, 10)).Inc()
+	if isAuthError(e) {
+		authErrorsTotal.Inc()
+	}
+
+	return newRequestError(class, fmt.Errorf("RPC error: %d %v", e.Code, e.Message))
+}
+
+// isAuthError reports whether a JSON-RPC error indicates a missing permission rather than a node problem.
+// Providers don't agree on an error code for rejected API keys, so only the message is checked. "Method not
+// found" isn't one, as nodes return it for methods they don't serve whatever the key.
+func isAuthError(e rpcError) bool {
+	msg := strings.ToLower(e.Message)
+	for _, s := range []string{"unauthorized", "forbidden", "not allowed", "api key"} {
+		if strings.Contains(msg, s) {
+			return true
+		}
+	}
+
+	return false
+}
+
+const (
+	// Default limit for the size of a response body.
+	DefaultMaxBodyBytes = 128 << 20
+
+	// Delay before the first retry, doubled on every further attempt.
+	retryBackoff = 100 * time.Millisecond
+
+	// Default limit for waiting as asked by a Retry-After header.
+	DefaultMaxRetryAfter = 10 * time.Second
+)
+
+// WithMaxBodyBytes limits the size of response bodies, protecting against unbounded responses.
+func WithMaxBodyBytes(n int64) Option {
+	return func(c *RPCClient) {
+		c.maxBodyBytes = n
+	}
+}
+
+// WithRetries sets how often a request failing with a retriable error class is sent again.
+func WithRetries(n int) Option {
+	return func(c *RPCClient) {
+		c.retries = n
+	}
+}
+
+// WithMaxRetryAfter sets the longest wait asked for by a Retry-After header on a rate limited request that is
+// honored before retrying. Longer waits are cut short, and zero ignores the header.
+func WithMaxRetryAfter(d time.Duration) Option {
+	return func(c *RPCClient) {
+		c.maxRetryAfter = d
+	}
+}
+
+// WithFallbacks sets endpoints to fail over to when requests to the active one fail with a timeout, connection,
+// rate limit or server error. Requests stick to an endpoint until it fails, then move on to the next one,
+// wrapping around to the primary endpoint after the last.
+func WithFallbacks(addrs ...string) Option {
+	return func(c *RPCClient) {
+		c.fallbackAddrs = addrs
+	}
+}
+
+// WithHeaders sends h with every request, e.g. for RPC providers authenticating with API key headers.
+func WithHeaders(h http.Header) Option {
+	return func(c *RPCClient) {
+		c.headers = h
+	}
+}
+
+func NewRPCClient(rpcAddr string, opts ...Option) *RPCClient {
 	c := &RPCClient{
-		httpClient: http.Client{},
-		rpcAddr:    rpcAddr,
+		httpClient:    http.Client{},
+		rpcAddr:       rpcAddr,
+		maxBodyBytes:  DefaultMaxBodyBytes,
+		maxRetryAfter: DefaultMaxRetryAfter,
+	}
+
+	for _, opt := range opts {
+		opt(c)
 	}
 
 	return c
 }
 
-func formatRPCRequest(method string, params []interface{}) io.Reader {
+func formatRPCRequest(method string, params []interface{}) *requestBody {
 	r := &rpcRequest{
 		Version: "2.0",
 		ID:      1,
@@ -69,26 +204,152 @@ func formatRPCRequest(method string, params []interface{}) io.Reader {
 	}
 
 	klog.V(2).Infof("jsonrpc request: %s", string(b))
-	return bytes.NewBuffer(b)
+	return &requestBody{Reader: bytes.NewReader(b), method: method}
+}
+
+// rpcRequest sends a JSON-RPC request and decodes the response into v, retrying failures of a retriable
+// error class with exponential backoff. If the endpoint asks to wait with a Retry-After header, that wait is
+// used instead, up to maxRetryAfter and the deadline of ctx. With fallback endpoints, each of them is tried
+// once before the first retry. Every attempt is counted and timed by method.
+func (c *RPCClient) rpcRequest(ctx context.Context, data *requestBody, v interface{}) error {
+	b, err := ioutil.ReadAll(data)
+	if err != nil {
+		panic(err)
+	}
+
+	backoff := retryBackoff
+	failovers := 0
+	for attempt := 0; ; {
+		addr := c.activeAddr()
+		start := time.Now()
+		err = c.doRequest(ctx, addr, b, v)
+		observeRequest(data.method, time.Since(start), err)
+		observeEndpoint(addr, !endpointFailed(err))
+		if err == nil || !retriable(ctx, err) {
+			return err
+		}
+
+		// Another endpoint may well answer right away, so failing over neither waits nor counts as a retry.
+		if failovers < len(c.fallbackAddrs) {
+			klog.Warningf("RPC request to %s failed with %s error, failing over: %v",
+				endpointHost(addr), ClassOf(err), err)
+			c.failover(addr)
+			failovers++
+			continue
+		}
+
+		if attempt >= c.retries {
+			return err
+		}
+
+		wait := backoff
+		if retryAfter := retryAfterOf(err); retryAfter > 0 && c.maxRetryAfter > 0 {
+			wait = retryAfter
+			if wait > c.maxRetryAfter {
+				wait = c.maxRetryAfter
+			}
+		}
+
+		klog.V(1).Infof("retrying RPC request in %v after %s error: %v", wait, ClassOf(err), err)
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return err
+		}
+		backoff *= 2
+		attempt++
+	}
+}
+
+// activeAddr returns the endpoint requests are currently sent to.
+func (c *RPCClient) activeAddr() string {
+	return c.addrAt(atomic.LoadInt32(&c.active))
+}
+
+// addrAt returns the primary endpoint for 0 and the fallback endpoints after it.
+func (c *RPCClient) addrAt(i int32) string {
+	if i == 0 {
+		return c.rpcAddr
+	}
+
+	return c.fallbackAddrs[i-1]
 }
 
-func (c *RPCClient) rpcRequest(ctx context.Context, data io.Reader) ([]byte, error) {
-	req, err := http.NewRequestWithContext(ctx, "POST", c.rpcAddr, data)
+// failover moves on to the endpoint after addr, unless a concurrent request already did.
+func (c *RPCClient) failover(addr string) {
+	i := atomic.LoadInt32(&c.active)
+	if c.addrAt(i) == addr {
+		atomic.CompareAndSwapInt32(&c.active, i, (i+1)%int32(len(c.fallbackAddrs)+1))
+	}
+}
+
+// doRequest sends a single request and decodes the response into v while it is streamed in, rather than
+// buffering the whole body first. This matters for large responses like getVoteAccounts on mainnet.
+func (c *RPCClient) doRequest(ctx context.Context, addr string, data []byte, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, "POST", addr, bytes.NewReader(data))
 	if err != nil {
 		panic(err)
 	}
+	for name, values := range c.headers {
+		req.Header[name] = values
+	}
 	req.Header.Set("content-type", "application/json")
+	if c.tokenFile != nil {
+		token, err := c.tokenFile.Token()
+		if err != nil {
+			return newRequestError(ErrorClassClient, err)
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
 
+	countCall(ctx)
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return nil, err
+		return newRequestError(classifyTransportError(err), fmt.Errorf("RPC call failed: %w", err))
 	}
 	defer resp.Body.Close()
 
-	body, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return nil, err
+	// Plain HTTP endpoints have no connection state.
+	observeTLS(req.URL.Host, resp.TLS)
+
+	if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden {
+		authErrorsTotal.Inc()
+	}
+
+	// JSON-RPC errors come with status 200, anything else is a failure of the HTTP layer.
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return newRateLimitError(parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()),
+			fmt.Errorf("RPC call failed: HTTP %s", resp.Status))
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return newStatusError(resp.StatusCode, fmt.Errorf("RPC call failed: HTTP %s", resp.Status))
+	}
+
+	// Read one byte past the limit to tell a body of exactly the limit from a larger one.
+	limited := &io.LimitedReader{R: resp.Body, N: c.maxBodyBytes + 1}
+
+	// Only keep a copy of the body if it is going to be logged.
+	var body io.Reader = limited
+	var raw bytes.Buffer
+	if klog.V(3).Enabled() {
+		body = io.TeeReader(limited, &raw)
+	}
+
+	if err := json.NewDecoder(body).Decode(v); err != nil {
+		if limited.N <= 0 {
+			return newRequestError(ErrorClassParse, fmt.Errorf("response body exceeds limit of %d bytes", c.maxBodyBytes))
+		}
+		return newRequestError(ErrorClassParse, fmt.Errorf("failed to decode response body: %w", err))
+	}
+
+	klog.V(3).Infof("jsonrpc response: %s", raw.String())
+
+	// Drain the rest of the body so the connection can be reused, but no further than the limit. A body
+	// that goes on past it is left unread, and the connection is closed along with it.
+	_, _ = io.Copy(ioutil.Discard, limited)
+	if limited.N <= 0 {
+		return newRequestError(ErrorClassParse, fmt.Errorf("response body exceeds limit of %d bytes", c.maxBodyBytes))
 	}
 
-	return body, nil
+	return nil
 }
@@ -0,0 +1,208 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestParseCommitment(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Commitment
+		wantErr bool
+	}{
+		{in: "processed", want: CommitmentProcessed},
+		{in: "confirmed", want: CommitmentConfirmed},
+		{in: "finalized", want: CommitmentFinalized},
+		// Deprecated names map onto their current equivalents.
+		{in: "recent", want: CommitmentProcessed},
+		{in: "singleGossip", want: CommitmentConfirmed},
+		{in: "max", want: CommitmentFinalized},
+		{in: "root", want: CommitmentFinalized},
+		{in: "", wantErr: true},
+		{in: "Processed", wantErr: true},
+		{in: "single", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseCommitment(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseCommitment(%q) error = %v, want error %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseCommitment(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestParseCommitmentSendsCurrentName(t *testing.T) {
+	c, err := ParseCommitment("recent")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := json.Marshal([]interface{}{c})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := string(b), `[{"commitment":"processed"}]`; got != want {
+		t.Errorf("params = %s, want %s", got, want)
+	}
+}
+
+func TestMaxBodyBytes(t *testing.T) {
+	const limit = 1024
+	response := func(size int) string {
+		envelope := `{"jsonrpc":"2.0","id":1,"result":""}`
+		return `{"jsonrpc":"2.0","id":1,"result":"` + strings.Repeat("x", size-len(envelope)) + `"}`
+	}
+
+	tests := []struct {
+		name    string
+		body    string
+		wantErr bool
+	}{
+		{name: "below the limit", body: response(limit / 2)},
+		{name: "exactly the limit", body: response(limit)},
+		{name: "one byte over the limit", body: response(limit + 1), wantErr: true},
+		{name: "far over the limit", body: response(100 * limit), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				_, _ = io.WriteString(w, tt.body)
+			}))
+			defer srv.Close()
+
+			c := NewRPCClient(srv.URL, WithMaxBodyBytes(limit), WithRetries(0))
+			_, err := c.Call(context.Background(), "getSlot", nil)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Call() error = %v, want error %v", err, tt.wantErr)
+			}
+			if err != nil {
+				if ClassOf(err) != ErrorClassParse || !strings.Contains(err.Error(), "exceeds limit of 1024 bytes") {
+					t.Errorf("Call() error = %v (class %s), want a parse error naming the limit", err, ClassOf(err))
+				}
+			}
+		})
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	tests := []struct {
+		name          string
+		retryAfter    string
+		maxRetryAfter time.Duration
+		timeout       time.Duration
+		wantWait      time.Duration
+		wantErr       bool
+	}{
+		{name: "honored", retryAfter: "1", maxRetryAfter: DefaultMaxRetryAfter, wantWait: time.Second},
+		{name: "capped", retryAfter: "30", maxRetryAfter: 300 * time.Millisecond, wantWait: 300 * time.Millisecond},
+		{
+			name: "cut short by the deadline", retryAfter: "30", maxRetryAfter: time.Minute,
+			timeout: 500 * time.Millisecond, wantWait: 500 * time.Millisecond, wantErr: true,
+		},
+		{name: "ignored when disabled", retryAfter: "30", wantWait: retryBackoff},
+		{name: "missing", maxRetryAfter: DefaultMaxRetryAfter, wantWait: retryBackoff},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests int32
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				if atomic.AddInt32(&requests, 1) == 1 {
+					if tt.retryAfter != "" {
+						w.Header().Set("Retry-After", tt.retryAfter)
+					}
+					w.WriteHeader(http.StatusTooManyRequests)
+					return
+				}
+				_, _ = io.WriteString(w, `{"jsonrpc":"2.0","id":1,"result":42}`)
+			}))
+			defer srv.Close()
+
+			ctx := context.Background()
+			if tt.timeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, tt.timeout)
+				defer cancel()
+			}
+
+			c := NewRPCClient(srv.URL, WithRetries(1), WithMaxRetryAfter(tt.maxRetryAfter))
+			start := time.Now()
+			_, err := c.Call(ctx, "getSlot", nil)
+			elapsed := time.Since(start)
+
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Call() error = %v, want error %v", err, tt.wantErr)
+			}
+			if err != nil && ClassOf(err) != ErrorClassRateLimited {
+				t.Errorf("Call() error class = %s, want %s", ClassOf(err), ErrorClassRateLimited)
+			}
+			if elapsed < tt.wantWait || elapsed > tt.wantWait+250*time.Millisecond {
+				t.Errorf("Call() took %v, want a wait of %v", elapsed, tt.wantWait)
+			}
+		})
+	}
+}
+
+func TestWithHeaders(t *testing.T) {
+	var got http.Header
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header
+		_, _ = io.WriteString(w, `{"jsonrpc":"2.0","id":1,"result":"ok"}`)
+	}))
+	defer srv.Close()
+
+	h := make(http.Header)
+	h.Add("X-Api-Key", "key1")
+	h.Add("X-Tenant", "a")
+	h.Add("X-Tenant", "b")
+	if _, err := NewRPCClient(srv.URL, WithHeaders(h)).GetHealth(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if got.Get("X-Api-Key") != "key1" || strings.Join(got["X-Tenant"], ",") != "a,b" {
+		t.Errorf("sent headers %v, want X-Api-Key: key1 and X-Tenant: a, b", got)
+	}
+	if got.Get("Content-Type") != "application/json" {
+		t.Errorf("Content-Type = %q, want application/json", got.Get("Content-Type"))
+	}
+}
+
+// A complete response followed by an endless body must be cut off at the limit rather than read on until the
+// request times out.
+func TestMaxBodyBytesLimitsDrain(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, `{"jsonrpc":"2.0","id":1,"result":42}`)
+		padding := []byte(strings.Repeat(" ", 4096))
+		for r.Context().Err() == nil {
+			if _, err := w.Write(padding); err != nil {
+				return
+			}
+		}
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	start := time.Now()
+	_, err := NewRPCClient(srv.URL, WithMaxBodyBytes(64*1024), WithRetries(0)).Call(ctx, "getSlot", nil)
+	if err == nil || !strings.Contains(err.Error(), "exceeds limit") {
+		t.Errorf("Call() error = %v, want the limit to be exceeded", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("request took %v, the body was drained past the limit", elapsed)
+	}
+}
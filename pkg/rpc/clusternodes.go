@@ -0,0 +1,43 @@
+package rpc
+
+import (
+	"context"
+)
+
+type (
+	ClusterNode struct {
+		// Node identity pubkey
+		Pubkey string `json:"pubkey"`
+		// Gossip network address, null if not advertised
+		Gossip *string `json:"gossip"`
+		// TPU network address, null if not advertised
+		TPU *string `json:"tpu"`
+		// JSON RPC network address, null if the RPC service is not enabled
+		RPC *string `json:"rpc"`
+		// Software version, null if not available
+		Version *string `json:"version"`
+		// Unique identifier of the node's feature set, null if not available
+		FeatureSet *uint32 `json:"featureSet"`
+		// Shred version the node has been configured to use, null if not available
+		ShredVersion *uint16 `json:"shredVersion"`
+	}
+
+	GetClusterNodesResponse struct {
+		Result []ClusterNode `json:"result"`
+		Error  rpcError      `json:"error"`
+	}
+)
+
+// https://docs.solana.com/developing/clients/jsonrpc-api#getclusternodes
+func (c *RPCClient) GetClusterNodes(ctx context.Context) ([]ClusterNode, error) {
+	var resp GetClusterNodesResponse
+	if err := c.rpcRequest(ctx, formatRPCRequest("getClusterNodes", []interface{}{}), &resp); err != nil {
+		return nil, err
+	}
+
+	if resp.Error.Code != 0 {
+		return nil, newRPCError("getClusterNodes", resp.Error)
+	}
+
+	return resp.Result, nil
+}
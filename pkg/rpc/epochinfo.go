@@ -2,9 +2,6 @@ package rpc
 
 import (
 	"context"
-	"encoding/json"
-	"fmt"
-	"k8s.io/klog/v2"
 )
 
 type (
@@ -20,7 +17,7 @@ type (
 		// Number of slots in this epoch
 		SlotsInEpoch int64 `json:"slotsInEpoch"`
 		// Total number of transactions ever (?)
-		TransactionCount int64 `json:"transactionCount"`
+		TransactionCount Int64 `json:"transactionCount"`
 	}
 
 	GetEpochInfoResponse struct {
@@ -31,20 +28,13 @@ type (
 
 // https://docs.solana.com/developing/clients/jsonrpc-api#getepochinfo
 func (c *RPCClient) GetEpochInfo(ctx context.Context, commitment Commitment) (*EpochInfo, error) {
-	body, err := c.rpcRequest(ctx, formatRPCRequest("getEpochInfo", []interface{}{commitment}))
-	if err != nil {
-		return nil, fmt.Errorf("RPC call failed: %w", err)
-	}
-
-	klog.V(2).Infof("epoch info response: %v", string(body))
-
 	var resp GetEpochInfoResponse
-	if err = json.Unmarshal(body, &resp); err != nil {
-		return nil, fmt.Errorf("failed to decode response body: %w", err)
+	if err := c.rpcRequest(ctx, formatRPCRequest("getEpochInfo", []interface{}{commitment}), &resp); err != nil {
+		return nil, err
 	}
 
 	if resp.Error.Code != 0 {
-		return nil, fmt.Errorf("RPC error: %d %v", resp.Error.Code, resp.Error.Message)
+		return nil, newRPCError("getEpochInfo", resp.Error)
 	}
 
 	return &resp.Result, nil
@@ -0,0 +1,164 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// ErrorClass groups RPC failures by cause, which decides whether a request is retried.
+type ErrorClass string
+
+const (
+	// The request timed out.
+	ErrorClassTimeout ErrorClass = "timeout"
+	// The endpoint could not be reached.
+	ErrorClassConnection ErrorClass = "connection"
+	// The endpoint rejected the request with HTTP 429.
+	ErrorClassRateLimited ErrorClass = "rate_limited"
+	// The endpoint failed to handle the request (HTTP 5xx or a JSON-RPC server error).
+	ErrorClassServer ErrorClass = "server"
+	// The request itself was rejected (HTTP 4xx or a JSON-RPC request error).
+	ErrorClassClient ErrorClass = "client"
+	// The response could not be decoded.
+	ErrorClassParse ErrorClass = "parse"
+)
+
+// RequestError is an RPC failure along with its class.
+type RequestError struct {
+	Class ErrorClass
+	Err   error
+
+	// How long the endpoint asked to wait before the next request, zero if it didn't say.
+	retryAfter time.Duration
+	// HTTP status of the response, zero if the request failed before one arrived or the error is unrelated to it.
+	status int
+}
+
+func (e *RequestError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *RequestError) Unwrap() error {
+	return e.Err
+}
+
+// newRequestError wraps err with its class.
+func newRequestError(class ErrorClass, err error) error {
+	return &RequestError{Class: class, Err: err}
+}
+
+// newStatusError is newRequestError for a response with a non-2xx HTTP status.
+func newStatusError(status int, err error) error {
+	return &RequestError{Class: classifyStatus(status), Err: err, status: status}
+}
+
+// errorCode returns the code label of err in solana_rpc_errors_total: the HTTP status if there is one, empty
+// if the request failed without a response.
+func errorCode(err error) string {
+	var reqErr *RequestError
+	if !errors.As(err, &reqErr) || reqErr.status == 0 {
+		return ""
+	}
+
+	return strconv.Itoa(reqErr.status)
+}
+
+// retryAfterOf returns how long the endpoint asked to wait after err, or zero if it didn't.
+func retryAfterOf(err error) time.Duration {
+	var reqErr *RequestError
+	if errors.As(err, &reqErr) {
+		return reqErr.retryAfter
+	}
+
+	return 0
+}
+
+// newRateLimitError is newRequestError for a rate limited request, which may come with the time to wait before
+// the next one.
+func newRateLimitError(retryAfter time.Duration, err error) error {
+	return &RequestError{Class: ErrorClassRateLimited, Err: err, retryAfter: retryAfter,
+		status: http.StatusTooManyRequests}
+}
+
+// ClassOf returns the class of an error returned by RPCClient, or an empty class if it is unclassified.
+func ClassOf(err error) ErrorClass {
+	var reqErr *RequestError
+	if errors.As(err, &reqErr) {
+		return reqErr.Class
+	}
+
+	return ""
+}
+
+// endpointFailed reports whether err is a failure of the endpoint rather than of the request itself.
+func endpointFailed(err error) bool {
+	switch ClassOf(err) {
+	case ErrorClassTimeout, ErrorClassConnection, ErrorClassRateLimited, ErrorClassServer:
+		return true
+	}
+
+	return false
+}
+
+// retriable reports whether the request can succeed when sent again. Timeouts are only retried if the
+// caller's context has time left, i.e. the timeout happened below the HTTP client.
+func retriable(ctx context.Context, err error) bool {
+	return endpointFailed(err) && ctx.Err() == nil
+}
+
+// classifyTransportError classifies an error returned by http.Client.Do.
+func classifyTransportError(err error) ErrorClass {
+	var netErr net.Error
+	if errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()) {
+		return ErrorClassTimeout
+	}
+
+	return ErrorClassConnection
+}
+
+// classifyStatus classifies a non-2xx HTTP status.
+func classifyStatus(code int) ErrorClass {
+	switch {
+	case code == http.StatusTooManyRequests:
+		return ErrorClassRateLimited
+	case code >= 500:
+		return ErrorClassServer
+	default:
+		return ErrorClassClient
+	}
+}
+
+// parseRetryAfter parses the value of a Retry-After header, which is either a number of seconds or an HTTP
+// date. It returns zero if the header is missing, malformed or in the past.
+func parseRetryAfter(header string, now time.Time) time.Duration {
+	if header == "" {
+		return 0
+	}
+
+	if secs, err := strconv.Atoi(header); err == nil {
+		if secs < 0 {
+			return 0
+		}
+		return time.Duration(secs) * time.Second
+	}
+
+	if t, err := http.ParseTime(header); err == nil && t.After(now) {
+		return t.Sub(now)
+	}
+
+	return 0
+}
+
+// classifyRPCError classifies a JSON-RPC error object by its code. Codes from -32600 to -32700 are
+// reserved for invalid requests, everything else is reported by the node while handling the request.
+func classifyRPCError(e rpcError) ErrorClass {
+	if e.Code <= -32600 && e.Code >= -32700 && e.Code != -32603 {
+		return ErrorClassClient
+	}
+
+	return ErrorClassServer
+}
@@ -0,0 +1,225 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestClassifyStatus(t *testing.T) {
+	tests := []struct {
+		status int
+		want   ErrorClass
+	}{
+		{http.StatusTooManyRequests, ErrorClassRateLimited},
+		{http.StatusInternalServerError, ErrorClassServer},
+		{http.StatusBadGateway, ErrorClassServer},
+		{http.StatusServiceUnavailable, ErrorClassServer},
+		{http.StatusBadRequest, ErrorClassClient},
+		{http.StatusUnauthorized, ErrorClassClient},
+		{http.StatusForbidden, ErrorClassClient},
+		{http.StatusNotFound, ErrorClassClient},
+	}
+
+	for _, tt := range tests {
+		if got := classifyStatus(tt.status); got != tt.want {
+			t.Errorf("classifyStatus(%d) = %s, want %s", tt.status, got, tt.want)
+		}
+	}
+}
+
+func TestClassifyRPCError(t *testing.T) {
+	tests := []struct {
+		code int64
+		want ErrorClass
+	}{
+		{-32700, ErrorClassClient}, // parse error
+		{-32600, ErrorClassClient}, // invalid request
+		{-32601, ErrorClassClient}, // method not found
+		{-32602, ErrorClassClient}, // invalid params
+		{-32603, ErrorClassServer}, // internal error
+		{-32004, ErrorClassServer}, // block not available
+		{-32005, ErrorClassServer}, // node unhealthy
+		{-32701, ErrorClassServer},
+		{-32599, ErrorClassServer},
+	}
+
+	for _, tt := range tests {
+		if got := classifyRPCError(rpcError{Code: tt.code}); got != tt.want {
+			t.Errorf("classifyRPCError(%d) = %s, want %s", tt.code, got, tt.want)
+		}
+	}
+}
+
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+func TestClassifyTransportError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want ErrorClass
+	}{
+		{"deadline exceeded", fmt.Errorf("Post: %w", context.DeadlineExceeded), ErrorClassTimeout},
+		{"network timeout", fmt.Errorf("Post: %w", timeoutError{}), ErrorClassTimeout},
+		{"connection refused", errors.New("dial tcp: connection refused"), ErrorClassConnection},
+		{"canceled", context.Canceled, ErrorClassConnection},
+	}
+
+	for _, tt := range tests {
+		if got := classifyTransportError(tt.err); got != tt.want {
+			t.Errorf("%s: classifyTransportError() = %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
+
+// Errors returned by RPCClient carry their class and are counted by class, method and code. Only authorization
+// failures are counted as such.
+func TestRequestErrorClass(t *testing.T) {
+	tests := []struct {
+		name      string
+		status    int
+		body      string
+		wantClass ErrorClass
+		wantCode  string
+		wantAuth  bool
+	}{
+		{name: "HTTP 500", status: http.StatusInternalServerError, wantClass: ErrorClassServer, wantCode: "500"},
+		{name: "HTTP 429", status: http.StatusTooManyRequests, wantClass: ErrorClassRateLimited, wantCode: "429"},
+		{name: "HTTP 401", status: http.StatusUnauthorized, wantClass: ErrorClassClient, wantCode: "401", wantAuth: true},
+		{name: "HTTP 404", status: http.StatusNotFound, wantClass: ErrorClassClient, wantCode: "404"},
+		{
+			name:      "method not found",
+			body:      `{"jsonrpc":"2.0","id":1,"error":{"code":-32601,"message":"Method not found"}}`,
+			wantClass: ErrorClassClient,
+			wantCode:  "-32601",
+		},
+		{
+			name:      "rejected API key",
+			body:      `{"jsonrpc":"2.0","id":1,"error":{"code":-32600,"message":"Unauthorized"}}`,
+			wantClass: ErrorClassClient,
+			wantCode:  "-32600",
+			wantAuth:  true,
+		},
+		{
+			name:      "node error",
+			body:      `{"jsonrpc":"2.0","id":1,"error":{"code":-32603,"message":"Internal error"}}`,
+			wantClass: ErrorClassServer,
+			wantCode:  "-32603",
+		},
+		{name: "malformed body", body: `{"jsonrpc":`, wantClass: ErrorClassParse},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				if tt.status != 0 {
+					w.WriteHeader(tt.status)
+				}
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer srv.Close()
+
+			counted := rpcErrorsTotal.WithLabelValues(string(tt.wantClass), "getHealth", tt.wantCode)
+			errorsBefore := testutil.ToFloat64(counted)
+			authBefore := testutil.ToFloat64(authErrorsTotal)
+			_, err := NewRPCClient(srv.URL).GetHealth(context.Background())
+
+			var reqErr *RequestError
+			if !errors.As(err, &reqErr) {
+				t.Fatalf("GetHealth() error = %v, want a RequestError", err)
+			}
+			if got := ClassOf(err); got != tt.wantClass {
+				t.Errorf("ClassOf() = %s, want %s", got, tt.wantClass)
+			}
+			if got := testutil.ToFloat64(counted) - errorsBefore; got != 1 {
+				t.Errorf("solana_rpc_errors_total{class=%q,code=%q} went up by %v, want 1", tt.wantClass, tt.wantCode, got)
+			}
+			if got := testutil.ToFloat64(authErrorsTotal) - authBefore; (got == 1) != tt.wantAuth {
+				t.Errorf("auth errors went up by %v, want auth error %v", got, tt.wantAuth)
+			}
+		})
+	}
+
+	t.Run("unreachable", func(t *testing.T) {
+		srv := httptest.NewServer(http.NotFoundHandler())
+		addr := srv.URL
+		srv.Close()
+
+		_, err := NewRPCClient(addr).GetHealth(context.Background())
+		if got := ClassOf(err); got != ErrorClassConnection {
+			t.Errorf("ClassOf(%v) = %s, want %s", err, got, ErrorClassConnection)
+		}
+	})
+}
+
+func TestRetriesRetriableClasses(t *testing.T) {
+	tests := []struct {
+		name      string
+		failures  int
+		status    int
+		wantCalls int
+		wantErr   bool
+	}{
+		{name: "server errors are retried", failures: 2, status: http.StatusBadGateway, wantCalls: 3},
+		{name: "rate limits are retried", failures: 1, status: http.StatusTooManyRequests, wantCalls: 2},
+		{name: "retries run out", failures: 5, status: http.StatusInternalServerError, wantCalls: 3, wantErr: true},
+		{name: "client errors are not retried", failures: 5, status: http.StatusBadRequest, wantCalls: 1, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls int
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				calls++
+				if calls <= tt.failures {
+					w.WriteHeader(tt.status)
+					return
+				}
+				_, _ = w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":"ok"}`))
+			}))
+			defer srv.Close()
+
+			_, err := NewRPCClient(srv.URL, WithRetries(2)).GetHealth(context.Background())
+			if (err != nil) != tt.wantErr {
+				t.Errorf("GetHealth() error = %v, want error %v", err, tt.wantErr)
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("sent %d requests, want %d", calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2021, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		header string
+		want   time.Duration
+	}{
+		{header: "", want: 0},
+		{header: "5", want: 5 * time.Second},
+		{header: "0", want: 0},
+		{header: "-1", want: 0},
+		{header: "Tue, 01 Jun 2021 12:00:30 GMT", want: 30 * time.Second},
+		// Dates in the past mean no wait.
+		{header: "Tue, 01 Jun 2021 11:59:00 GMT", want: 0},
+		{header: "soon", want: 0},
+	}
+
+	for _, tt := range tests {
+		if got := parseRetryAfter(tt.header, now); got != tt.want {
+			t.Errorf("parseRetryAfter(%q) = %v, want %v", tt.header, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package rpc
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// countingServer answers getHealth with status, or ok if status is 200, and counts the requests.
+func countingServer(t *testing.T, status *int32) (*httptest.Server, *int32) {
+	var requests int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		if code := int(atomic.LoadInt32(status)); code != http.StatusOK {
+			w.WriteHeader(code)
+			return
+		}
+		_, _ = w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":"ok"}`))
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv, &requests
+}
+
+func TestFailover(t *testing.T) {
+	primaryStatus, fallbackStatus := int32(http.StatusServiceUnavailable), int32(http.StatusOK)
+	primary, primaryRequests := countingServer(t, &primaryStatus)
+	fallback, fallbackRequests := countingServer(t, &fallbackStatus)
+
+	c := NewRPCClient(primary.URL, WithRetries(0), WithFallbacks(fallback.URL))
+	if _, err := c.GetHealth(context.Background()); err != nil {
+		t.Fatalf("GetHealth() with a working fallback = %v", err)
+	}
+	if got := testutil.ToFloat64(endpointUp.WithLabelValues(endpointHost(primary.URL))); got != 0 {
+		t.Errorf("primary endpoint up = %v, want 0", got)
+	}
+	if got := testutil.ToFloat64(endpointUp.WithLabelValues(endpointHost(fallback.URL))); got != 1 {
+		t.Errorf("fallback endpoint up = %v, want 1", got)
+	}
+
+	// Requests stick to the fallback while it works.
+	if _, err := c.GetHealth(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := atomic.LoadInt32(primaryRequests), int32(1); got != want {
+		t.Errorf("primary got %d requests, want %d", got, want)
+	}
+	if got, want := atomic.LoadInt32(fallbackRequests), int32(2); got != want {
+		t.Errorf("fallback got %d requests, want %d", got, want)
+	}
+
+	// Once the fallback fails too, requests wrap around to the primary endpoint.
+	atomic.StoreInt32(&primaryStatus, http.StatusOK)
+	atomic.StoreInt32(&fallbackStatus, http.StatusTooManyRequests)
+	if _, err := c.GetHealth(context.Background()); err != nil {
+		t.Fatalf("GetHealth() after the primary endpoint recovered = %v", err)
+	}
+	if got := c.activeAddr(); got != primary.URL {
+		t.Errorf("active endpoint = %s, want the primary %s", got, primary.URL)
+	}
+}
+
+func TestNoFailoverOnClientError(t *testing.T) {
+	primaryStatus, fallbackStatus := int32(http.StatusNotFound), int32(http.StatusOK)
+	primary, _ := countingServer(t, &primaryStatus)
+	fallback, fallbackRequests := countingServer(t, &fallbackStatus)
+
+	c := NewRPCClient(primary.URL, WithRetries(0), WithFallbacks(fallback.URL))
+	if _, err := c.GetHealth(context.Background()); err == nil {
+		t.Fatal("GetHealth() succeeded against an endpoint answering 404")
+	}
+	if got := atomic.LoadInt32(fallbackRequests); got != 0 {
+		t.Errorf("fallback got %d requests after a client error, want none", got)
+	}
+}
+
+func TestEndpointHost(t *testing.T) {
+	for addr, want := range map[string]string{
+		"https://rpc.example.com/v1/secret-key?token=abc": "rpc.example.com",
+		"http://127.0.0.1:8899":                           "127.0.0.1:8899",
+		"not a url":                                       "invalid",
+	} {
+		if got := endpointHost(addr); got != want {
+			t.Errorf("endpointHost(%q) = %q, want %q", addr, got, want)
+		}
+	}
+}
@@ -3,9 +3,8 @@ package rpc
 import (
 	"context"
 	"encoding/json"
-	"fmt"
-
-	"k8s.io/klog/v2"
+	"regexp"
+	"strconv"
 )
 
 type (
@@ -13,30 +12,70 @@ type (
 		Result string   `json:"result"`
 		Error  rpcError `json:"error"`
 	}
-)
 
-// https://docs.solana.com/developing/clients/jsonrpc-api#gethealth
-func (c *RPCClient) GetHealth(ctx context.Context) (bool, error) {
-	body, err := c.rpcRequest(ctx, formatRPCRequest("getHealth", []interface{}{}))
+	// Health is the state of the node as reported by getHealth.
+	Health struct {
+		Healthy bool
+		// Number of slots the node is behind the cluster, nil if it is healthy or didn't say.
+		NumSlotsBehind *int64
+	}
 
-	if body == nil {
-		return false, fmt.Errorf("RPC call failed: Body empty")
+	unhealthyData struct {
+		NumSlotsBehind *int64 `json:"numSlotsBehind"`
 	}
+)
+
+// JSON-RPC error code returned by getHealth for an unhealthy node.
+const rpcCodeNodeUnhealthy = -32005
+
+// Nodes before numSlotsBehind was added to the error data only report it in the message.
+var slotsBehindMessage = regexp.MustCompile(`behind by (\d+) slots`)
 
+// https://docs.solana.com/developing/clients/jsonrpc-api#gethealth
+func (c *RPCClient) GetHealth(ctx context.Context) (bool, error) {
+	health, err := c.GetHealthStatus(ctx)
 	if err != nil {
-		return false, fmt.Errorf("RPC call failed: %w", err)
+		return false, err
 	}
 
-	klog.V(2).Infof("health response: %v", string(body))
+	return health.Healthy, nil
+}
 
+// GetHealthStatus is GetHealth including how far an unhealthy node is behind. An unhealthy node is reported as
+// such rather than as an error.
+func (c *RPCClient) GetHealthStatus(ctx context.Context) (*Health, error) {
 	var resp GetHealthResponse
-	if err = json.Unmarshal(body, &resp); err != nil {
-		return false, fmt.Errorf("failed to decode response body: %w", err)
+	if err := c.rpcRequest(ctx, formatRPCRequest("getHealth", []interface{}{}), &resp); err != nil {
+		return nil, err
 	}
 
+	if resp.Error.Code == rpcCodeNodeUnhealthy {
+		return &Health{NumSlotsBehind: parseSlotsBehind(resp.Error)}, nil
+	}
 	if resp.Error.Code != 0 {
-		return false, fmt.Errorf("RPC error: %d %v", resp.Error.Code, resp.Error.Message)
+		return nil, newRPCError("getHealth", resp.Error)
+	}
+
+	// Old nodes answer with "behind" or "unknown" instead of an error.
+	return &Health{Healthy: resp.Result == "ok"}, nil
+}
+
+// parseSlotsBehind extracts the number of slots an unhealthy node is behind from a getHealth error. Depending on
+// the version, it is in the error data, only in the message, or not known at all ("Node is unhealthy").
+func parseSlotsBehind(e rpcError) *int64 {
+	var data unhealthyData
+	if len(e.Data) > 0 && json.Unmarshal(e.Data, &data) == nil && data.NumSlotsBehind != nil {
+		return data.NumSlotsBehind
+	}
+
+	m := slotsBehindMessage.FindStringSubmatch(e.Message)
+	if m == nil {
+		return nil
+	}
+	n, err := strconv.ParseInt(m[1], 10, 64)
+	if err != nil {
+		return nil
 	}
 
-	return resp.Result == "ok", nil
+	return &n
 }
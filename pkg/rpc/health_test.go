@@ -0,0 +1,74 @@
+package rpc
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestGetHealthStatus(t *testing.T) {
+	behind := func(n int64) *int64 { return &n }
+
+	tests := []struct {
+		name        string
+		body        string
+		wantHealthy bool
+		wantBehind  *int64
+		wantErr     bool
+	}{
+		{name: "healthy", body: `{"jsonrpc":"2.0","id":1,"result":"ok"}`, wantHealthy: true},
+		{
+			name: "slots behind in data",
+			body: `{"jsonrpc":"2.0","id":1,"error":{"code":-32005,"message":"Node is behind by 42 slots",` +
+				`"data":{"numSlotsBehind":42}}}`,
+			wantBehind: behind(42),
+		},
+		{
+			name:       "slots behind in message only",
+			body:       `{"jsonrpc":"2.0","id":1,"error":{"code":-32005,"message":"Node is behind by 17 slots"}}`,
+			wantBehind: behind(17),
+		},
+		{
+			name: "unknown slots behind",
+			body: `{"jsonrpc":"2.0","id":1,"error":{"code":-32005,"message":"Node is unhealthy",` +
+				`"data":{"numSlotsBehind":null}}}`,
+		},
+		{name: "old behind result", body: `{"jsonrpc":"2.0","id":1,"result":"behind"}`},
+		{
+			name:    "other error",
+			body:    `{"jsonrpc":"2.0","id":1,"error":{"code":-32601,"message":"Method not found"}}`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer srv.Close()
+
+			health, err := NewRPCClient(srv.URL).GetHealthStatus(context.Background())
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("GetHealthStatus() = %+v, want an error", health)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if health.Healthy != tt.wantHealthy {
+				t.Errorf("Healthy = %v, want %v", health.Healthy, tt.wantHealthy)
+			}
+			switch {
+			case tt.wantBehind == nil && health.NumSlotsBehind != nil:
+				t.Errorf("NumSlotsBehind = %d, want nil", *health.NumSlotsBehind)
+			case tt.wantBehind != nil && (health.NumSlotsBehind == nil || *health.NumSlotsBehind != *tt.wantBehind):
+				t.Errorf("NumSlotsBehind = %v, want %d", health.NumSlotsBehind, *tt.wantBehind)
+			}
+		})
+	}
+}
@@ -0,0 +1,37 @@
+package rpc
+
+import (
+	"context"
+)
+
+type (
+	InflationRate struct {
+		// Total inflation, as an annual rate
+		Total float64 `json:"total"`
+		// Inflation allocated to validators, as an annual rate
+		Validator float64 `json:"validator"`
+		// Inflation allocated to the foundation, as an annual rate
+		Foundation float64 `json:"foundation"`
+		// Epoch for which these values are valid
+		Epoch int64 `json:"epoch"`
+	}
+
+	GetInflationRateResponse struct {
+		Result InflationRate `json:"result"`
+		Error  rpcError      `json:"error"`
+	}
+)
+
+// https://docs.solana.com/developing/clients/jsonrpc-api#getinflationrate
+func (c *RPCClient) GetInflationRate(ctx context.Context) (*InflationRate, error) {
+	var resp GetInflationRateResponse
+	if err := c.rpcRequest(ctx, formatRPCRequest("getInflationRate", []interface{}{}), &resp); err != nil {
+		return nil, err
+	}
+
+	if resp.Error.Code != 0 {
+		return nil, newRPCError("getInflationRate", resp.Error)
+	}
+
+	return &resp.Result, nil
+}
@@ -0,0 +1,48 @@
+package rpc
+
+import (
+	"context"
+	"fmt"
+)
+
+type (
+	InflationReward struct {
+		// Epoch for which the reward occurred
+		Epoch int64 `json:"epoch"`
+		// Slot in which the rewards are effective
+		EffectiveSlot int64 `json:"effectiveSlot"`
+		// Reward amount in lamports
+		Amount Int64 `json:"amount"`
+		// Post balance of the account in lamports
+		PostBalance Int64 `json:"postBalance"`
+		// Vote account commission when the reward was credited, only set for vote accounts
+		Commission *int `json:"commission"`
+	}
+
+	GetInflationRewardResponse struct {
+		Result []*InflationReward `json:"result"`
+		Error  rpcError           `json:"error"`
+	}
+)
+
+// GetInflationReward returns the inflation rewards of the given accounts for epoch, in the same order. An
+// entry is nil if the account received no reward.
+// https://docs.solana.com/developing/clients/jsonrpc-api#getinflationreward
+func (c *RPCClient) GetInflationReward(ctx context.Context, pubkeys []string, epoch int64) ([]*InflationReward, error) {
+	params := []interface{}{pubkeys, map[string]interface{}{"epoch": epoch}}
+
+	var resp GetInflationRewardResponse
+	if err := c.rpcRequest(ctx, formatRPCRequest("getInflationReward", params), &resp); err != nil {
+		return nil, err
+	}
+
+	if resp.Error.Code != 0 {
+		return nil, newRPCError("getInflationReward", resp.Error)
+	}
+
+	if len(resp.Result) != len(pubkeys) {
+		return nil, fmt.Errorf("requested %d inflation rewards, got %d", len(pubkeys), len(resp.Result))
+	}
+
+	return resp.Result, nil
+}
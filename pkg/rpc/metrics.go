@@ -0,0 +1,121 @@
+package rpc
+
+import (
+	"crypto/tls"
+	"net/url"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	authErrorsTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "solana_rpc_auth_errors_total",
+		Help: "Number of RPC requests rejected as unauthorized or not allowed",
+	})
+
+	rpcErrorsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "solana_rpc_errors_total",
+			Help: "Number of failed RPC requests by error class, method and JSON-RPC error code or HTTP status",
+		},
+		[]string{"class", "method", "code"})
+
+	tlsCertExpiry = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "solana_rpc_tls_cert_expiry_seconds",
+			Help: "Unix timestamp at which the first certificate presented by the HTTPS RPC endpoint expires",
+		},
+		[]string{"host"})
+
+	contextSlot = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "solana_rpc_context_slot",
+			Help: "Slot the latest response to an RPC method returning a context was evaluated at",
+		},
+		[]string{"method"})
+
+	endpointUp = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "solana_exporter_rpc_endpoint_up",
+			Help: "Whether the latest request to the RPC endpoint got an answer, by host",
+		},
+		[]string{"endpoint"})
+
+	requestDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name: "solana_exporter_rpc_request_duration_seconds",
+			Help: "Duration of RPC requests, including failed ones, by method",
+		},
+		[]string{"method"})
+
+	requestsTotal = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "solana_exporter_rpc_requests_total",
+		Help: "Number of RPC requests sent, including retries",
+	})
+)
+
+func init() {
+	prometheus.MustRegister(authErrorsTotal)
+	prometheus.MustRegister(tlsCertExpiry)
+	prometheus.MustRegister(rpcErrorsTotal)
+	prometheus.MustRegister(contextSlot)
+	prometheus.MustRegister(endpointUp)
+	prometheus.MustRegister(requestDuration)
+	prometheus.MustRegister(requestsTotal)
+}
+
+// observeRequest records a single attempt of a request. JSON-RPC errors come with a successful response and are
+// counted by newRPCError instead.
+func observeRequest(method string, d time.Duration, err error) {
+	requestsTotal.Inc()
+	requestDuration.WithLabelValues(method).Observe(d.Seconds())
+	if err != nil {
+		rpcErrorsTotal.WithLabelValues(string(ClassOf(err)), method, errorCode(err)).Inc()
+	}
+}
+
+// observeEndpoint records whether a request to addr got an answer, which includes JSON-RPC and client errors.
+func observeEndpoint(addr string, up bool) {
+	var v float64
+	if up {
+		v = 1
+	}
+
+	endpointUp.WithLabelValues(endpointHost(addr)).Set(v)
+}
+
+// endpointHost returns the host of an endpoint URL. The rest is left out of labels and logs, as providers may put
+// an API key in the path or query.
+func endpointHost(addr string) string {
+	u, err := url.Parse(addr)
+	if err != nil || u.Host == "" {
+		return "invalid"
+	}
+
+	return u.Host
+}
+
+// observeContextSlot records the slot from the context of a response. Responses of nodes that don't send a
+// context decode it as slot 0, which is ignored.
+func observeContextSlot(method string, slot int64) {
+	if slot > 0 {
+		contextSlot.WithLabelValues(method).Set(float64(slot))
+	}
+}
+
+// observeTLS records the earliest expiry in the certificate chain of a TLS connection.
+func observeTLS(host string, state *tls.ConnectionState) {
+	if state == nil || len(state.PeerCertificates) == 0 {
+		return
+	}
+
+	expiry := state.PeerCertificates[0].NotAfter
+	for _, cert := range state.PeerCertificates[1:] {
+		if cert.NotAfter.Before(expiry) {
+			expiry = cert.NotAfter
+		}
+	}
+
+	tlsCertExpiry.WithLabelValues(host).Set(float64(expiry.Unix()))
+}
@@ -0,0 +1,136 @@
+package rpc
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+)
+
+func TestObserveTLSUsesEarliestExpiry(t *testing.T) {
+	leaf := time.Date(2027, 3, 1, 0, 0, 0, 0, time.UTC)
+	intermediate := time.Date(2026, 12, 1, 0, 0, 0, 0, time.UTC)
+	state := &tls.ConnectionState{PeerCertificates: []*x509.Certificate{
+		{NotAfter: leaf}, {NotAfter: intermediate}, {NotAfter: time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)},
+	}}
+
+	observeTLS("chain.example:443", state)
+	if got := testutil.ToFloat64(tlsCertExpiry.WithLabelValues("chain.example:443")); got != float64(intermediate.Unix()) {
+		t.Errorf("solana_rpc_tls_cert_expiry_seconds = %v, want %v", got, intermediate.Unix())
+	}
+}
+
+func TestTLSCertExpiry(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = io.WriteString(w, `{"jsonrpc":"2.0","id":1,"result":"ok"}`)
+	})
+
+	srv := httptest.NewTLSServer(handler)
+	defer srv.Close()
+	c := NewRPCClient(srv.URL)
+	c.httpClient = *srv.Client()
+	if _, err := c.GetHealth(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	host := srv.Listener.Addr().String()
+	if got, want := testutil.ToFloat64(tlsCertExpiry.WithLabelValues(host)), float64(srv.Certificate().NotAfter.Unix()); got != want {
+		t.Errorf("solana_rpc_tls_cert_expiry_seconds{host=%q} = %v, want %v", host, got, want)
+	}
+
+	// Plain HTTP endpoints don't get a series.
+	plain := httptest.NewServer(handler)
+	defer plain.Close()
+	before := testutil.CollectAndCount(tlsCertExpiry)
+	if _, err := NewRPCClient(plain.URL).GetHealth(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if n := testutil.CollectAndCount(tlsCertExpiry); n != before {
+		t.Errorf("a plain HTTP request added %d series", n-before)
+	}
+}
+
+func TestContextSlot(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = io.WriteString(w, `{"jsonrpc":"2.0","id":1,"result":{"context":{"slot":1234},"value":{"total":100,`+
+			`"circulating":60,"nonCirculating":40,"nonCirculatingAccounts":[]}}}`)
+	}))
+	defer srv.Close()
+
+	if _, err := NewRPCClient(srv.URL).GetSupply(context.Background(), CommitmentFinalized); err != nil {
+		t.Fatal(err)
+	}
+	if got := testutil.ToFloat64(contextSlot.WithLabelValues("getSupply")); got != 1234 {
+		t.Errorf("solana_rpc_context_slot{method=\"getSupply\"} = %v, want 1234", got)
+	}
+
+	// A response without a context keeps the previous slot.
+	observeContextSlot("getSupply", 0)
+	if got := testutil.ToFloat64(contextSlot.WithLabelValues("getSupply")); got != 1234 {
+		t.Errorf("solana_rpc_context_slot{method=\"getSupply\"} = %v after a missing context, want 1234", got)
+	}
+}
+
+func TestRequestMetrics(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req rpcRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		switch req.Method {
+		case "getIdentity":
+			_, _ = io.WriteString(w, `{"jsonrpc":"2.0","id":1,"error":{"code":-32601,"message":"Method not found"}}`)
+		case "getHealth":
+			_, _ = io.WriteString(w, `{"jsonrpc":"2.0","id":1,"result":"ok"}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer srv.Close()
+
+	c := NewRPCClient(srv.URL)
+	requests := testutil.ToFloat64(requestsTotal)
+	rpcErrors := testutil.ToFloat64(rpcErrorsTotal.WithLabelValues(string(ErrorClassClient), "getIdentity", "-32601"))
+	statusErrors := testutil.ToFloat64(rpcErrorsTotal.WithLabelValues(string(ErrorClassClient), "getSlot", "404"))
+	healthRequests := histogramCount(t, "getHealth")
+
+	if _, err := c.GetHealth(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.GetIdentity(context.Background()); err == nil {
+		t.Fatal("GetIdentity succeeded, want the node's error")
+	}
+	if _, err := c.GetSlot(context.Background(), CommitmentProcessed); err == nil {
+		t.Fatal("GetSlot succeeded, want the HTTP error")
+	}
+
+	if got := testutil.ToFloat64(requestsTotal) - requests; got != 3 {
+		t.Errorf("solana_exporter_rpc_requests_total increased by %v, want 3", got)
+	}
+	if got := histogramCount(t, "getHealth") - healthRequests; got != 1 {
+		t.Errorf("solana_exporter_rpc_request_duration_seconds{method=\"getHealth\"} observed %d requests, want 1", got)
+	}
+	if got := testutil.ToFloat64(rpcErrorsTotal.WithLabelValues(string(ErrorClassClient), "getIdentity", "-32601")) - rpcErrors; got != 1 {
+		t.Errorf("JSON-RPC errors of getIdentity increased by %v, want 1", got)
+	}
+	if got := testutil.ToFloat64(rpcErrorsTotal.WithLabelValues(string(ErrorClassClient), "getSlot", "404")) - statusErrors; got != 1 {
+		t.Errorf("HTTP errors of getSlot increased by %v, want 1", got)
+	}
+}
+
+// histogramCount returns the number of requests of method observed in solana_exporter_rpc_request_duration_seconds.
+func histogramCount(t *testing.T, method string) uint64 {
+	var m dto.Metric
+	if err := requestDuration.WithLabelValues(method).(prometheus.Histogram).Write(&m); err != nil {
+		t.Fatal(err)
+	}
+
+	return m.GetHistogram().GetSampleCount()
+}
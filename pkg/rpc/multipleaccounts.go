@@ -0,0 +1,78 @@
+package rpc
+
+import (
+	"context"
+	"fmt"
+)
+
+const (
+	// Maximum number of pubkeys accepted by a single getMultipleAccounts call.
+	MaxMultipleAccounts = 100
+)
+
+type (
+	Account struct {
+		Executable bool   `json:"executable"`
+		Lamports   Int64  `json:"lamports"`
+		Owner      string `json:"owner"`
+		RentEpoch  int64  `json:"rentEpoch"`
+	}
+
+	GetMultipleAccountsResponse struct {
+		Result struct {
+			Context struct {
+				Slot int64 `json:"slot"`
+			} `json:"context"`
+			Value []*Account `json:"value"`
+		} `json:"result"`
+		Error rpcError `json:"error"`
+	}
+)
+
+// GetMultipleAccounts fetches the given accounts in batches of MaxMultipleAccounts. The result is aligned with
+// pubkeys, with nil entries for accounts that do not exist. Account data is not requested.
+//
+// https://docs.solana.com/developing/clients/jsonrpc-api#getmultipleaccounts
+func (c *RPCClient) GetMultipleAccounts(ctx context.Context, pubkeys []string, commitment Commitment) ([]*Account, error) {
+	accounts := make([]*Account, 0, len(pubkeys))
+
+	for start := 0; start < len(pubkeys); start += MaxMultipleAccounts {
+		end := start + MaxMultipleAccounts
+		if end > len(pubkeys) {
+			end = len(pubkeys)
+		}
+
+		chunk, err := c.getMultipleAccounts(ctx, pubkeys[start:end], commitment)
+		if err != nil {
+			return nil, err
+		}
+
+		accounts = append(accounts, chunk...)
+	}
+
+	return accounts, nil
+}
+
+func (c *RPCClient) getMultipleAccounts(ctx context.Context, pubkeys []string, commitment Commitment) ([]*Account, error) {
+	config := map[string]interface{}{
+		"encoding":   "base64",
+		"dataSlice":  map[string]int{"offset": 0, "length": 0},
+		"commitment": string(commitment),
+	}
+
+	var resp GetMultipleAccountsResponse
+	if err := c.rpcRequest(ctx, formatRPCRequest("getMultipleAccounts", []interface{}{pubkeys, config}), &resp); err != nil {
+		return nil, err
+	}
+
+	if resp.Error.Code != 0 {
+		return nil, newRPCError("getMultipleAccounts", resp.Error)
+	}
+	observeContextSlot("getMultipleAccounts", resp.Result.Context.Slot)
+
+	if len(resp.Result.Value) != len(pubkeys) {
+		return nil, fmt.Errorf("requested %d accounts, got %d", len(pubkeys), len(resp.Result.Value))
+	}
+
+	return resp.Result.Value, nil
+}
@@ -0,0 +1,91 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+func TestGetMultipleAccountsChunks(t *testing.T) {
+	tests := []struct {
+		n          int
+		wantChunks []int
+	}{
+		{n: 1, wantChunks: []int{1}},
+		{n: 100, wantChunks: []int{100}},
+		{n: 101, wantChunks: []int{100, 1}},
+		{n: 250, wantChunks: []int{100, 100, 50}},
+	}
+
+	for _, tt := range tests {
+		t.Run(strconv.Itoa(tt.n), func(t *testing.T) {
+			var mu sync.Mutex
+			var chunks []int
+			// Every account holds as many lamports as its number in the pubkey, and "missing" ones don't exist.
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				var req struct {
+					Params []json.RawMessage `json:"params"`
+				}
+				var pubkeys []string
+				if err := json.NewDecoder(r.Body).Decode(&req); err != nil || len(req.Params) == 0 {
+					http.Error(w, "bad request", http.StatusBadRequest)
+					return
+				}
+				_ = json.Unmarshal(req.Params[0], &pubkeys)
+				var config struct {
+					Commitment string `json:"commitment"`
+				}
+				if len(req.Params) < 2 || json.Unmarshal(req.Params[1], &config) != nil || config.Commitment != "confirmed" {
+					t.Errorf("got params %s, want the confirmed commitment", req.Params)
+				}
+				mu.Lock()
+				chunks = append(chunks, len(pubkeys))
+				mu.Unlock()
+
+				value := make([]interface{}, len(pubkeys))
+				for i, pubkey := range pubkeys {
+					var n int
+					if _, err := fmt.Sscanf(pubkey, "account%d", &n); err == nil {
+						value[i] = map[string]interface{}{"lamports": n, "owner": "11111111111111111111111111111111"}
+					}
+				}
+				_ = json.NewEncoder(w).Encode(map[string]interface{}{
+					"jsonrpc": "2.0", "id": 1,
+					"result": map[string]interface{}{"context": map[string]int{"slot": 1}, "value": value},
+				})
+			}))
+			defer srv.Close()
+
+			pubkeys := make([]string, tt.n)
+			for i := range pubkeys {
+				pubkeys[i] = fmt.Sprintf("account%d", i)
+			}
+			pubkeys[tt.n-1] = "missing"
+
+			accounts, err := NewRPCClient(srv.URL).GetMultipleAccounts(context.Background(), pubkeys, CommitmentConfirmed)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(chunks, tt.wantChunks) {
+				t.Errorf("requested chunks of %v accounts, want %v", chunks, tt.wantChunks)
+			}
+			if len(accounts) != tt.n {
+				t.Fatalf("got %d accounts, want %d", len(accounts), tt.n)
+			}
+			for i, account := range accounts[:tt.n-1] {
+				if account == nil || account.Lamports != Int64(i) {
+					t.Fatalf("account %d = %+v, want %d lamports", i, account, i)
+				}
+			}
+			if accounts[tt.n-1] != nil {
+				t.Errorf("missing account = %+v, want nil", accounts[tt.n-1])
+			}
+		})
+	}
+}
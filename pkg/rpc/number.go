@@ -0,0 +1,35 @@
+package rpc
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+)
+
+// Int64 is an integer that is decoded from either a JSON number or a JSON string. Some providers send u64
+// amounts like lamports as strings so they don't lose precision in JavaScript clients, which would otherwise
+// fail to decode.
+type Int64 int64
+
+func (n *Int64) UnmarshalJSON(b []byte) error {
+	if bytes.Equal(b, []byte("null")) {
+		return nil
+	}
+
+	s := string(b)
+	if len(b) > 0 && b[0] == '"' {
+		if err := json.Unmarshal(b, &s); err != nil {
+			return err
+		}
+	}
+
+	// Amounts are u64, but none come close to the largest int64.
+	v, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid integer %s: %w", b, err)
+	}
+
+	*n = Int64(v)
+	return nil
+}
@@ -0,0 +1,48 @@
+package rpc
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestInt64(t *testing.T) {
+	tests := []struct {
+		name    string
+		json    string
+		want    Int64
+		wantErr bool
+	}{
+		{name: "number", json: `42`, want: 42},
+		{name: "string", json: `"42"`, want: 42},
+		{name: "large", json: `"18000000000000000000"`, wantErr: true},
+		{name: "u64 amount", json: `"9000000000000000000"`, want: 9000000000000000000},
+		{name: "null", json: `null`},
+		{name: "not a number", json: `"abc"`, wantErr: true},
+		{name: "fraction", json: `1.5`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got Int64
+			err := json.Unmarshal([]byte(tt.json), &got)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Unmarshal(%s) error = %v, want error %v", tt.json, err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("Unmarshal(%s) = %d, want %d", tt.json, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSupplyWithStringAmounts(t *testing.T) {
+	var supply Supply
+	body := `{"total":"500000000000000000","circulating":300,"nonCirculating":"200","nonCirculatingAccounts":["a"]}`
+	if err := json.Unmarshal([]byte(body), &supply); err != nil {
+		t.Fatal(err)
+	}
+
+	if supply.Total != 500000000000000000 || supply.Circulating != 300 || supply.NonCirculating != 200 {
+		t.Errorf("decoded %+v", supply)
+	}
+}
@@ -0,0 +1,50 @@
+package rpc
+
+import (
+	"context"
+	"fmt"
+)
+
+const (
+	// Largest number of samples getRecentPerformanceSamples returns.
+	MaxPerformanceSamples = 720
+)
+
+type (
+	PerformanceSample struct {
+		// Slot in which the sample was taken
+		Slot int64 `json:"slot"`
+		// Number of transactions processed during the sample period
+		NumTransactions int64 `json:"numTransactions"`
+		// Number of slots completed during the sample period
+		NumSlots int64 `json:"numSlots"`
+		// Number of seconds in the sample window
+		SamplePeriodSecs int64 `json:"samplePeriodSecs"`
+	}
+
+	GetRecentPerformanceSamplesResponse struct {
+		Result []PerformanceSample `json:"result"`
+		Error  rpcError            `json:"error"`
+	}
+)
+
+// GetRecentPerformanceSamples returns up to limit performance samples, newest first. The node takes a sample
+// every 60 seconds.
+//
+// https://docs.solana.com/developing/clients/jsonrpc-api#getrecentperformancesamples
+func (c *RPCClient) GetRecentPerformanceSamples(ctx context.Context, limit int) ([]PerformanceSample, error) {
+	if limit < 1 || limit > MaxPerformanceSamples {
+		return nil, fmt.Errorf("performance sample limit %d out of range 1-%d", limit, MaxPerformanceSamples)
+	}
+
+	var resp GetRecentPerformanceSamplesResponse
+	if err := c.rpcRequest(ctx, formatRPCRequest("getRecentPerformanceSamples", []interface{}{limit}), &resp); err != nil {
+		return nil, err
+	}
+
+	if resp.Error.Code != 0 {
+		return nil, newRPCError("getRecentPerformanceSamples", resp.Error)
+	}
+
+	return resp.Result, nil
+}
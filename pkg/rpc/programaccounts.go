@@ -0,0 +1,52 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+)
+
+type GetProgramAccountsResponse struct {
+	// Accounts are left undecoded as only their number is of interest.
+	Result []json.RawMessage `json:"result"`
+	Error  rpcError          `json:"error"`
+}
+
+// Filter restricts getProgramAccounts to accounts matching it.
+type Filter map[string]interface{}
+
+// MemcmpFilter matches accounts whose data contains the base58 encoded bytes at offset.
+func MemcmpFilter(offset int, bytes string) Filter {
+	return Filter{"memcmp": map[string]interface{}{"offset": offset, "bytes": bytes}}
+}
+
+// DataSizeFilter matches accounts whose data is size bytes long.
+func DataSizeFilter(size int) Filter {
+	return Filter{"dataSize": size}
+}
+
+// GetProgramAccountCount returns the number of accounts owned by a program that match all filters. Account
+// data is not requested, but the response still lists every account and can be large for popular programs.
+//
+// https://docs.solana.com/developing/clients/jsonrpc-api#getprogramaccounts
+func (c *RPCClient) GetProgramAccountCount(ctx context.Context, programID string, commitment Commitment,
+	filters ...Filter) (int, error) {
+	config := map[string]interface{}{
+		"commitment": string(commitment),
+		"encoding":   "base64",
+		"dataSlice":  map[string]int{"offset": 0, "length": 0},
+	}
+	if len(filters) > 0 {
+		config["filters"] = filters
+	}
+
+	var resp GetProgramAccountsResponse
+	if err := c.rpcRequest(ctx, formatRPCRequest("getProgramAccounts", []interface{}{programID, config}), &resp); err != nil {
+		return 0, err
+	}
+
+	if resp.Error.Code != 0 {
+		return 0, newRPCError("getProgramAccounts", resp.Error)
+	}
+
+	return len(resp.Result), nil
+}
@@ -0,0 +1,24 @@
+package rpc
+
+import (
+	"context"
+)
+
+type GetSlotResponse struct {
+	Result int64    `json:"result"`
+	Error  rpcError `json:"error"`
+}
+
+// https://docs.solana.com/developing/clients/jsonrpc-api#getslot
+func (c *RPCClient) GetSlot(ctx context.Context, commitment Commitment) (int64, error) {
+	var resp GetSlotResponse
+	if err := c.rpcRequest(ctx, formatRPCRequest("getSlot", []interface{}{commitment}), &resp); err != nil {
+		return 0, err
+	}
+
+	if resp.Error.Code != 0 {
+		return 0, newRPCError("getSlot", resp.Error)
+	}
+
+	return resp.Result, nil
+}
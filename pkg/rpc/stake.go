@@ -0,0 +1,107 @@
+package rpc
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+const (
+	// StakeProgramID owns all stake accounts.
+	StakeProgramID = "Stake11111111111111111111111111111111111111"
+
+	// Size of a stake account's data.
+	stakeAccountSize = 200
+	// Offset of the delegation's vote pubkey in a stake account, after the state tag and the meta.
+	stakeVoterOffset = 124
+	// Offset of the delegated stake, followed by the activation and the deactivation epoch (all u64).
+	stakeDelegationOffset = 156
+	// Offset of the delegation's deactivation epoch, after the vote pubkey, stake and activation epoch.
+	stakeDeactivationEpochOffset = 172
+	// Deactivation epoch of a stake that was never deactivated (u64 max), base58 encoded.
+	stakeNotDeactivated = "jpXCZedGfVQ"
+)
+
+// DelegatorFilters returns the filters that match stake accounts delegated to votePubkey which haven't been
+// deactivated.
+func DelegatorFilters(votePubkey string) []Filter {
+	return []Filter{
+		DataSizeFilter(stakeAccountSize),
+		MemcmpFilter(stakeVoterOffset, votePubkey),
+		MemcmpFilter(stakeDeactivationEpochOffset, stakeNotDeactivated),
+	}
+}
+
+// GetDelegatorCount returns the number of stake accounts delegated to votePubkey which haven't been
+// deactivated. Stake that is still activating is included.
+func (c *RPCClient) GetDelegatorCount(ctx context.Context, votePubkey string, commitment Commitment) (int, error) {
+	return c.GetProgramAccountCount(ctx, StakeProgramID, commitment, DelegatorFilters(votePubkey)...)
+}
+
+type (
+	// Delegation is the part of a stake account's delegation that tells its stake apart by activation state.
+	// Epochs that were never set, like the deactivation epoch of stake that wasn't deactivated, are -1.
+	Delegation struct {
+		Stake             int64
+		ActivationEpoch   int64
+		DeactivationEpoch int64
+	}
+
+	GetStakeDelegationsResponse struct {
+		Result []struct {
+			Account struct {
+				// Base64 encoded data and the encoding.
+				Data []string `json:"data"`
+			} `json:"account"`
+		} `json:"result"`
+		Error rpcError `json:"error"`
+	}
+)
+
+// GetStakeDelegations returns the delegations of all stake accounts delegated to votePubkey, including
+// deactivated ones. Only the stake and epochs of each account are requested, but the call still scans the
+// whole stake program.
+func (c *RPCClient) GetStakeDelegations(ctx context.Context, votePubkey string,
+	commitment Commitment) ([]Delegation, error) {
+	config := map[string]interface{}{
+		"commitment": string(commitment),
+		"encoding":   "base64",
+		"dataSlice":  map[string]int{"offset": stakeDelegationOffset, "length": 24},
+		"filters":    []Filter{DataSizeFilter(stakeAccountSize), MemcmpFilter(stakeVoterOffset, votePubkey)},
+	}
+
+	var resp GetStakeDelegationsResponse
+	if err := c.rpcRequest(ctx, formatRPCRequest("getProgramAccounts", []interface{}{StakeProgramID, config}), &resp); err != nil {
+		return nil, err
+	}
+
+	if resp.Error.Code != 0 {
+		return nil, newRPCError("getProgramAccounts", resp.Error)
+	}
+
+	delegations := make([]Delegation, 0, len(resp.Result))
+	for _, account := range resp.Result {
+		if len(account.Account.Data) == 0 {
+			return nil, errors.New("stake account without data")
+		}
+
+		b, err := base64.StdEncoding.DecodeString(account.Account.Data[0])
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode stake account data: %w", err)
+		}
+		if len(b) != 24 {
+			return nil, fmt.Errorf("got %d bytes of stake account data, expected 24", len(b))
+		}
+
+		// u64 max, used for unset epochs, wraps around to -1.
+		delegations = append(delegations, Delegation{
+			Stake:             int64(binary.LittleEndian.Uint64(b[0:])),
+			ActivationEpoch:   int64(binary.LittleEndian.Uint64(b[8:])),
+			DeactivationEpoch: int64(binary.LittleEndian.Uint64(b[16:])),
+		})
+	}
+
+	return delegations, nil
+}
@@ -0,0 +1,51 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestGetDelegatorCount(t *testing.T) {
+	var params []json.RawMessage
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Params []json.RawMessage `json:"params"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		params = req.Params
+		_, _ = w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":[{"pubkey":"a"},{"pubkey":"b"}]}`))
+	}))
+	defer srv.Close()
+
+	count, err := NewRPCClient(srv.URL).GetDelegatorCount(context.Background(), "vote1", CommitmentFinalized)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 2 {
+		t.Errorf("GetDelegatorCount() = %d, want 2", count)
+	}
+
+	if len(params) != 2 || string(params[0]) != `"`+StakeProgramID+`"` {
+		t.Fatalf("getProgramAccounts params = %s, want the stake program and a config", params)
+	}
+	var config struct {
+		Commitment string                   `json:"commitment"`
+		Filters    []map[string]interface{} `json:"filters"`
+	}
+	if err := json.Unmarshal(params[1], &config); err != nil {
+		t.Fatal(err)
+	}
+	want := []map[string]interface{}{
+		{"dataSize": float64(200)},
+		{"memcmp": map[string]interface{}{"offset": float64(124), "bytes": "vote1"}},
+		// u64 max, the deactivation epoch of stake that was never deactivated.
+		{"memcmp": map[string]interface{}{"offset": float64(172), "bytes": "jpXCZedGfVQ"}},
+	}
+	if config.Commitment != "finalized" || !reflect.DeepEqual(config.Filters, want) {
+		t.Errorf("getProgramAccounts config = %s, want finalized commitment and filters %v", params[1], want)
+	}
+}
@@ -0,0 +1,43 @@
+package rpc
+
+import (
+	"context"
+)
+
+type (
+	Supply struct {
+		// Total supply in lamports
+		Total Int64 `json:"total"`
+		// Circulating supply in lamports
+		Circulating Int64 `json:"circulating"`
+		// Non-circulating supply in lamports
+		NonCirculating Int64 `json:"nonCirculating"`
+		// Addresses of non-circulating accounts
+		NonCirculatingAccounts []string `json:"nonCirculatingAccounts"`
+	}
+
+	GetSupplyResponse struct {
+		Result struct {
+			Context struct {
+				Slot int64 `json:"slot"`
+			} `json:"context"`
+			Value Supply `json:"value"`
+		} `json:"result"`
+		Error rpcError `json:"error"`
+	}
+)
+
+// https://docs.solana.com/developing/clients/jsonrpc-api#getsupply
+func (c *RPCClient) GetSupply(ctx context.Context, commitment Commitment) (*Supply, error) {
+	var resp GetSupplyResponse
+	if err := c.rpcRequest(ctx, formatRPCRequest("getSupply", []interface{}{commitment}), &resp); err != nil {
+		return nil, err
+	}
+
+	if resp.Error.Code != 0 {
+		return nil, newRPCError("getSupply", resp.Error)
+	}
+	observeContextSlot("getSupply", resp.Result.Context.Slot)
+
+	return &resp.Result.Value, nil
+}
@@ -0,0 +1,57 @@
+package rpc
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"sync"
+	"time"
+
+	"k8s.io/klog/v2"
+)
+
+// TokenFile reads a bearer token from a file. The file is read again once refresh has passed since the last
+// read, so rotated tokens are picked up without a restart.
+type TokenFile struct {
+	path    string
+	refresh time.Duration
+
+	mu     sync.Mutex
+	token  string
+	readAt time.Time
+}
+
+func NewTokenFile(path string, refresh time.Duration) *TokenFile {
+	return &TokenFile{path: path, refresh: refresh}
+}
+
+// Token returns the current token. If the file can't be read again, the previous token is kept.
+func (f *TokenFile) Token() (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if !f.readAt.IsZero() && time.Since(f.readAt) < f.refresh {
+		return f.token, nil
+	}
+
+	b, err := ioutil.ReadFile(f.path)
+	if err != nil {
+		if f.readAt.IsZero() {
+			return "", fmt.Errorf("failed to read token file: %w", err)
+		}
+		klog.Warningf("failed to read token file, keeping the previous token: %v", err)
+		return f.token, nil
+	}
+
+	f.token = strings.TrimSpace(string(b))
+	f.readAt = time.Now()
+
+	return f.token, nil
+}
+
+// WithTokenFile sends the token read from f as a bearer token in the Authorization header of every request.
+func WithTokenFile(f *TokenFile) Option {
+	return func(c *RPCClient) {
+		c.tokenFile = f
+	}
+}
@@ -0,0 +1,85 @@
+package rpc
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestTokenFileRotation(t *testing.T) {
+	dir, err := ioutil.TempDir("", "token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "token")
+
+	writeToken := func(token string) {
+		if err := ioutil.WriteFile(path, []byte(token), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var mu sync.Mutex
+	var header string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		header = r.Header.Get("Authorization")
+		mu.Unlock()
+		_, _ = io.WriteString(w, `{"jsonrpc":"2.0","id":1,"result":42}`)
+	}))
+	defer srv.Close()
+
+	const refresh = 100 * time.Millisecond
+	c := NewRPCClient(srv.URL, WithTokenFile(NewTokenFile(path, refresh)))
+
+	steps := []struct {
+		name   string
+		write  string
+		remove bool
+		wait   time.Duration
+		want   string
+	}{
+		{name: "initial token", write: "first\n", want: "Bearer first"},
+		{name: "rotated within the refresh interval", write: "second", want: "Bearer first"},
+		{name: "rotated token picked up", wait: refresh, want: "Bearer second"},
+		{name: "unreadable file keeps the token", remove: true, wait: refresh, want: "Bearer second"},
+		{name: "file back again", write: "third", wait: refresh, want: "Bearer third"},
+	}
+
+	for _, step := range steps {
+		if step.write != "" {
+			writeToken(step.write)
+		}
+		if step.remove {
+			if err := os.Remove(path); err != nil {
+				t.Fatal(err)
+			}
+		}
+		time.Sleep(step.wait)
+
+		if _, err := c.Call(context.Background(), "getSlot", nil); err != nil {
+			t.Fatalf("%s: %v", step.name, err)
+		}
+		mu.Lock()
+		got := header
+		mu.Unlock()
+		if got != step.want {
+			t.Errorf("%s: Authorization = %q, want %q", step.name, got, step.want)
+		}
+	}
+}
+
+func TestTokenFileMissing(t *testing.T) {
+	f := NewTokenFile(filepath.Join(os.TempDir(), "solana-exporter-no-such-token"), time.Minute)
+	if _, err := f.Token(); err == nil {
+		t.Error("Token() succeeded without a token file")
+	}
+}
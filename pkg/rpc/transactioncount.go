@@ -0,0 +1,24 @@
+package rpc
+
+import (
+	"context"
+)
+
+type GetTransactionCountResponse struct {
+	Result Int64    `json:"result"`
+	Error  rpcError `json:"error"`
+}
+
+// https://docs.solana.com/developing/clients/jsonrpc-api#gettransactioncount
+func (c *RPCClient) GetTransactionCount(ctx context.Context, commitment Commitment) (int64, error) {
+	var resp GetTransactionCountResponse
+	if err := c.rpcRequest(ctx, formatRPCRequest("getTransactionCount", []interface{}{commitment}), &resp); err != nil {
+		return 0, err
+	}
+
+	if resp.Error.Code != 0 {
+		return 0, newRPCError("getTransactionCount", resp.Error)
+	}
+
+	return int64(resp.Result), nil
+}
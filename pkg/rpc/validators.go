@@ -2,21 +2,22 @@ package rpc
 
 import (
 	"context"
-	"encoding/json"
-	"fmt"
+
 	"k8s.io/klog/v2"
 )
 
 type (
 	VoteAccount struct {
-		ActivatedStake   int64   `json:"activatedStake"`
-		Commission       int     `json:"commission"`
-		EpochCredits     [][]int `json:"epochCredits"`
-		EpochVoteAccount bool    `json:"epochVoteAccount"`
-		LastVote         int     `json:"lastVote"`
-		NodePubkey       string  `json:"nodePubkey"`
-		RootSlot         int     `json:"rootSlot"`
-		VotePubkey       string  `json:"votePubkey"`
+		ActivatedStake Int64 `json:"activatedStake"`
+		Commission     int   `json:"commission"`
+		// Entries are [epoch, credits, previousCredits]. They are decoded into arrays rather than slices, which
+		// saves an allocation per entry, i.e. several per validator, on every mainnet getVoteAccounts.
+		EpochCredits     [][3]int `json:"epochCredits"`
+		EpochVoteAccount bool     `json:"epochVoteAccount"`
+		LastVote         int      `json:"lastVote"`
+		NodePubkey       string   `json:"nodePubkey"`
+		RootSlot         int      `json:"rootSlot"`
+		VotePubkey       string   `json:"votePubkey"`
 	}
 
 	GetVoteAccountsResponse struct {
@@ -30,21 +31,105 @@ type (
 
 // https://docs.solana.com/developing/clients/jsonrpc-api#getvoteaccounts
 func (c *RPCClient) GetVoteAccounts(ctx context.Context, params []interface{}) (*GetVoteAccountsResponse, error) {
-	body, err := c.rpcRequest(ctx, formatRPCRequest("getVoteAccounts", params))
+	var resp GetVoteAccountsResponse
+	if err := c.rpcRequest(ctx, formatRPCRequest("getVoteAccounts", params), &resp); err != nil {
+		return nil, err
+	}
+
+	if resp.Error.Code != 0 {
+		return nil, newRPCError("getVoteAccounts", resp.Error)
+	}
+
+	return &resp, nil
+}
+
+// WithSplitVoteAccounts fetches the full vote account set as two getVoteAccounts calls, one for the current and
+// one for the delinquent accounts, for providers that truncate or time out on the whole set but support the
+// status filter. If either call fails, the set is fetched with a single call instead.
+func WithSplitVoteAccounts() Option {
+	return func(c *RPCClient) {
+		c.splitVoteAccounts = true
+	}
+}
+
+// GetAllVoteAccounts fetches the vote accounts of the whole cluster, split into a call for the current and one
+// for the delinquent accounts with WithSplitVoteAccounts.
+func (c *RPCClient) GetAllVoteAccounts(ctx context.Context, commitment Commitment) (*GetVoteAccountsResponse, error) {
+	if c.splitVoteAccounts {
+		resp, err := c.getSplitVoteAccounts(ctx, commitment)
+		if err == nil {
+			return resp, nil
+		}
+		klog.Warningf("failed to get current and delinquent vote accounts separately, fetching them at once: %v", err)
+	}
+
+	return c.GetVoteAccounts(ctx, []interface{}{map[string]string{"commitment": string(commitment)}})
+}
+
+// getSplitVoteAccounts merges the vote accounts fetched with the status filter set to current and to
+// delinquent. Each list is taken from the call asking for it, so nodes that ignore the filter still give
+// the right set, only not a smaller response.
+func (c *RPCClient) getSplitVoteAccounts(ctx context.Context, commitment Commitment) (*GetVoteAccountsResponse, error) {
+	current, err := c.GetVoteAccounts(ctx, []interface{}{
+		map[string]string{"commitment": string(commitment), "status": "current"}})
+	if err != nil {
+		return nil, err
+	}
+	delinquent, err := c.GetVoteAccounts(ctx, []interface{}{
+		map[string]string{"commitment": string(commitment), "status": "delinquent"}})
 	if err != nil {
-		return nil, fmt.Errorf("RPC call failed: %w", err)
+		return nil, err
 	}
 
-	klog.V(3).Infof("getVoteAccounts response: %v", string(body))
+	current.Result.Delinquent = delinquent.Result.Delinquent
+	return current, nil
+}
 
-	var resp GetVoteAccountsResponse
-	if err = json.Unmarshal(body, &resp); err != nil {
-		return nil, fmt.Errorf("failed to decode response body: %w", err)
+// GetVoteAccountsFor fetches the vote accounts of the given vote pubkeys with a single getVoteAccounts call.
+// The votePubkey filter only takes one pubkey, so a single account is fetched on its own, which keeps the
+// response small, while several are picked out of the whole cluster set. If the filtered call fails, e.g.
+// because the provider doesn't support the filter, the whole set is fetched instead. Current and delinquent
+// accounts are kept apart, and pubkeys unknown to the node are left out. When the accounts were picked out
+// of the whole set, it is returned as well, nil otherwise.
+func (c *RPCClient) GetVoteAccountsFor(ctx context.Context, commitment Commitment,
+	votePubkeys []string) (watched, all *GetVoteAccountsResponse, err error) {
+	if len(votePubkeys) == 1 {
+		params := map[string]string{"commitment": string(commitment), "votePubkey": votePubkeys[0]}
+		resp, err := c.GetVoteAccounts(ctx, []interface{}{params})
+		if err == nil {
+			// Nodes that don't know the filter ignore it and return every account.
+			return filterVoteAccounts(resp, votePubkeys), nil, nil
+		}
+		klog.Warningf("failed to get vote account %s, fetching all vote accounts: %v", votePubkeys[0], err)
 	}
 
-	if resp.Error.Code != 0 {
-		return nil, fmt.Errorf("RPC error: %d %v", resp.Error.Code, resp.Error.Message)
+	all, err = c.GetAllVoteAccounts(ctx, commitment)
+	if err != nil {
+		return nil, nil, err
 	}
 
-	return &resp, nil
+	return filterVoteAccounts(all, votePubkeys), all, nil
+}
+
+// filterVoteAccounts returns the accounts of resp with one of the given vote pubkeys, leaving resp as it is.
+func filterVoteAccounts(resp *GetVoteAccountsResponse, votePubkeys []string) *GetVoteAccountsResponse {
+	wanted := make(map[string]bool, len(votePubkeys))
+	for _, pubkey := range votePubkeys {
+		wanted[pubkey] = true
+	}
+
+	filter := func(accounts []VoteAccount) []VoteAccount {
+		var kept []VoteAccount
+		for _, account := range accounts {
+			if wanted[account.VotePubkey] {
+				kept = append(kept, account)
+			}
+		}
+		return kept
+	}
+	filtered := &GetVoteAccountsResponse{Error: resp.Error}
+	filtered.Result.Current = filter(resp.Result.Current)
+	filtered.Result.Delinquent = filter(resp.Result.Delinquent)
+
+	return filtered
 }
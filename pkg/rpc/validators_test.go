@@ -0,0 +1,393 @@
+package rpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+// voteAccountsFixture returns a getVoteAccounts response with n current validators, each with the five
+// epochCredits entries mainnet nodes return, followed by a delinquent one.
+func voteAccountsFixture(n int) []byte {
+	var buf bytes.Buffer
+	buf.WriteString(`{"jsonrpc":"2.0","id":1,"result":{"current":[`)
+	for i := 0; i < n; i++ {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		fmt.Fprintf(&buf, `{"activatedStake":%d,"commission":%d,"epochCredits":[`, 1000000000000+i, i%101)
+		for e := 0; e < 5; e++ {
+			if e > 0 {
+				buf.WriteByte(',')
+			}
+			fmt.Fprintf(&buf, `[%d,%d,%d]`, 400+e, 100000*(e+1)+i, 100000*e+i)
+		}
+		fmt.Fprintf(&buf, `],"epochVoteAccount":true,"lastVote":%d,"nodePubkey":"node%d","rootSlot":%d,"votePubkey":"vote%d"}`,
+			200000000+i, i, 199999968+i, i)
+	}
+	buf.WriteString(`],"delinquent":[{"activatedStake":"42","commission":100,"epochCredits":[],` +
+		`"epochVoteAccount":false,"lastVote":0,"nodePubkey":"nodeX","rootSlot":0,"votePubkey":"voteX"}]}}`)
+
+	return buf.Bytes()
+}
+
+func TestGetVoteAccountsLargeResponse(t *testing.T) {
+	const n = 5000
+	fixture := voteAccountsFixture(n)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write(fixture)
+	}))
+	defer srv.Close()
+
+	resp, err := NewRPCClient(srv.URL).GetVoteAccounts(context.Background(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(resp.Result.Current) != n {
+		t.Fatalf("got %d current accounts, want %d", len(resp.Result.Current), n)
+	}
+	last := resp.Result.Current[n-1]
+	want := VoteAccount{
+		ActivatedStake:   1000000000000 + n - 1,
+		Commission:       (n - 1) % 101,
+		EpochVoteAccount: true,
+		LastVote:         200000000 + n - 1,
+		NodePubkey:       fmt.Sprintf("node%d", n-1),
+		RootSlot:         199999968 + n - 1,
+		VotePubkey:       fmt.Sprintf("vote%d", n-1),
+	}
+	for e := 0; e < 5; e++ {
+		want.EpochCredits = append(want.EpochCredits, [3]int{400 + e, 100000*(e+1) + n - 1, 100000*e + n - 1})
+	}
+	if fmt.Sprint(last) != fmt.Sprint(want) {
+		t.Errorf("last current account = %+v, want %+v", last, want)
+	}
+
+	if len(resp.Result.Delinquent) != 1 {
+		t.Fatalf("got %d delinquent accounts, want 1", len(resp.Result.Delinquent))
+	}
+	if d := resp.Result.Delinquent[0]; d.VotePubkey != "voteX" || d.ActivatedStake != 42 || len(d.EpochCredits) != 0 {
+		t.Errorf("delinquent account = %+v", d)
+	}
+}
+
+// voteAccountsServer answers getVoteAccounts from a fixed cluster, honouring the votePubkey filter.
+func voteAccountsServer(t *testing.T, current, delinquent []VoteAccount) *httptest.Server {
+	filter := func(accounts []VoteAccount, pubkey string) []VoteAccount {
+		filtered := []VoteAccount{}
+		for _, account := range accounts {
+			if pubkey == "" || account.VotePubkey == pubkey {
+				filtered = append(filtered, account)
+			}
+		}
+		return filtered
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Params []map[string]string `json:"params"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		var pubkey string
+		if len(req.Params) > 0 {
+			pubkey = req.Params[0]["votePubkey"]
+		}
+
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"jsonrpc": "2.0", "id": 1,
+			"result": map[string]interface{}{
+				"current":    filter(current, pubkey),
+				"delinquent": filter(delinquent, pubkey),
+			},
+		})
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv
+}
+
+// Fetching the watched vote accounts separately must give the same accounts as picking them out of one call.
+func TestGetVoteAccountsForMerges(t *testing.T) {
+	current := []VoteAccount{
+		{VotePubkey: "vote1", NodePubkey: "node1", ActivatedStake: 100, EpochCredits: [][3]int{{5, 150, 100}}},
+		{VotePubkey: "vote2", NodePubkey: "node2", ActivatedStake: 200},
+		{VotePubkey: "vote3", NodePubkey: "node3", ActivatedStake: 300},
+	}
+	delinquent := []VoteAccount{{VotePubkey: "vote4", NodePubkey: "node4", ActivatedStake: 400}}
+	c := NewRPCClient(voteAccountsServer(t, current, delinquent).URL)
+	watched := []string{"vote3", "vote4", "vote1", "unknown"}
+
+	single, err := c.GetVoteAccounts(context.Background(), []interface{}{map[string]string{"commitment": "processed"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	pick := func(accounts []VoteAccount) []string {
+		var picked []string
+		for _, pubkey := range watched {
+			for _, account := range accounts {
+				if account.VotePubkey == pubkey {
+					picked = append(picked, fmt.Sprint(account))
+				}
+			}
+		}
+		return picked
+	}
+
+	merged, _, err := c.GetVoteAccountsFor(context.Background(), CommitmentProcessed, watched)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := pick(merged.Result.Current), pick(single.Result.Current); fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("merged current = %v, want %v", got, want)
+	}
+	if got, want := pick(merged.Result.Delinquent), pick(single.Result.Delinquent); fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("merged delinquent = %v, want %v", got, want)
+	}
+	if n := len(merged.Result.Current) + len(merged.Result.Delinquent); n != 3 {
+		t.Errorf("merged %d accounts, want the 3 watched ones the node knows", n)
+	}
+}
+
+func TestGetVoteAccountsFor(t *testing.T) {
+	// How the node handles the votePubkey filter.
+	const (
+		filters = iota
+		ignoresFilter
+		rejectsFilter
+		down
+	)
+
+	tests := []struct {
+		name           string
+		node           int
+		pubkeys        []string
+		wantFilters    []string // votePubkey of each call, empty for the full set
+		wantCurrent    []string
+		wantDelinquent []string
+		wantErr        bool
+	}{
+		{
+			name: "one current validator", pubkeys: []string{"voteA"},
+			wantFilters: []string{"voteA"}, wantCurrent: []string{"voteA"},
+		},
+		{
+			name: "one delinquent validator", pubkeys: []string{"voteC"},
+			wantFilters: []string{"voteC"}, wantDelinquent: []string{"voteC"},
+		},
+		{
+			name: "node ignoring the filter", node: ignoresFilter, pubkeys: []string{"voteA"},
+			wantFilters: []string{"voteA"}, wantCurrent: []string{"voteA"},
+		},
+		{
+			name: "falls back to the full set", node: rejectsFilter, pubkeys: []string{"voteC"},
+			wantFilters: []string{"voteC", ""}, wantDelinquent: []string{"voteC"},
+		},
+		{
+			name: "several validators from one full set", pubkeys: []string{"voteA", "voteC", "voteD"},
+			wantFilters: []string{""}, wantCurrent: []string{"voteA"}, wantDelinquent: []string{"voteC"},
+		},
+		{
+			name: "node down", node: down, pubkeys: []string{"voteA"},
+			wantFilters: []string{"voteA", ""}, wantErr: true,
+		},
+	}
+
+	accounts := map[string][]string{"current": {"voteA", "voteB"}, "delinquent": {"voteC"}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var mu sync.Mutex
+			var filters []string
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				var req struct {
+					Params []map[string]string `json:"params"`
+				}
+				_ = json.NewDecoder(r.Body).Decode(&req)
+				filter := req.Params[0]["votePubkey"]
+				mu.Lock()
+				filters = append(filters, filter)
+				mu.Unlock()
+
+				switch {
+				case tt.node == down:
+					http.Error(w, "unavailable", http.StatusServiceUnavailable)
+					return
+				case tt.node == rejectsFilter && filter != "":
+					fmt.Fprint(w, `{"jsonrpc":"2.0","id":1,"error":{"code":-32602,"message":"Invalid params"}}`)
+					return
+				}
+
+				result := make(map[string][]map[string]string)
+				for state, pubkeys := range accounts {
+					result[state] = []map[string]string{}
+					for _, pubkey := range pubkeys {
+						if filter == "" || tt.node == ignoresFilter || filter == pubkey {
+							result[state] = append(result[state], map[string]string{"votePubkey": pubkey})
+						}
+					}
+				}
+				_ = json.NewEncoder(w).Encode(map[string]interface{}{"jsonrpc": "2.0", "id": 1, "result": result})
+			}))
+			defer srv.Close()
+
+			resp, all, err := NewRPCClient(srv.URL, WithRetries(0)).GetVoteAccountsFor(context.Background(),
+				CommitmentConfirmed, tt.pubkeys)
+			if !reflect.DeepEqual(filters, tt.wantFilters) {
+				t.Errorf("calls filtered by %q, want %q", filters, tt.wantFilters)
+			}
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GetVoteAccountsFor() error = %v, want error %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			// The whole set is handed out when the watched accounts were picked out of it.
+			if fullSet := tt.wantFilters[len(tt.wantFilters)-1] == ""; (all != nil) != fullSet {
+				t.Errorf("got the whole set %v, want it %v", all != nil, fullSet)
+			}
+			if all != nil && len(all.Result.Current)+len(all.Result.Delinquent) != 3 {
+				t.Errorf("whole set = %+v, want all 3 accounts", all.Result)
+			}
+
+			pubkeys := func(accounts []VoteAccount) []string {
+				var pubkeys []string
+				for _, account := range accounts {
+					pubkeys = append(pubkeys, account.VotePubkey)
+				}
+				return pubkeys
+			}
+			if got := pubkeys(resp.Result.Current); !reflect.DeepEqual(got, tt.wantCurrent) {
+				t.Errorf("current = %v, want %v", got, tt.wantCurrent)
+			}
+			if got := pubkeys(resp.Result.Delinquent); !reflect.DeepEqual(got, tt.wantDelinquent) {
+				t.Errorf("delinquent = %v, want %v", got, tt.wantDelinquent)
+			}
+		})
+	}
+}
+
+// A split fetch gives the same set as a single call, also from nodes that ignore the status filter, and falls
+// back to the single call if the filter is rejected.
+func TestGetAllVoteAccountsSplit(t *testing.T) {
+	// How the node handles the status filter.
+	const (
+		filters = iota
+		ignoresFilter
+		rejectsFilter
+	)
+
+	tests := []struct {
+		name         string
+		node         int
+		wantStatuses []string // status of each call, empty for the full set
+	}{
+		{name: "split", wantStatuses: []string{"current", "delinquent"}},
+		{name: "node ignoring the filter", node: ignoresFilter, wantStatuses: []string{"current", "delinquent"}},
+		{name: "falls back to a single call", node: rejectsFilter, wantStatuses: []string{"current", ""}},
+	}
+
+	current := []VoteAccount{
+		{VotePubkey: "vote1", NodePubkey: "node1", ActivatedStake: 100, EpochCredits: [][3]int{{5, 150, 100}}},
+		{VotePubkey: "vote2", NodePubkey: "node2", ActivatedStake: 200},
+	}
+	delinquent := []VoteAccount{{VotePubkey: "vote3", NodePubkey: "node3", ActivatedStake: 300}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var mu sync.Mutex
+			var statuses []string
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				var req struct {
+					Params []map[string]string `json:"params"`
+				}
+				_ = json.NewDecoder(r.Body).Decode(&req)
+				status := req.Params[0]["status"]
+				mu.Lock()
+				statuses = append(statuses, status)
+				mu.Unlock()
+
+				if tt.node == rejectsFilter && status != "" {
+					fmt.Fprint(w, `{"jsonrpc":"2.0","id":1,"error":{"code":-32602,"message":"Invalid params"}}`)
+					return
+				}
+				result := map[string][]VoteAccount{"current": {}, "delinquent": {}}
+				if status != "delinquent" || tt.node == ignoresFilter {
+					result["current"] = current
+				}
+				if status != "current" || tt.node == ignoresFilter {
+					result["delinquent"] = delinquent
+				}
+				_ = json.NewEncoder(w).Encode(map[string]interface{}{"jsonrpc": "2.0", "id": 1, "result": result})
+			}))
+			defer srv.Close()
+
+			single, err := NewRPCClient(srv.URL).GetAllVoteAccounts(context.Background(), CommitmentConfirmed)
+			if err != nil {
+				t.Fatal(err)
+			}
+			statuses = nil
+
+			split, err := NewRPCClient(srv.URL, WithRetries(0), WithSplitVoteAccounts()).GetAllVoteAccounts(
+				context.Background(), CommitmentConfirmed)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(statuses, tt.wantStatuses) {
+				t.Errorf("calls with status %q, want %q", statuses, tt.wantStatuses)
+			}
+			if !reflect.DeepEqual(split.Result, single.Result) {
+				t.Errorf("split fetch = %+v, want %+v as from a single call", split.Result, single.Result)
+			}
+		})
+	}
+}
+
+// sliceVoteAccounts is the layout vote accounts were decoded into before, with a slice per epochCredits entry.
+type sliceVoteAccounts struct {
+	Result struct {
+		Current []struct {
+			ActivatedStake   Int64   `json:"activatedStake"`
+			Commission       int     `json:"commission"`
+			EpochCredits     [][]int `json:"epochCredits"`
+			EpochVoteAccount bool    `json:"epochVoteAccount"`
+			LastVote         int     `json:"lastVote"`
+			NodePubkey       string  `json:"nodePubkey"`
+			RootSlot         int     `json:"rootSlot"`
+			VotePubkey       string  `json:"votePubkey"`
+		} `json:"current"`
+	} `json:"result"`
+}
+
+// BenchmarkDecodeVoteAccounts decodes a mainnet-sized getVoteAccounts response. Compare the allocations of
+// the current layout with the previous one, and with decoding without a target type.
+func BenchmarkDecodeVoteAccounts(b *testing.B) {
+	fixture := voteAccountsFixture(2000)
+
+	targets := []struct {
+		name string
+		new  func() interface{}
+	}{
+		{"targeted", func() interface{} { return new(GetVoteAccountsResponse) }},
+		{"slices", func() interface{} { return new(sliceVoteAccounts) }},
+		{"generic", func() interface{} { return new(map[string]interface{}) }},
+	}
+
+	for _, target := range targets {
+		b.Run(target.name, func(b *testing.B) {
+			b.ReportAllocs()
+			b.SetBytes(int64(len(fixture)))
+			for i := 0; i < b.N; i++ {
+				if err := json.NewDecoder(bytes.NewReader(fixture)).Decode(target.new()); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
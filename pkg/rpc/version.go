@@ -2,10 +2,6 @@ package rpc
 
 import (
 	"context"
-	"encoding/json"
-	"fmt"
-
-	"k8s.io/klog/v2"
 )
 
 type (
@@ -18,25 +14,13 @@ type (
 )
 
 func (c *RPCClient) GetVersion(ctx context.Context) (*string, error) {
-	body, err := c.rpcRequest(ctx, formatRPCRequest("getVersion", []interface{}{}))
-
-	if body == nil {
-		return nil, fmt.Errorf("RPC call failed: Body empty")
-	}
-
-	if err != nil {
-		return nil, fmt.Errorf("RPC call failed: %w", err)
-	}
-
-	klog.V(2).Infof("version response: %v", string(body))
-
 	var resp GetVersionResponse
-	if err = json.Unmarshal(body, &resp); err != nil {
-		return nil, fmt.Errorf("failed to decode response body: %w", err)
+	if err := c.rpcRequest(ctx, formatRPCRequest("getVersion", []interface{}{}), &resp); err != nil {
+		return nil, err
 	}
 
 	if resp.Error.Code != 0 {
-		return nil, fmt.Errorf("RPC error: %d %v", resp.Error.Code, resp.Error.Message)
+		return nil, newRPCError("getVersion", resp.Error)
 	}
 
 	return &resp.Result.Version, nil
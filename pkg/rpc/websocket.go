@@ -0,0 +1,189 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"k8s.io/klog/v2"
+)
+
+type (
+	// WSClient subscribes to notifications from the PubSub WebSocket API of a node, usually served on the RPC
+	// port plus one.
+	WSClient struct {
+		wsAddr string
+		// Additional headers sent with the handshake, like the API keys of RPC providers.
+		header http.Header
+
+		// Time within which a message or a pong has to arrive, after which the connection is given up.
+		readTimeout time.Duration
+		// Interval of the pings keeping quiet connections alive. Their pongs count as frames.
+		pingInterval time.Duration
+		// Limit for the size of a message, including all its fragments.
+		maxMessageBytes int
+	}
+
+	// SlotInfo is a slotSubscribe notification, sent when the node starts processing a slot.
+	SlotInfo struct {
+		Parent int64 `json:"parent"`
+		Root   int64 `json:"root"`
+		Slot   int64 `json:"slot"`
+	}
+
+	// Vote is a voteSubscribe notification, sent for every vote the node observes in gossip. The vote pubkey is
+	// only included by newer nodes.
+	Vote struct {
+		VotePubkey string  `json:"votePubkey"`
+		Slots      []int64 `json:"slots"`
+		Hash       string  `json:"hash"`
+		Timestamp  *int64  `json:"timestamp"`
+	}
+
+	wsMessage struct {
+		ID     int             `json:"id"`
+		Result json.RawMessage `json:"result"`
+		Error  rpcError        `json:"error"`
+		Method string          `json:"method"`
+		Params struct {
+			Result json.RawMessage `json:"result"`
+		} `json:"params"`
+	}
+)
+
+const (
+	// Slots are notified every 400ms, and quiet vote subscriptions are kept alive by pings, so a connection
+	// that stays silent for much longer is broken.
+	wsReadTimeout      = 30 * time.Second
+	wsPingInterval     = 10 * time.Second
+	wsWriteTimeout     = 10 * time.Second
+	wsHandshakeTimeout = 10 * time.Second
+
+	// Notifications are small, anything near this size is not one.
+	wsMaxMessageBytes = 1 << 20
+)
+
+func NewWSClient(wsAddr string, header http.Header) *WSClient {
+	return &WSClient{
+		wsAddr:          wsAddr,
+		header:          header,
+		readTimeout:     wsReadTimeout,
+		pingInterval:    wsPingInterval,
+		maxMessageBytes: wsMaxMessageBytes,
+	}
+}
+
+// SubscribeSlots calls notify for every slotSubscribe notification until ctx is done or the connection fails.
+// It always returns a non-nil error.
+func (c *WSClient) SubscribeSlots(ctx context.Context, notify func(SlotInfo)) error {
+	return c.subscribe(ctx, "slotSubscribe", func(result json.RawMessage) error {
+		var info SlotInfo
+		if err := json.Unmarshal(result, &info); err != nil {
+			return err
+		}
+		notify(info)
+		return nil
+	})
+}
+
+// SubscribeVotes calls notify for every voteSubscribe notification until ctx is done or the connection fails.
+// Nodes only serve it with --rpc-pubsub-enable-vote-subscription. It always returns a non-nil error.
+func (c *WSClient) SubscribeVotes(ctx context.Context, notify func(Vote)) error {
+	return c.subscribe(ctx, "voteSubscribe", func(result json.RawMessage) error {
+		var vote Vote
+		if err := json.Unmarshal(result, &vote); err != nil {
+			return err
+		}
+		notify(vote)
+		return nil
+	})
+}
+
+// subscribe opens a connection of its own for a subscription without parameters and passes the result of each
+// notification to notify.
+func (c *WSClient) subscribe(ctx context.Context, method string, notify func(json.RawMessage) error) error {
+	dialer := websocket.Dialer{Proxy: http.ProxyFromEnvironment, HandshakeTimeout: wsHandshakeTimeout}
+	conn, resp, err := dialer.DialContext(ctx, c.wsAddr, c.header)
+	if err != nil {
+		if errors.Is(err, websocket.ErrBadHandshake) && resp != nil {
+			err = fmt.Errorf("handshake failed: HTTP %s", resp.Status)
+		}
+		return fmt.Errorf("failed to connect to %s: %w", endpointHost(c.wsAddr), err)
+	}
+	defer conn.Close()
+
+	conn.SetReadLimit(int64(c.maxMessageBytes))
+	keepReading := func() {
+		if c.readTimeout > 0 {
+			_ = conn.SetReadDeadline(time.Now().Add(c.readTimeout))
+		}
+	}
+	// Pings of the server are answered by the default handler, the pongs to ours show the connection is alive.
+	conn.SetPongHandler(func(string) error {
+		keepReading()
+		return nil
+	})
+
+	// Unblock the read below once ctx is done, and keep the connection alive until then.
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		ticker := time.NewTicker(c.pingInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				conn.Close()
+				return
+			case <-ticker.C:
+				if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(wsWriteTimeout)); err != nil {
+					klog.V(1).Infof("failed to ping %s: %v", endpointHost(c.wsAddr), err)
+					conn.Close()
+					return
+				}
+			case <-stop:
+				return
+			}
+		}
+	}()
+
+	_ = conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+	if err := conn.WriteJSON(&rpcRequest{Version: "2.0", ID: 1, Method: method, Params: []interface{}{}}); err != nil {
+		return err
+	}
+
+	for {
+		keepReading()
+		_, b, err := conn.ReadMessage()
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			if errors.Is(err, websocket.ErrReadLimit) {
+				return fmt.Errorf("websocket message exceeds limit of %d bytes", c.maxMessageBytes)
+			}
+			return err
+		}
+
+		var msg wsMessage
+		if err := json.Unmarshal(b, &msg); err != nil {
+			return fmt.Errorf("failed to decode %s message: %w", method, err)
+		}
+
+		switch {
+		case msg.Error.Code != 0:
+			return newRPCError(method, msg.Error)
+		case msg.Method == "":
+			klog.V(1).Infof("%s subscription %s confirmed", method, msg.Result)
+		default:
+			if err := notify(msg.Params.Result); err != nil {
+				return fmt.Errorf("failed to decode %s notification: %w", method, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,260 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// Messages longer than the write buffer are sent in several frames, so the client has to join them.
+var testUpgrader = websocket.Upgrader{WriteBufferSize: 64}
+
+// serveWebSocket accepts WebSocket connections and hands each of them to serve.
+func serveWebSocket(t *testing.T, serve func(*websocket.Conn)) string {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := testUpgrader.Upgrade(w, r, nil)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer conn.Close()
+
+		serve(conn)
+	}))
+	t.Cleanup(srv.Close)
+
+	return "ws" + strings.TrimPrefix(srv.URL, "http")
+}
+
+// confirmSubscription reads the subscription request, which must be for method, and confirms it. The server
+// fails to read frames that the client didn't mask.
+func confirmSubscription(t *testing.T, conn *websocket.Conn, method string) {
+	var req rpcRequest
+	if err := conn.ReadJSON(&req); err != nil || req.Method != method {
+		t.Errorf("got request %+v (%v), want %s", req, err, method)
+	}
+	write(t, conn, `{"jsonrpc":"2.0","result":1,"id":1}`)
+}
+
+func write(t *testing.T, conn *websocket.Conn, msg string) {
+	if err := conn.WriteMessage(websocket.TextMessage, []byte(msg)); err != nil {
+		t.Error(err)
+	}
+}
+
+// waitClosed reads from conn until the client goes away, answering its pings.
+func waitClosed(conn *websocket.Conn) {
+	for {
+		if _, _, err := conn.ReadMessage(); err != nil {
+			return
+		}
+	}
+}
+
+func slotNotification(slot int) string {
+	return fmt.Sprintf(`{"jsonrpc":"2.0","method":"slotNotification","params":{"result":`+
+		`{"parent":%d,"root":%d,"slot":%d},"subscription":1}}`, slot-1, slot-32, slot)
+}
+
+func TestSubscribeSlots(t *testing.T) {
+	pongs := make(chan string, 1)
+	addr := serveWebSocket(t, func(conn *websocket.Conn) {
+		confirmSubscription(t, conn, "slotSubscribe")
+		conn.SetPongHandler(func(payload string) error {
+			pongs <- payload
+			return nil
+		})
+
+		// A ping between the frames of a message is answered without disturbing it.
+		w, err := conn.NextWriter(websocket.TextMessage)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		msg := slotNotification(100)
+		_, _ = w.Write([]byte(msg[:80]))
+		if err := conn.WriteControl(websocket.PingMessage, []byte("ping"), time.Now().Add(time.Second)); err != nil {
+			t.Error(err)
+		}
+		_, _ = w.Write([]byte(msg[80:]))
+		_ = w.Close()
+
+		write(t, conn, slotNotification(101))
+		// Read the pong before closing.
+		_ = conn.SetReadDeadline(time.Now().Add(time.Second))
+		_, _, _ = conn.NextReader()
+
+		_ = conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+		waitClosed(conn)
+	})
+
+	var slots []SlotInfo
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	err := NewWSClient(addr, nil).SubscribeSlots(ctx, func(info SlotInfo) { slots = append(slots, info) })
+	if !websocket.IsCloseError(err, websocket.CloseNormalClosure) {
+		t.Errorf("SubscribeSlots() = %v after the server closed the connection, want a normal closure", err)
+	}
+
+	want := []SlotInfo{{Parent: 99, Root: 68, Slot: 100}, {Parent: 100, Root: 69, Slot: 101}}
+	if len(slots) != len(want) || slots[0] != want[0] || slots[1] != want[1] {
+		t.Errorf("got slots %+v, want %+v", slots, want)
+	}
+	select {
+	case pong := <-pongs:
+		if pong != "ping" {
+			t.Errorf("pong payload = %q, want the ping's", pong)
+		}
+	default:
+		t.Error("ping wasn't answered")
+	}
+}
+
+func TestSubscribeVotesError(t *testing.T) {
+	addr := serveWebSocket(t, func(conn *websocket.Conn) {
+		_, _, _ = conn.ReadMessage()
+		write(t, conn, `{"jsonrpc":"2.0","error":{"code":-32601,"message":"Method not found"},"id":1}`)
+		waitClosed(conn)
+	})
+
+	err := NewWSClient(addr, nil).SubscribeVotes(context.Background(), func(Vote) { t.Error("got a vote") })
+	if err == nil || !strings.Contains(err.Error(), "Method not found") {
+		t.Errorf("SubscribeVotes() = %v, want the node's error", err)
+	}
+}
+
+func TestSubscribeStopsWithContext(t *testing.T) {
+	addr := serveWebSocket(t, func(conn *websocket.Conn) {
+		confirmSubscription(t, conn, "slotSubscribe")
+		// Keep the connection open without sending anything until the client goes away.
+		waitClosed(conn)
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if err := NewWSClient(addr, nil).SubscribeSlots(ctx, func(SlotInfo) {}); err != context.DeadlineExceeded {
+		t.Errorf("SubscribeSlots() = %v, want %v", err, context.DeadlineExceeded)
+	}
+}
+
+func TestSubscribeSendsHeaders(t *testing.T) {
+	var got http.Header
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header
+		http.Error(w, "forbidden", http.StatusForbidden)
+	}))
+	defer srv.Close()
+
+	header := http.Header{"X-Api-Key": {"key1"}}
+	err := NewWSClient("ws"+strings.TrimPrefix(srv.URL, "http"), header).SubscribeSlots(context.Background(),
+		func(SlotInfo) {})
+	if err == nil || !strings.Contains(err.Error(), "handshake failed: HTTP 403") {
+		t.Errorf("SubscribeSlots() = %v, want the failed handshake", err)
+	}
+	if got.Get("X-Api-Key") != "key1" {
+		t.Errorf("handshake headers %v, want X-Api-Key: key1", got)
+	}
+}
+
+func TestSubscribeFailures(t *testing.T) {
+	tests := []struct {
+		name    string
+		serve   func(conn *websocket.Conn)
+		wantErr string
+	}{
+		{
+			name: "silent node",
+			serve: func(conn *websocket.Conn) {
+				// Never answer, not even the pings.
+				b := make([]byte, 512)
+				for {
+					if _, err := conn.UnderlyingConn().Read(b); err != nil {
+						return
+					}
+				}
+			},
+			wantErr: "timeout",
+		},
+		{
+			name: "message over the limit",
+			serve: func(conn *websocket.Conn) {
+				write(t, conn, strings.Repeat("x", 5000))
+				waitClosed(conn)
+			},
+			wantErr: "exceeds limit of 4096 bytes",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			addr := serveWebSocket(t, func(conn *websocket.Conn) {
+				confirmSubscription(t, conn, "slotSubscribe")
+				tt.serve(conn)
+			})
+
+			c := NewWSClient(addr, nil)
+			c.readTimeout, c.pingInterval, c.maxMessageBytes = 300*time.Millisecond, time.Hour, 4096
+
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+
+			err := c.SubscribeSlots(ctx, func(SlotInfo) {})
+			if err == nil || ctx.Err() != nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("SubscribeSlots() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// A subscription without notifications stays open as long as the node answers the pings.
+
+func TestSubscribeKeepalive(t *testing.T) {
+	var pings int32
+	addr := serveWebSocket(t, func(conn *websocket.Conn) {
+		confirmSubscription(t, conn, "voteSubscribe")
+		conn.SetPingHandler(func(payload string) error {
+			atomic.AddInt32(&pings, 1)
+			return conn.WriteControl(websocket.PongMessage, []byte(payload), time.Now().Add(time.Second))
+		})
+		waitClosed(conn)
+	})
+
+	c := NewWSClient(addr, nil)
+	c.readTimeout, c.pingInterval = 300*time.Millisecond, 100*time.Millisecond
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	err := c.SubscribeVotes(ctx, func(Vote) {})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("SubscribeVotes() error = %v, want it to last until the deadline", err)
+	}
+	if n := atomic.LoadInt32(&pings); n < 5 {
+		t.Errorf("got %d pings, want one every 100ms", n)
+	}
+}
+
+// Notifications that fail to decode end the subscription, to be opened again.
+func TestSubscribeInvalidNotification(t *testing.T) {
+	addr := serveWebSocket(t, func(conn *websocket.Conn) {
+		confirmSubscription(t, conn, "slotSubscribe")
+		write(t, conn, `{"jsonrpc":"2.0","method":"slotNotification","params":{"result":{"slot":"x"}}}`)
+		waitClosed(conn)
+	})
+
+	err := NewWSClient(addr, nil).SubscribeSlots(context.Background(), func(SlotInfo) { t.Error("got a slot") })
+	var typeErr *json.UnmarshalTypeError
+	if !errors.As(err, &typeErr) {
+		t.Errorf("SubscribeSlots() = %v, want a decoding error", err)
+	}
+}